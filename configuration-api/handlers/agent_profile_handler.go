@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"github.com/storos/sdlc-agent/configuration-api/services"
+)
+
+// AgentProfileHandler handles HTTP requests for agent profiles
+type AgentProfileHandler struct {
+	service *services.AgentProfileService
+	logger  *logrus.Logger
+}
+
+// NewAgentProfileHandler creates a new agent profile handler
+func NewAgentProfileHandler(service *services.AgentProfileService, logger *logrus.Logger) *AgentProfileHandler {
+	return &AgentProfileHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetAgentProfiles returns all agent profiles
+// GET /api/agent-profiles
+func (h *AgentProfileHandler) GetAgentProfiles(c *gin.Context) {
+	profiles, err := h.service.GetAllProfiles(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get agent profiles")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+// GetAgentProfile returns an agent profile by ID
+// GET /api/agent-profiles/:id
+func (h *AgentProfileHandler) GetAgentProfile(c *gin.Context) {
+	id := c.Param("id")
+
+	profile, err := h.service.GetProfileByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAgentProfileID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent profile ID"})
+			return
+		}
+		if errors.Is(err, services.ErrAgentProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Agent profile not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get agent profile")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// CreateAgentProfile creates a new agent profile
+// POST /api/agent-profiles
+func (h *AgentProfileHandler) CreateAgentProfile(c *gin.Context) {
+	var req models.CreateAgentProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.service.CreateProfile(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAgentProfile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrDuplicateAgentProfileName) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Agent profile with this name already exists"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to create agent profile")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	h.logger.WithField("agent_profile_id", profile.ID.Hex()).Info("Agent profile created successfully")
+	c.JSON(http.StatusCreated, profile)
+}
+
+// UpdateAgentProfile updates an existing agent profile
+// PUT /api/agent-profiles/:id
+func (h *AgentProfileHandler) UpdateAgentProfile(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.UpdateAgentProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateProfile(c.Request.Context(), id, &req); err != nil {
+		if errors.Is(err, services.ErrInvalidAgentProfileID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent profile ID"})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidAgentProfile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrAgentProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Agent profile not found"})
+			return
+		}
+		if errors.Is(err, services.ErrDuplicateAgentProfileName) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Agent profile with this name already exists"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to update agent profile")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	h.logger.WithField("agent_profile_id", id).Info("Agent profile updated successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Agent profile updated successfully"})
+}
+
+// DeleteAgentProfile deletes an agent profile
+// DELETE /api/agent-profiles/:id
+func (h *AgentProfileHandler) DeleteAgentProfile(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteProfile(c.Request.Context(), id); err != nil {
+		if errors.Is(err, services.ErrInvalidAgentProfileID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent profile ID"})
+			return
+		}
+		if errors.Is(err, services.ErrAgentProfileInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrAgentProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Agent profile not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to delete agent profile")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	h.logger.WithField("agent_profile_id", id).Info("Agent profile deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Agent profile deleted successfully"})
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/configuration-api/models"
 	"github.com/storos/sdlc-agent/configuration-api/services"
 )
 
@@ -53,3 +55,58 @@ func (h *DevelopmentHandler) GetDevelopment(c *gin.Context) {
 
 	c.JSON(http.StatusOK, development)
 }
+
+// ApprovePlan approves the implementation plan of a development
+// POST /api/developments/:id/approve
+func (h *DevelopmentHandler) ApprovePlan(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.ApprovePlanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.service.ApprovePlan(c.Request.Context(), id, &req); err != nil {
+		h.handlePlanReviewError(c, id, err)
+		return
+	}
+
+	h.logger.WithField("id", id).Info("Plan approved")
+	c.JSON(http.StatusOK, gin.H{"message": "Plan approved"})
+}
+
+// RejectPlan rejects the implementation plan of a development with feedback
+// POST /api/developments/:id/reject
+func (h *DevelopmentHandler) RejectPlan(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.RejectPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.RejectPlan(c.Request.Context(), id, &req); err != nil {
+		h.handlePlanReviewError(c, id, err)
+		return
+	}
+
+	h.logger.WithField("id", id).Info("Plan rejected")
+	c.JSON(http.StatusOK, gin.H{"message": "Plan rejected"})
+}
+
+func (h *DevelopmentHandler) handlePlanReviewError(c *gin.Context, id string, err error) {
+	if errors.Is(err, services.ErrDevelopmentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Development not found"})
+		return
+	}
+	if errors.Is(err, services.ErrNotAwaitingApproval) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Development is not awaiting plan approval"})
+		return
+	}
+	h.logger.WithError(err).WithField("id", id).Error("Failed to review plan")
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"github.com/storos/sdlc-agent/configuration-api/services"
+)
+
+// ExperimentHandler handles HTTP requests for experiments
+type ExperimentHandler struct {
+	service *services.ExperimentService
+	logger  *logrus.Logger
+}
+
+// NewExperimentHandler creates a new experiment handler
+func NewExperimentHandler(service *services.ExperimentService, logger *logrus.Logger) *ExperimentHandler {
+	return &ExperimentHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetExperiments returns experiments, filtered by the jira_project_key and status query parameters
+// GET /api/experiments
+func (h *ExperimentHandler) GetExperiments(c *gin.Context) {
+	experiments, err := h.service.GetExperiments(c.Request.Context(), c.Query("jira_project_key"), c.Query("status"))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get experiments")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, experiments)
+}
+
+// GetExperiment returns an experiment by ID
+// GET /api/experiments/:id
+func (h *ExperimentHandler) GetExperiment(c *gin.Context) {
+	id := c.Param("id")
+
+	experiment, err := h.service.GetExperimentByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, id, "Failed to get experiment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+// CreateExperiment creates a new running experiment
+// POST /api/experiments
+func (h *ExperimentHandler) CreateExperiment(c *gin.Context) {
+	var req models.CreateExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	experiment, err := h.service.CreateExperiment(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "", "Failed to create experiment", err)
+		return
+	}
+
+	h.logger.WithField("experiment_id", experiment.ID.Hex()).Info("Experiment created successfully")
+	c.JSON(http.StatusCreated, experiment)
+}
+
+// UpdateExperiment updates an experiment, e.g. to stop it
+// PUT /api/experiments/:id
+func (h *ExperimentHandler) UpdateExperiment(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.UpdateExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateExperiment(c.Request.Context(), id, &req); err != nil {
+		h.handleError(c, id, "Failed to update experiment", err)
+		return
+	}
+
+	h.logger.WithField("experiment_id", id).Info("Experiment updated successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Experiment updated successfully"})
+}
+
+// DeleteExperiment deletes an experiment
+// DELETE /api/experiments/:id
+func (h *ExperimentHandler) DeleteExperiment(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteExperiment(c.Request.Context(), id); err != nil {
+		h.handleError(c, id, "Failed to delete experiment", err)
+		return
+	}
+
+	h.logger.WithField("experiment_id", id).Info("Experiment deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Experiment deleted successfully"})
+}
+
+// GetExperimentResults returns the outcomes aggregated per variant
+// GET /api/experiments/:id/results
+func (h *ExperimentHandler) GetExperimentResults(c *gin.Context) {
+	id := c.Param("id")
+
+	results, err := h.service.GetResults(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, id, "Failed to get experiment results", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// PromoteExperimentVariant applies a variant to the project and ends the experiment
+// POST /api/experiments/:id/promote
+func (h *ExperimentHandler) PromoteExperimentVariant(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.PromoteExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.PromoteVariant(c.Request.Context(), id, &req); err != nil {
+		h.handleError(c, id, "Failed to promote experiment variant", err)
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"experiment_id": id,
+		"variant":       req.Variant,
+	}).Info("Experiment variant promoted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Experiment variant promoted successfully"})
+}
+
+// handleError maps experiment service errors to HTTP responses
+func (h *ExperimentHandler) handleError(c *gin.Context, id, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidExperiment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrExperimentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Experiment not found"})
+	case errors.Is(err, services.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, services.ErrExperimentRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": "Project already has a running experiment"})
+	default:
+		h.logger.WithError(err).WithField("experiment_id", id).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -88,6 +88,10 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 
 	project, err := h.service.CreateProject(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidRepository) || errors.Is(err, services.ErrInvalidProject) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, services.ErrDuplicateProjectKey) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Project with this JIRA key already exists"})
 			return
@@ -113,6 +117,10 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateProject(c.Request.Context(), id, &req); err != nil {
+		if errors.Is(err, services.ErrInvalidRepository) || errors.Is(err, services.ErrInvalidProject) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, services.ErrProjectNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 			return
@@ -180,6 +188,10 @@ func (h *ProjectHandler) AddRepository(c *gin.Context) {
 	}
 
 	if err := h.service.AddRepository(c.Request.Context(), id, &req); err != nil {
+		if errors.Is(err, services.ErrInvalidRepository) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, services.ErrProjectNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 			return
@@ -211,6 +223,10 @@ func (h *ProjectHandler) UpdateRepository(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateRepository(c.Request.Context(), projectID, repoID, &req); err != nil {
+		if errors.Is(err, services.ErrInvalidRepository) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, services.ErrRepositoryNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found"})
 			return
@@ -113,7 +113,7 @@ func setupTestRouter(handler *ProjectHandler) *gin.Engine {
 
 func TestCreateProject_Success(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -147,7 +147,7 @@ func TestCreateProject_Success(t *testing.T) {
 
 func TestCreateProject_InvalidData(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -170,7 +170,7 @@ func TestCreateProject_InvalidData(t *testing.T) {
 
 func TestGetAllProjects(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -203,7 +203,7 @@ func TestGetAllProjects(t *testing.T) {
 
 func TestGetAllProjects_WithJiraKeyFilter(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -246,7 +246,7 @@ func TestGetAllProjects_WithJiraKeyFilter(t *testing.T) {
 
 func TestGetProjectByID_Success(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -278,7 +278,7 @@ func TestGetProjectByID_Success(t *testing.T) {
 
 func TestGetProjectByID_InvalidID(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -293,7 +293,7 @@ func TestGetProjectByID_InvalidID(t *testing.T) {
 
 func TestGetProjectByID_NotFound(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -309,7 +309,7 @@ func TestGetProjectByID_NotFound(t *testing.T) {
 
 func TestUpdateProject_Success(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -346,7 +346,7 @@ func TestUpdateProject_Success(t *testing.T) {
 
 func TestDeleteProject_Success(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -371,7 +371,7 @@ func TestDeleteProject_Success(t *testing.T) {
 
 func TestAddRepository_Success(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -406,7 +406,7 @@ func TestAddRepository_Success(t *testing.T) {
 
 func TestGetRepositories_Success(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -446,7 +446,7 @@ func TestGetRepositories_Success(t *testing.T) {
 
 func TestUpdateRepository_Success(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -488,7 +488,7 @@ func TestUpdateRepository_Success(t *testing.T) {
 
 func TestDeleteRepository_Success(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := services.NewProjectService(mockRepo)
+	service := services.NewProjectService(mockRepo, nil)
 	handler := NewProjectHandler(service)
 	router := setupTestRouter(handler)
 
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"github.com/storos/sdlc-agent/configuration-api/services"
+)
+
+// PromptTemplateHandler handles HTTP requests for prompt templates
+type PromptTemplateHandler struct {
+	service *services.PromptTemplateService
+	logger  *logrus.Logger
+}
+
+// NewPromptTemplateHandler creates a new prompt template handler
+func NewPromptTemplateHandler(service *services.PromptTemplateService, logger *logrus.Logger) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetPromptTemplates returns all prompt templates
+// GET /api/prompt-templates
+func (h *PromptTemplateHandler) GetPromptTemplates(c *gin.Context) {
+	templates, err := h.service.GetAllTemplates(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get prompt templates")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetPromptTemplate returns a prompt template by ID
+// GET /api/prompt-templates/:id
+func (h *PromptTemplateHandler) GetPromptTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	promptTemplate, err := h.service.GetTemplateByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrPromptTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt template not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get prompt template")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promptTemplate)
+}
+
+// CreatePromptTemplate creates a new prompt template
+// POST /api/prompt-templates
+func (h *PromptTemplateHandler) CreatePromptTemplate(c *gin.Context) {
+	var req models.CreatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promptTemplate, err := h.service.CreateTemplate(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPromptTemplate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrDuplicatePromptTemplateName) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Prompt template with this name already exists"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to create prompt template")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	h.logger.WithField("prompt_template_id", promptTemplate.ID.Hex()).Info("Prompt template created successfully")
+	c.JSON(http.StatusCreated, promptTemplate)
+}
+
+// UpdatePromptTemplate updates an existing prompt template
+// PUT /api/prompt-templates/:id
+func (h *PromptTemplateHandler) UpdatePromptTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.UpdatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateTemplate(c.Request.Context(), id, &req); err != nil {
+		if errors.Is(err, services.ErrInvalidPromptTemplate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrPromptTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt template not found"})
+			return
+		}
+		if errors.Is(err, services.ErrDuplicatePromptTemplateName) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Prompt template with this name already exists"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to update prompt template")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	h.logger.WithField("prompt_template_id", id).Info("Prompt template updated successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt template updated successfully"})
+}
+
+// DeletePromptTemplate deletes a prompt template
+// DELETE /api/prompt-templates/:id
+func (h *PromptTemplateHandler) DeletePromptTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteTemplate(c.Request.Context(), id); err != nil {
+		if errors.Is(err, services.ErrPromptTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt template not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to delete prompt template")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	h.logger.WithField("prompt_template_id", id).Info("Prompt template deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt template deleted successfully"})
+}
+
+// PreviewPromptTemplate renders a template against a sample issue
+// POST /api/prompt-templates/preview
+func (h *PromptTemplateHandler) PreviewPromptTemplate(c *gin.Context) {
+	var req models.PreviewPromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.service.PreviewTemplate(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPromptTemplate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrPromptTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt template not found"})
+			return
+		}
+		if errors.Is(err, services.ErrProjectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to preview prompt template")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
@@ -69,16 +69,25 @@ func main() {
 	projectRepo := repositories.NewProjectRepository(db)
 	developmentRepo := repositories.NewDevelopmentRepository(db)
 	webhookRepo := repositories.NewWebhookRepository(db)
+	agentProfileRepo := repositories.NewAgentProfileRepository(db)
+	promptTemplateRepo := repositories.NewPromptTemplateRepository(db)
+	experimentRepo := repositories.NewExperimentRepository(db)
 
 	// Initialize services
-	projectService := services.NewProjectService(projectRepo)
+	projectService := services.NewProjectService(projectRepo, agentProfileRepo)
 	developmentService := services.NewDevelopmentService(developmentRepo)
 	webhookService := services.NewWebhookService(webhookRepo)
+	agentProfileService := services.NewAgentProfileService(agentProfileRepo, projectRepo, experimentRepo)
+	promptTemplateService := services.NewPromptTemplateService(promptTemplateRepo, projectRepo)
+	experimentService := services.NewExperimentService(experimentRepo, developmentRepo, projectRepo, promptTemplateRepo, agentProfileRepo)
 
 	// Initialize handlers
 	projectHandler := handlers.NewProjectHandler(projectService, logger)
 	developmentHandler := handlers.NewDevelopmentHandler(developmentService, logger)
 	webhookHandler := handlers.NewWebhookHandler(webhookService, logger)
+	agentProfileHandler := handlers.NewAgentProfileHandler(agentProfileService, logger)
+	promptTemplateHandler := handlers.NewPromptTemplateHandler(promptTemplateService, logger)
+	experimentHandler := handlers.NewExperimentHandler(experimentService, logger)
 
 	// Setup Gin router
 	if os.Getenv("GIN_MODE") != "debug" {
@@ -122,10 +131,36 @@ func main() {
 		// Development routes
 		api.GET("/developments", developmentHandler.GetDevelopments)
 		api.GET("/developments/:id", developmentHandler.GetDevelopment)
+		api.POST("/developments/:id/approve", developmentHandler.ApprovePlan)
+		api.POST("/developments/:id/reject", developmentHandler.RejectPlan)
 
 		// Webhook Event routes
 		api.GET("/webhook-events", webhookHandler.GetWebhookEvents)
 		api.GET("/webhook-events/:id", webhookHandler.GetWebhookEvent)
+
+		// Agent Profile routes
+		api.GET("/agent-profiles", agentProfileHandler.GetAgentProfiles)
+		api.GET("/agent-profiles/:id", agentProfileHandler.GetAgentProfile)
+		api.POST("/agent-profiles", agentProfileHandler.CreateAgentProfile)
+		api.PUT("/agent-profiles/:id", agentProfileHandler.UpdateAgentProfile)
+		api.DELETE("/agent-profiles/:id", agentProfileHandler.DeleteAgentProfile)
+
+		// Prompt Template routes
+		api.GET("/prompt-templates", promptTemplateHandler.GetPromptTemplates)
+		api.GET("/prompt-templates/:id", promptTemplateHandler.GetPromptTemplate)
+		api.POST("/prompt-templates", promptTemplateHandler.CreatePromptTemplate)
+		api.POST("/prompt-templates/preview", promptTemplateHandler.PreviewPromptTemplate)
+		api.PUT("/prompt-templates/:id", promptTemplateHandler.UpdatePromptTemplate)
+		api.DELETE("/prompt-templates/:id", promptTemplateHandler.DeletePromptTemplate)
+
+		// Experiment routes
+		api.GET("/experiments", experimentHandler.GetExperiments)
+		api.GET("/experiments/:id", experimentHandler.GetExperiment)
+		api.GET("/experiments/:id/results", experimentHandler.GetExperimentResults)
+		api.POST("/experiments", experimentHandler.CreateExperiment)
+		api.POST("/experiments/:id/promote", experimentHandler.PromoteExperimentVariant)
+		api.PUT("/experiments/:id", experimentHandler.UpdateExperiment)
+		api.DELETE("/experiments/:id", experimentHandler.DeleteExperiment)
 	}
 
 	// Start server in a goroutine
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Supported Claude CLI permission modes
+const (
+	PermissionModeDefault           = "default"
+	PermissionModeAcceptEdits       = "acceptEdits"
+	PermissionModePlan              = "plan"
+	PermissionModeBypassPermissions = "bypassPermissions"
+)
+
+// AgentProfile represents a named set of agent invocation options
+type AgentProfile struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name            string             `json:"name" bson:"name"`
+	Description     string             `json:"description" bson:"description"`
+	Model           string             `json:"model,omitempty" bson:"model,omitempty"`
+	MaxTurns        int                `json:"max_turns,omitempty" bson:"max_turns,omitempty"`
+	TimeoutSeconds  int                `json:"timeout_seconds,omitempty" bson:"timeout_seconds,omitempty"`
+	PermissionMode  string             `json:"permission_mode" bson:"permission_mode"`
+	AllowedTools    []string           `json:"allowed_tools,omitempty" bson:"allowed_tools,omitempty"`
+	DisallowedTools []string           `json:"disallowed_tools,omitempty" bson:"disallowed_tools,omitempty"`
+	AddDirs         []string           `json:"add_dirs,omitempty" bson:"add_dirs,omitempty"`                 // Extra directories the agent may access
+	Env             map[string]string  `json:"env,omitempty" bson:"env,omitempty"`                           // Environment variables for the agent process
+	MCPConfig       string             `json:"mcp_config,omitempty" bson:"mcp_config,omitempty"`             // Raw MCP server configuration (JSON)
+	MaxFixAttempts  int                `json:"max_fix_attempts,omitempty" bson:"max_fix_attempts,omitempty"` // Re-invocations after failed verification
+	Version         int                `json:"version" bson:"version"`                                       // Incremented on every update
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// CreateAgentProfileRequest represents the request body for creating an agent profile
+type CreateAgentProfileRequest struct {
+	Name            string            `json:"name" binding:"required"`
+	Description     string            `json:"description"`
+	Model           string            `json:"model"`
+	MaxTurns        int               `json:"max_turns" binding:"min=0"`
+	TimeoutSeconds  int               `json:"timeout_seconds" binding:"min=0"`
+	PermissionMode  string            `json:"permission_mode"`
+	AllowedTools    []string          `json:"allowed_tools"`
+	DisallowedTools []string          `json:"disallowed_tools"`
+	AddDirs         []string          `json:"add_dirs"`
+	Env             map[string]string `json:"env"`
+	MCPConfig       string            `json:"mcp_config"`
+	MaxFixAttempts  int               `json:"max_fix_attempts" binding:"min=0,max=10"`
+}
+
+// UpdateAgentProfileRequest represents the request body for updating an agent profile
+type UpdateAgentProfileRequest struct {
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	Model           string            `json:"model"`
+	MaxTurns        *int              `json:"max_turns" binding:"omitempty,min=0"`
+	TimeoutSeconds  *int              `json:"timeout_seconds" binding:"omitempty,min=0"`
+	PermissionMode  string            `json:"permission_mode"`
+	AllowedTools    []string          `json:"allowed_tools"`
+	DisallowedTools []string          `json:"disallowed_tools"`
+	AddDirs         []string          `json:"add_dirs"`
+	Env             map[string]string `json:"env"`
+	MCPConfig       *string           `json:"mcp_config"`
+	MaxFixAttempts  *int              `json:"max_fix_attempts" binding:"omitempty,min=0,max=10"`
+}
@@ -6,17 +6,171 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Development statuses set while a plan is reviewed, a question is answered
+// or the ticket needs refinement
+const (
+	DevelopmentStatusAwaitingApproval = "awaiting_approval"
+	DevelopmentStatusPlanApproved     = "plan_approved"
+	DevelopmentStatusPlanRejected     = "plan_rejected"
+	DevelopmentStatusAwaitingInput    = "awaiting_input"
+	DevelopmentStatusInputReceived    = "input_received"
+	DevelopmentStatusNeedsRefinement  = "needs_refinement"
+)
+
+// Plan statuses
+const (
+	PlanStatusPending  = "pending"
+	PlanStatusApproved = "approved"
+	PlanStatusRejected = "rejected"
+)
+
 type Development struct {
-	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	JiraIssueID        string             `bson:"jira_issue_id" json:"jira_issue_id"`
-	JiraIssueKey       string             `bson:"jira_issue_key" json:"jira_issue_key"`
-	JiraProjectKey     string             `bson:"jira_project_key" json:"jira_project_key"`
-	RepositoryURL      string             `bson:"repository_url" json:"repository_url"`
-	BranchName         string             `bson:"branch_name" json:"branch_name"`
-	PRMRUrl            string             `bson:"pr_mr_url,omitempty" json:"pr_mr_url,omitempty"`
-	Status             string             `bson:"status" json:"status"`
-	DevelopmentDetails string             `bson:"development_details,omitempty" json:"development_details,omitempty"`
-	ErrorMessage       string             `bson:"error_message,omitempty" json:"error_message,omitempty"`
-	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
-	CompletedAt        *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ID                    primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	JiraIssueID           string                `bson:"jira_issue_id" json:"jira_issue_id"`
+	JiraIssueKey          string                `bson:"jira_issue_key" json:"jira_issue_key"`
+	JiraProjectKey        string                `bson:"jira_project_key" json:"jira_project_key"`
+	RepositoryURL         string                `bson:"repository_url" json:"repository_url"`
+	BranchName            string                `bson:"branch_name" json:"branch_name"`
+	PRMRUrl               string                `bson:"pr_mr_url,omitempty" json:"pr_mr_url,omitempty"`
+	Status                string                `bson:"status" json:"status"`
+	DevelopmentDetails    string                `bson:"development_details,omitempty" json:"development_details,omitempty"`
+	ErrorMessage          string                `bson:"error_message,omitempty" json:"error_message,omitempty"`
+	FailureType           string                `bson:"failure_type,omitempty" json:"failure_type,omitempty"` // e.g. merge_conflict
+	Prompt                string                `bson:"prompt,omitempty" json:"prompt,omitempty"`
+	AgentProfileID        string                `bson:"agent_profile_id,omitempty" json:"agent_profile_id,omitempty"`
+	AgentProfileVersion   int                   `bson:"agent_profile_version,omitempty" json:"agent_profile_version,omitempty"`
+	PromptTemplateID      string                `bson:"prompt_template_id,omitempty" json:"prompt_template_id,omitempty"`
+	PromptTemplateVersion int                   `bson:"prompt_template_version,omitempty" json:"prompt_template_version,omitempty"`
+	Experiment            *ExperimentAssignment `bson:"experiment,omitempty" json:"experiment,omitempty"`
+	PullRequest           *PullRequestStatus    `bson:"pull_request,omitempty" json:"pull_request,omitempty"`
+	Verification          *VerificationResult   `bson:"verification,omitempty" json:"verification,omitempty"`
+	Iterations            []Iteration           `bson:"iterations,omitempty" json:"iterations,omitempty"`
+	Candidates            []Candidate           `bson:"candidates,omitempty" json:"candidates,omitempty"`
+	Plan                  *Plan                 `bson:"plan,omitempty" json:"plan,omitempty"`
+	PlanHistory           []Plan                `bson:"plan_history,omitempty" json:"plan_history,omitempty"`
+	Questions             []Question            `bson:"questions,omitempty" json:"questions,omitempty"`
+	Readiness             *ReadinessResult      `bson:"readiness,omitempty" json:"readiness,omitempty"`
+	WorkspacePath         string                `bson:"workspace_path,omitempty" json:"workspace_path,omitempty"`
+	CreatedAt             time.Time             `bson:"created_at" json:"created_at"`
+	CompletedAt           *time.Time            `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// VerificationStep is the outcome of a single verification command
+type VerificationStep struct {
+	Stage      string `bson:"stage" json:"stage"`
+	Command    string `bson:"command" json:"command"`
+	Dir        string `bson:"dir,omitempty" json:"dir,omitempty"`
+	Passed     bool   `bson:"passed" json:"passed"`
+	Skipped    bool   `bson:"skipped,omitempty" json:"skipped,omitempty"`
+	TimedOut   bool   `bson:"timed_out,omitempty" json:"timed_out,omitempty"`
+	ExitCode   int    `bson:"exit_code" json:"exit_code"`
+	DurationMs int64  `bson:"duration_ms" json:"duration_ms"`
+	Output     string `bson:"output,omitempty" json:"output,omitempty"`
+}
+
+// VerificationResult represents the build/lint/test results recorded by the consumer
+type VerificationResult struct {
+	Passed      bool               `bson:"passed" json:"passed"`
+	Policy      string             `bson:"policy" json:"policy"`
+	Steps       []VerificationStep `bson:"steps" json:"steps"`
+	StartedAt   time.Time          `bson:"started_at" json:"started_at"`
+	CompletedAt time.Time          `bson:"completed_at" json:"completed_at"`
+}
+
+// Iteration records one agent invocation (initial generation or fix) and its verification
+type Iteration struct {
+	Candidate    int                 `bson:"candidate,omitempty" json:"candidate,omitempty"` // Set in best-of-N runs
+	Number       int                 `bson:"number" json:"number"`
+	Kind         string              `bson:"kind" json:"kind"` // generate, fix
+	FilesChanged int                 `bson:"files_changed" json:"files_changed"`
+	Verification *VerificationResult `bson:"verification,omitempty" json:"verification,omitempty"`
+	StartedAt    time.Time           `bson:"started_at" json:"started_at"`
+	CompletedAt  time.Time           `bson:"completed_at" json:"completed_at"`
+}
+
+// Candidate is one best-of-N generation attempt with its score and rank
+type Candidate struct {
+	Index            int                 `bson:"index" json:"index"`
+	AgentProfileID   string              `bson:"agent_profile_id,omitempty" json:"agent_profile_id,omitempty"`
+	AgentProfileName string              `bson:"agent_profile_name,omitempty" json:"agent_profile_name,omitempty"`
+	Score            float64             `bson:"score" json:"score"`
+	Rank             int                 `bson:"rank" json:"rank"`
+	Selected         bool                `bson:"selected" json:"selected"`
+	FilesChanged     int                 `bson:"files_changed" json:"files_changed"`
+	DiffLines        int                 `bson:"diff_lines" json:"diff_lines"`
+	DiffPath         string              `bson:"diff_path,omitempty" json:"diff_path,omitempty"`
+	Verification     *VerificationResult `bson:"verification,omitempty" json:"verification,omitempty"`
+	Error            string              `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// Plan is an implementation plan produced by the consumer in plan-first mode
+type Plan struct {
+	Revision   int        `bson:"revision" json:"revision"`
+	Content    string     `bson:"content" json:"content"`
+	Status     string     `bson:"status" json:"status"` // pending, approved, rejected
+	Feedback   string     `bson:"feedback,omitempty" json:"feedback,omitempty"`
+	ReviewedBy string     `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	ReviewedAt *time.Time `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+}
+
+// Question is a clarifying question the agent asked on JIRA, with the answer once given
+type Question struct {
+	Question   string     `bson:"question" json:"question"`
+	Context    string     `bson:"context,omitempty" json:"context,omitempty"`
+	Answer     string     `bson:"answer,omitempty" json:"answer,omitempty"`
+	AnsweredBy string     `bson:"answered_by,omitempty" json:"answered_by,omitempty"`
+	AskedAt    time.Time  `bson:"asked_at" json:"asked_at"`
+	AnsweredAt *time.Time `bson:"answered_at,omitempty" json:"answered_at,omitempty"`
+}
+
+// ReadinessCheck is the outcome of a single readiness rule
+type ReadinessCheck struct {
+	Name   string  `bson:"name" json:"name"`
+	Weight int     `bson:"weight" json:"weight"`
+	Score  float64 `bson:"score" json:"score"` // Fraction of the weight earned (0-1)
+	Passed bool    `bson:"passed" json:"passed"`
+	Detail string  `bson:"detail,omitempty" json:"detail,omitempty"`
+}
+
+// ReadinessResult is the readiness score the consumer computed before generating code
+type ReadinessResult struct {
+	Score     int              `bson:"score" json:"score"`
+	Threshold int              `bson:"threshold" json:"threshold"`
+	Passed    bool             `bson:"passed" json:"passed"`
+	Checks    []ReadinessCheck `bson:"checks" json:"checks"`
+}
+
+// ExperimentAssignment is the experiment variant a development was assigned to
+type ExperimentAssignment struct {
+	ID      string `bson:"id" json:"id"`
+	Name    string `bson:"name" json:"name"`
+	Variant string `bson:"variant" json:"variant"`
+}
+
+// PullRequestStatus is the state of the pull/merge request, tracked by the consumer after creation
+type PullRequestStatus struct {
+	State     string     `bson:"state" json:"state"` // open, merged, closed
+	Reviews   int        `bson:"reviews" json:"reviews"`
+	MergedAt  *time.Time `bson:"merged_at,omitempty" json:"merged_at,omitempty"`
+	ClosedAt  *time.Time `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+	CheckedAt time.Time  `bson:"checked_at" json:"checked_at"`
+}
+
+// Pull request states
+const (
+	PullRequestStateOpen   = "open"
+	PullRequestStateMerged = "merged"
+	PullRequestStateClosed = "closed"
+)
+
+// ApprovePlanRequest represents the request body for approving a plan
+type ApprovePlanRequest struct {
+	ReviewedBy string `json:"reviewed_by"`
+}
+
+// RejectPlanRequest represents the request body for rejecting a plan
+type RejectPlanRequest struct {
+	Feedback   string `json:"feedback" binding:"required"`
+	ReviewedBy string `json:"reviewed_by"`
 }
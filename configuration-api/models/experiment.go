@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Experiment statuses
+const (
+	ExperimentStatusRunning  = "running"
+	ExperimentStatusStopped  = "stopped"
+	ExperimentStatusPromoted = "promoted"
+)
+
+// Experiment splits the developments of a JIRA project between prompt template and
+// agent profile variants. The consumer assigns each issue to a variant by weight.
+type Experiment struct {
+	ID              primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Name            string              `json:"name" bson:"name"`
+	Description     string              `json:"description" bson:"description"`
+	JiraProjectKey  string              `json:"jira_project_key" bson:"jira_project_key"`
+	Status          string              `json:"status" bson:"status"` // running, stopped, promoted
+	Variants        []ExperimentVariant `json:"variants" bson:"variants"`
+	PromotedVariant string              `json:"promoted_variant,omitempty" bson:"promoted_variant,omitempty"`
+	CreatedAt       time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// ExperimentVariant overrides the project's prompt template and/or agent profile.
+// Empty fields keep the project configuration, so a variant can act as the control.
+type ExperimentVariant struct {
+	Name             string `json:"name" bson:"name"`
+	Weight           int    `json:"weight" bson:"weight"` // Percentage of developments, all variants add up to 100
+	PromptTemplateID string `json:"prompt_template_id,omitempty" bson:"prompt_template_id,omitempty"`
+	AgentProfileID   string `json:"agent_profile_id,omitempty" bson:"agent_profile_id,omitempty"`
+}
+
+// CreateExperimentRequest represents the request body for creating an experiment
+type CreateExperimentRequest struct {
+	Name           string              `json:"name" binding:"required"`
+	Description    string              `json:"description"`
+	JiraProjectKey string              `json:"jira_project_key" binding:"required"`
+	Variants       []ExperimentVariant `json:"variants" binding:"required"`
+}
+
+// UpdateExperimentRequest represents the request body for updating an experiment
+type UpdateExperimentRequest struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Status      string              `json:"status"` // running or stopped
+	Variants    []ExperimentVariant `json:"variants"`
+}
+
+// PromoteExperimentRequest represents the request body for promoting a variant
+type PromoteExperimentRequest struct {
+	Variant string `json:"variant" binding:"required"`
+}
+
+// ExperimentResults are the outcomes of an experiment aggregated per variant
+type ExperimentResults struct {
+	ExperimentID string           `json:"experiment_id"`
+	Name         string           `json:"name"`
+	Status       string           `json:"status"`
+	Variants     []VariantOutcome `json:"variants"`
+}
+
+// VariantOutcome aggregates the developments assigned to a variant. Rates are
+// fractions (0-1) of the developments they apply to; averages are per development.
+type VariantOutcome struct {
+	Variant          string  `json:"variant"`
+	Developments     int     `json:"developments"`
+	Completed        int     `json:"completed"`
+	Failed           int     `json:"failed"`
+	SuccessRate      float64 `json:"success_rate"`      // completed / (completed + failed)
+	VerificationRate float64 `json:"verification_rate"` // passed / verified
+	PRsOpen          int     `json:"prs_open"`
+	PRsMerged        int     `json:"prs_merged"`
+	PRsClosed        int     `json:"prs_closed"` // Closed without merge
+	MergeRate        float64 `json:"merge_rate"` // merged / (merged + closed)
+	AvgReviews       float64 `json:"avg_reviews"`
+	AvgFixIterations float64 `json:"avg_fix_iterations"`
+	AgentRuns        int     `json:"agent_runs"`
+	AgentMinutes     float64 `json:"agent_minutes"`
+	AvgAgentMinutes  float64 `json:"avg_agent_minutes"`
+}
@@ -8,61 +8,273 @@ import (
 
 // Repository represents a Git repository configuration
 type Repository struct {
-	RepositoryID     string `json:"repository_id" bson:"repository_id" binding:"required"`
-	URL              string `json:"url" bson:"url" binding:"required,url"`
-	Description      string `json:"description" bson:"description" binding:"required"`
-	GitAccessToken   string `json:"git_access_token" bson:"git_access_token" binding:"required"`
-	BaseBranch       string `json:"base_branch" bson:"base_branch"` // Base branch for PRs (e.g., "main", "master")
+	RepositoryID   string                `json:"repository_id" bson:"repository_id" binding:"required"`
+	URL            string                `json:"url" bson:"url" binding:"required"` // HTTPS or SSH (git@host:org/repo.git) URL
+	Description    string                `json:"description" bson:"description" binding:"required"`
+	GitAccessToken string                `json:"git_access_token" bson:"git_access_token" binding:"required"`
+	BaseBranch     string                `json:"base_branch" bson:"base_branch"`                               // Base branch for PRs (e.g., "main", "master")
+	AgentProfileID string                `json:"agent_profile_id,omitempty" bson:"agent_profile_id,omitempty"` // Overrides the project agent profile
+	Verification   *VerificationSettings `json:"verification,omitempty" bson:"verification,omitempty"`
+	Conventions    *ConventionSettings   `json:"conventions,omitempty" bson:"conventions,omitempty"`
+	RepoMap        *RepoMapSettings      `json:"repo_map,omitempty" bson:"repo_map,omitempty"`
+	Retrieval      *RetrievalSettings    `json:"retrieval,omitempty" bson:"retrieval,omitempty"`
+	Clone          *CloneSettings        `json:"clone,omitempty" bson:"clone,omitempty"`
+	SSHKey         *SSHKeyCredential     `json:"ssh_key,omitempty" bson:"ssh_key,omitempty"` // Deploy key for SSH URLs
+	Rerun          *RerunSettings        `json:"rerun,omitempty" bson:"rerun,omitempty"`
+	Naming         *NamingSettings       `json:"naming,omitempty" bson:"naming,omitempty"`
+	BaseSync       *BaseSyncSettings     `json:"base_sync,omitempty" bson:"base_sync,omitempty"`
+	CommitGroups   *CommitGroupSettings  `json:"commit_groups,omitempty" bson:"commit_groups,omitempty"`
+	Fork           *ForkSettings         `json:"fork,omitempty" bson:"fork,omitempty"`
+}
+
+// ForkSettings makes the agent push its branches to a fork and open cross-repository pull
+// requests against the repository, so the access token only needs read access to it.
+// The fork is either given by URL or created through the GitHub or GitLab API.
+type ForkSettings struct {
+	URL       string `json:"url,omitempty" bson:"url,omitempty"`             // Existing fork the branches are pushed to
+	Create    bool   `json:"create,omitempty" bson:"create,omitempty"`       // Create the fork when no URL is given
+	Namespace string `json:"namespace,omitempty" bson:"namespace,omitempty"` // Organization or group of a created fork, the token owner by default
+}
+
+// CommitGroupSettings splits the agent's changes into one commit per group of files. Without
+// groups the defaults apply: migrations, implementation, tests and docs.
+type CommitGroupSettings struct {
+	Enabled bool          `json:"enabled" bson:"enabled"`
+	Groups  []CommitGroup `json:"groups,omitempty" bson:"groups,omitempty"`
+}
+
+// CommitGroup is a group of changed files committed together, in the order of the groups.
+// A file belongs to the first group with a matching glob; the group without globs takes
+// the files matching no glob.
+type CommitGroup struct {
+	Name  string   `json:"name" bson:"name"`
+	Globs []string `json:"globs,omitempty" bson:"globs,omitempty"`
+}
+
+// BaseSyncSettings controls how the agent's commits are brought up to date when the base
+// branch moved while the agent ran. Conflicts are handed back to the agent and the result
+// is verified again.
+type BaseSyncSettings struct {
+	Strategy string `json:"strategy,omitempty" bson:"strategy,omitempty"` // rebase (default), merge or disabled
+}
+
+// NamingSettings holds the Go templates for branch names and commit messages. They are
+// rendered with the issue fields Key, ProjectKey, Type, Summary, Slug and Labels.
+type NamingSettings struct {
+	BranchTemplate string `json:"branch_template,omitempty" bson:"branch_template,omitempty"` // Default: feature/{{.Key}}
+	CommitTemplate string `json:"commit_template,omitempty" bson:"commit_template,omitempty"` // Default: [{{.Key}}] {{.Summary}}
+}
+
+// RerunSettings controls what the consumer does when the branch of an issue already exists
+// on the remote from a previous run. The open pull request is updated in both cases.
+type RerunSettings struct {
+	Policy string `json:"policy,omitempty" bson:"policy,omitempty"` // append (default, commits on top) or replace (force-with-lease a fresh attempt)
+}
+
+// SSHKeyCredential is the deploy key used to clone and push repositories with SSH URLs.
+// Pinned known hosts are mandatory: ssh only connects to servers presenting one of them.
+type SSHKeyCredential struct {
+	PrivateKey string `json:"private_key" bson:"private_key"`                     // PEM or OpenSSH private key
+	Passphrase string `json:"passphrase,omitempty" bson:"passphrase,omitempty"`   // Passphrase of an encrypted key
+	KnownHosts string `json:"known_hosts,omitempty" bson:"known_hosts,omitempty"` // Required known_hosts lines pinning the host keys
+}
+
+// CloneSettings reduce what the consumer clones of large repositories, or complete the
+// checkout with submodules and Git LFS objects. Shallow clones are deepened automatically
+// when more history is needed.
+type CloneSettings struct {
+	Depth        int      `json:"depth,omitempty" bson:"depth,omitempty"`                 // Number of commits of history, 0 for all
+	SingleBranch bool     `json:"single_branch,omitempty" bson:"single_branch,omitempty"` // Clone only the base branch
+	Filter       string   `json:"filter,omitempty" bson:"filter,omitempty"`               // Partial clone filter: blob:none, blob:limit=<size> or tree:0
+	SparsePaths  []string `json:"sparse_paths,omitempty" bson:"sparse_paths,omitempty"`   // Directories checked out (cone mode)
+	Submodules   bool     `json:"submodules,omitempty" bson:"submodules,omitempty"`       // Initialize submodules recursively
+	LFS          bool     `json:"lfs,omitempty" bson:"lfs,omitempty"`                     // Fetch Git LFS objects and push those the agent adds
+}
+
+// RetrievalSettings controls the files ranked against the issue (BM25 over paths,
+// identifiers and comments) that the consumer adds to the agent prompt
+type RetrievalSettings struct {
+	Disabled bool `json:"disabled,omitempty" bson:"disabled,omitempty"`
+	TopK     int  `json:"top_k,omitempty" bson:"top_k,omitempty"` // Number of files, defaults to 5
+}
+
+// RepoMapSettings controls whether the consumer adds the repository map (file tree with
+// exported symbols) to the agent prompt
+type RepoMapSettings struct {
+	Enabled   bool `json:"enabled" bson:"enabled"`
+	MaxTokens int  `json:"max_tokens,omitempty" bson:"max_tokens,omitempty"` // Defaults to 2048
+}
+
+// ConventionSettings configures which convention documents (contributing guides, agent
+// instructions, lint configs, ADRs) the consumer includes in the agent context.
+// Globs are matched against paths relative to the repository root; a glob without a
+// slash matches the file name in any directory and "**" matches any number of directories.
+type ConventionSettings struct {
+	Globs        []string `json:"globs,omitempty" bson:"globs,omitempty"`                   // Replace the default globs
+	MaxBytes     int      `json:"max_bytes,omitempty" bson:"max_bytes,omitempty"`           // Total budget, defaults to 32 KB
+	MaxFileBytes int      `json:"max_file_bytes,omitempty" bson:"max_file_bytes,omitempty"` // Per file budget, defaults to 8 KB
+}
+
+// Verification policies applied when post-generation checks fail
+const (
+	VerificationPolicyBlock    = "block"
+	VerificationPolicyDraft    = "draft"
+	VerificationPolicyAnnotate = "annotate"
+	VerificationPolicyDisabled = "disabled"
+)
+
+// VerificationSettings configures the build/lint/test gate for a repository.
+// Commands given for a stage replace the commands detected by the consumer.
+type VerificationSettings struct {
+	Policy         string   `json:"policy,omitempty" bson:"policy,omitempty"` // block, draft, annotate (default) or disabled
+	SetupCommands  []string `json:"setup_commands,omitempty" bson:"setup_commands,omitempty"`
+	BuildCommands  []string `json:"build_commands,omitempty" bson:"build_commands,omitempty"`
+	LintCommands   []string `json:"lint_commands,omitempty" bson:"lint_commands,omitempty"`
+	TestCommands   []string `json:"test_commands,omitempty" bson:"test_commands,omitempty"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty" bson:"timeout_seconds,omitempty"` // Per command
+}
+
+// BestOfNSettings enables parallel candidate generation for a project's tickets.
+// The consumer verifies every candidate and pushes the highest scoring one.
+type BestOfNSettings struct {
+	Candidates      int      `json:"candidates" bson:"candidates"`                                   // Number of parallel agent runs (2-5, 0 or 1 disables)
+	AgentProfileIDs []string `json:"agent_profile_ids,omitempty" bson:"agent_profile_ids,omitempty"` // Assigned to candidates round-robin
+	Labels          []string `json:"labels,omitempty" bson:"labels,omitempty"`                       // Only for issues with one of these labels
+}
+
+// PlanFirstSettings makes the consumer produce an implementation plan and wait for
+// approval before generating code
+type PlanFirstSettings struct {
+	Enabled bool     `json:"enabled" bson:"enabled"`
+	Labels  []string `json:"labels,omitempty" bson:"labels,omitempty"` // Only for issues with one of these labels
+}
+
+// ReadinessSettings makes the consumer score an issue before generating code. Issues
+// scoring below the threshold are moved to needs_refinement with a JIRA comment.
+type ReadinessSettings struct {
+	Enabled                   bool `json:"enabled" bson:"enabled"`
+	Threshold                 int  `json:"threshold,omitempty" bson:"threshold,omitempty"`                                     // 0-100, defaults to 60
+	MinDescriptionLength      int  `json:"min_description_length,omitempty" bson:"min_description_length,omitempty"`           // Defaults to 100 characters
+	RequireAcceptanceCriteria bool `json:"require_acceptance_criteria,omitempty" bson:"require_acceptance_criteria,omitempty"` // Score the presence of acceptance criteria
+	CheckReferences           bool `json:"check_references,omitempty" bson:"check_references,omitempty"`                       // Score referenced files/endpoints against the repository
+	LLMJudgment               bool `json:"llm_judgment,omitempty" bson:"llm_judgment,omitempty"`                               // Ask the agent to judge the issue
+}
+
+// CommitSettings controls the identity, signature and co-author trailers of the
+// consumer's commits. Committer and author are the same identity; for branch protection
+// requiring verified signatures its email must belong to the owner of the signing key.
+type CommitSettings struct {
+	AuthorName  string                 `json:"author_name,omitempty" bson:"author_name,omitempty"`   // Defaults to "SDLC AI Agent"
+	AuthorEmail string                 `json:"author_email,omitempty" bson:"author_email,omitempty"` // Defaults to sdlc-agent@example.com
+	Signing     *CommitSigningSettings `json:"signing,omitempty" bson:"signing,omitempty"`
+	UserMapping []UserMapping          `json:"user_mapping,omitempty" bson:"user_mapping,omitempty"` // Maps JIRA assignees to Co-authored-by trailers
+}
+
+// CommitSigningSettings references the signing key in the consumer's secret store. Only
+// secret names are stored here, never the key itself.
+type CommitSigningSettings struct {
+	Format           string `json:"format" bson:"format"`                                           // gpg or ssh
+	KeySecret        string `json:"key_secret" bson:"key_secret"`                                   // Secret holding the armored GPG or OpenSSH private key
+	PassphraseSecret string `json:"passphrase_secret,omitempty" bson:"passphrase_secret,omitempty"` // Secret holding the key passphrase
+}
+
+// UserMapping maps a JIRA user to the git identity credited as co-author
+type UserMapping struct {
+	JiraUser string `json:"jira_user" bson:"jira_user"` // JIRA account ID, username or email address
+	Name     string `json:"name" bson:"name"`
+	Email    string `json:"email" bson:"email"`
 }
 
 // Project represents a project configuration
 type Project struct {
-	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name            string             `json:"name" bson:"name" binding:"required"`
-	Description     string             `json:"description" bson:"description" binding:"required"`
-	Scope           string             `json:"scope" bson:"scope" binding:"required"`
-	JiraProjectKey  string             `json:"jira_project_key" bson:"jira_project_key" binding:"required"`
-	JiraProjectName string             `json:"jira_project_name" bson:"jira_project_name" binding:"required"`
-	JiraProjectURL  string             `json:"jira_project_url" bson:"jira_project_url" binding:"required,url"`
-	Repositories    []Repository       `json:"repositories" bson:"repositories"`
-	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name             string             `json:"name" bson:"name" binding:"required"`
+	Description      string             `json:"description" bson:"description" binding:"required"`
+	Scope            string             `json:"scope" bson:"scope" binding:"required"`
+	JiraProjectKey   string             `json:"jira_project_key" bson:"jira_project_key" binding:"required"`
+	JiraProjectName  string             `json:"jira_project_name" bson:"jira_project_name" binding:"required"`
+	JiraProjectURL   string             `json:"jira_project_url" bson:"jira_project_url" binding:"required,url"`
+	Repositories     []Repository       `json:"repositories" bson:"repositories"`
+	AgentProfileID   string             `json:"agent_profile_id,omitempty" bson:"agent_profile_id,omitempty"`
+	PromptTemplateID string             `json:"prompt_template_id,omitempty" bson:"prompt_template_id,omitempty"` // Renders the agent prompt, default prompt if empty
+	BestOfN          *BestOfNSettings   `json:"best_of_n,omitempty" bson:"best_of_n,omitempty"`
+	PlanFirst        *PlanFirstSettings `json:"plan_first,omitempty" bson:"plan_first,omitempty"`
+	Readiness        *ReadinessSettings `json:"readiness,omitempty" bson:"readiness,omitempty"`
+	Commit           *CommitSettings    `json:"commit,omitempty" bson:"commit,omitempty"`
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
 // CreateProjectRequest represents the request body for creating a project
 type CreateProjectRequest struct {
-	Name            string       `json:"name" binding:"required"`
-	Description     string       `json:"description" binding:"required"`
-	Scope           string       `json:"scope" binding:"required"`
-	JiraProjectKey  string       `json:"jira_project_key" binding:"required"`
-	JiraProjectName string       `json:"jira_project_name" binding:"required"`
-	JiraProjectURL  string       `json:"jira_project_url" binding:"required,url"`
-	Repositories    []Repository `json:"repositories"`
+	Name             string             `json:"name" binding:"required"`
+	Description      string             `json:"description" binding:"required"`
+	Scope            string             `json:"scope" binding:"required"`
+	JiraProjectKey   string             `json:"jira_project_key" binding:"required"`
+	JiraProjectName  string             `json:"jira_project_name" binding:"required"`
+	JiraProjectURL   string             `json:"jira_project_url" binding:"required,url"`
+	Repositories     []Repository       `json:"repositories"`
+	AgentProfileID   string             `json:"agent_profile_id"`
+	PromptTemplateID string             `json:"prompt_template_id"`
+	BestOfN          *BestOfNSettings   `json:"best_of_n"`
+	PlanFirst        *PlanFirstSettings `json:"plan_first"`
+	Readiness        *ReadinessSettings `json:"readiness"`
+	Commit           *CommitSettings    `json:"commit"`
 }
 
 // UpdateProjectRequest represents the request body for updating a project
 type UpdateProjectRequest struct {
-	Name            string       `json:"name"`
-	Description     string       `json:"description"`
-	Scope           string       `json:"scope"`
-	JiraProjectKey  string       `json:"jira_project_key"`
-	JiraProjectName string       `json:"jira_project_name"`
-	JiraProjectURL  string       `json:"jira_project_url"`
-	Repositories    []Repository `json:"repositories"`
+	Name             string             `json:"name"`
+	Description      string             `json:"description"`
+	Scope            string             `json:"scope"`
+	JiraProjectKey   string             `json:"jira_project_key"`
+	JiraProjectName  string             `json:"jira_project_name"`
+	JiraProjectURL   string             `json:"jira_project_url"`
+	Repositories     []Repository       `json:"repositories"`
+	AgentProfileID   *string            `json:"agent_profile_id"`
+	PromptTemplateID string             `json:"prompt_template_id"`
+	BestOfN          *BestOfNSettings   `json:"best_of_n"`
+	PlanFirst        *PlanFirstSettings `json:"plan_first"`
+	Readiness        *ReadinessSettings `json:"readiness"`
+	Commit           *CommitSettings    `json:"commit"`
 }
 
 // AddRepositoryRequest represents the request body for adding a repository
 type AddRepositoryRequest struct {
-	URL             string `json:"url" binding:"required,url"`
-	Description     string `json:"description" binding:"required"`
-	GitAccessToken  string `json:"git_access_token" binding:"required"`
-	BaseBranch      string `json:"base_branch"` // Base branch for PRs (defaults to "main" if not specified)
+	URL            string                `json:"url" binding:"required"`
+	Description    string                `json:"description" binding:"required"`
+	GitAccessToken string                `json:"git_access_token" binding:"required"`
+	BaseBranch     string                `json:"base_branch"` // Base branch for PRs (defaults to "main" if not specified)
+	AgentProfileID string                `json:"agent_profile_id"`
+	Verification   *VerificationSettings `json:"verification"`
+	Conventions    *ConventionSettings   `json:"conventions"`
+	RepoMap        *RepoMapSettings      `json:"repo_map"`
+	Retrieval      *RetrievalSettings    `json:"retrieval"`
+	Clone          *CloneSettings        `json:"clone"`
+	SSHKey         *SSHKeyCredential     `json:"ssh_key"`
+	Rerun          *RerunSettings        `json:"rerun"`
+	Naming         *NamingSettings       `json:"naming"`
+	BaseSync       *BaseSyncSettings     `json:"base_sync"`
+	CommitGroups   *CommitGroupSettings  `json:"commit_groups"`
+	Fork           *ForkSettings         `json:"fork"`
 }
 
 // UpdateRepositoryRequest represents the request body for updating a repository
 type UpdateRepositoryRequest struct {
-	URL             string `json:"url" binding:"url"`
-	Description     string `json:"description"`
-	GitAccessToken  string `json:"git_access_token"`
-	BaseBranch      string `json:"base_branch"`
+	URL            string                `json:"url"`
+	Description    string                `json:"description"`
+	GitAccessToken string                `json:"git_access_token"`
+	BaseBranch     string                `json:"base_branch"`
+	AgentProfileID *string               `json:"agent_profile_id"`
+	Verification   *VerificationSettings `json:"verification"`
+	Conventions    *ConventionSettings   `json:"conventions"`
+	RepoMap        *RepoMapSettings      `json:"repo_map"`
+	Retrieval      *RetrievalSettings    `json:"retrieval"`
+	Clone          *CloneSettings        `json:"clone"`
+	SSHKey         *SSHKeyCredential     `json:"ssh_key"`
+	Rerun          *RerunSettings        `json:"rerun"`
+	Naming         *NamingSettings       `json:"naming"`
+	BaseSync       *BaseSyncSettings     `json:"base_sync"`
+	CommitGroups   *CommitGroupSettings  `json:"commit_groups"`
+	Fork           *ForkSettings         `json:"fork"`
 }
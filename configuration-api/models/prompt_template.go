@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PromptTemplate is a Go text/template the consumer renders into the agent prompt
+type PromptTemplate struct {
+	ID          primitive.ObjectID       `json:"id" bson:"_id,omitempty"`
+	Name        string                   `json:"name" bson:"name"`
+	Description string                   `json:"description" bson:"description"`
+	Content     string                   `json:"content" bson:"content"`
+	Version     int                      `json:"version" bson:"version"`                     // Incremented on every update
+	History     []PromptTemplateRevision `json:"history,omitempty" bson:"history,omitempty"` // Previous contents, oldest first
+	CreatedAt   time.Time                `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time                `json:"updated_at" bson:"updated_at"`
+}
+
+// PromptTemplateRevision is a previous version of a prompt template's content
+type PromptTemplateRevision struct {
+	Version   int       `json:"version" bson:"version"`
+	Content   string    `json:"content" bson:"content"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// CreatePromptTemplateRequest represents the request body for creating a prompt template
+type CreatePromptTemplateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Content     string `json:"content" binding:"required"`
+}
+
+// UpdatePromptTemplateRequest represents the request body for updating a prompt template
+type UpdatePromptTemplateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Content     string `json:"content"`
+}
+
+// PreviewPromptTemplateRequest renders a stored template (template_id) or inline content
+// against a sample issue. Project and analysis are optional.
+type PreviewPromptTemplateRequest struct {
+	TemplateID string          `json:"template_id"`
+	Content    string          `json:"content"`
+	ProjectID  string          `json:"project_id"`
+	Issue      *SampleIssue    `json:"issue"`
+	Analysis   *SampleAnalysis `json:"analysis"`
+}
+
+// SampleIssue is the issue a template preview is rendered with
+type SampleIssue struct {
+	Key         string   `json:"key"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	IssueType   string   `json:"issue_type"`
+	Labels      []string `json:"labels"`
+}
+
+// SampleAnalysis is the repository analysis a template preview is rendered with
+type SampleAnalysis struct {
+	ProjectType    string            `json:"project_type"`
+	Languages      []string          `json:"languages"`
+	EntryPoints    []string          `json:"entry_points"`
+	KeyDirectories []string          `json:"key_directories"`
+	ConfigFiles    []string          `json:"config_files"`
+	Patterns       map[string]string `json:"patterns"`
+	Frameworks     []string          `json:"frameworks"`
+	TestFrameworks []string          `json:"test_frameworks"`
+	Conventions    []ConventionFile  `json:"conventions"`
+}
+
+// ConventionFile is a repository convention document the consumer includes in the agent context
+type ConventionFile struct {
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+}
+
+// PreviewPromptTemplateResponse is the rendered preview
+type PreviewPromptTemplateResponse struct {
+	Prompt          string `json:"prompt"`
+	TemplateVersion int    `json:"template_version,omitempty"`
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AgentProfileRepository handles database operations for agent profiles
+type AgentProfileRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAgentProfileRepository creates a new agent profile repository
+func NewAgentProfileRepository(db *mongo.Database) *AgentProfileRepository {
+	return &AgentProfileRepository{
+		collection: db.Collection("agent_profiles"),
+	}
+}
+
+// FindAll returns all agent profiles sorted by name
+func (r *AgentProfileRepository) FindAll(ctx context.Context) ([]models.AgentProfile, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var profiles []models.AgentProfile
+	if err := cursor.All(ctx, &profiles); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// FindByID returns an agent profile by ID
+func (r *AgentProfileRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.AgentProfile, error) {
+	var profile models.AgentProfile
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&profile)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// FindByName returns an agent profile by name
+func (r *AgentProfileRepository) FindByName(ctx context.Context, name string) (*models.AgentProfile, error) {
+	var profile models.AgentProfile
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&profile)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Create creates a new agent profile
+func (r *AgentProfileRepository) Create(ctx context.Context, profile *models.AgentProfile) error {
+	profile.Version = 1
+	profile.CreatedAt = time.Now()
+	profile.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, profile)
+	if err != nil {
+		return err
+	}
+
+	profile.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Update updates an existing agent profile and bumps its version
+func (r *AgentProfileRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": update,
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Delete deletes an agent profile
+func (r *AgentProfileRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
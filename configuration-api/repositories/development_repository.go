@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/storos/sdlc-agent/configuration-api/models"
 	"go.mongodb.org/mongo-driver/bson"
@@ -47,6 +48,22 @@ func (r *DevelopmentRepository) GetByID(ctx context.Context, id primitive.Object
 	return &development, nil
 }
 
+// GetByExperimentID returns the developments assigned to a variant of the experiment
+func (r *DevelopmentRepository) GetByExperimentID(ctx context.Context, experimentID string) ([]models.Development, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"experiment.id": experimentID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var developments []models.Development
+	if err := cursor.All(ctx, &developments); err != nil {
+		return nil, err
+	}
+
+	return developments, nil
+}
+
 func (r *DevelopmentRepository) GetByJiraProjectKey(ctx context.Context, jiraProjectKey string) ([]models.Development, error) {
 	// Sort by created_at descending (newest first)
 	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
@@ -64,3 +81,29 @@ func (r *DevelopmentRepository) GetByJiraProjectKey(ctx context.Context, jiraPro
 
 	return developments, nil
 }
+
+// ReviewPlan sets the plan review outcome of a development awaiting approval.
+// Returns false when no development with this ID is awaiting approval.
+func (r *DevelopmentRepository) ReviewPlan(ctx context.Context, id primitive.ObjectID, status, planStatus, feedback, reviewedBy string) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":    id,
+		"status": models.DevelopmentStatusAwaitingApproval,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           status,
+			"plan.status":      planStatus,
+			"plan.feedback":    feedback,
+			"plan.reviewed_by": reviewedBy,
+			"plan.reviewed_at": &now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExperimentRepository handles database operations for experiments
+type ExperimentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewExperimentRepository creates a new experiment repository
+func NewExperimentRepository(db *mongo.Database) *ExperimentRepository {
+	return &ExperimentRepository{
+		collection: db.Collection("experiments"),
+	}
+}
+
+// Find returns the experiments matching the filter, newest first
+func (r *ExperimentRepository) Find(ctx context.Context, filter bson.M) ([]models.Experiment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var experiments []models.Experiment
+	if err := cursor.All(ctx, &experiments); err != nil {
+		return nil, err
+	}
+
+	return experiments, nil
+}
+
+// FindByID returns an experiment by ID
+func (r *ExperimentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Experiment, error) {
+	var experiment models.Experiment
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&experiment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// FindRunningByJiraProjectKey returns the running experiment of a JIRA project
+func (r *ExperimentRepository) FindRunningByJiraProjectKey(ctx context.Context, jiraProjectKey string) (*models.Experiment, error) {
+	var experiment models.Experiment
+	err := r.collection.FindOne(ctx, bson.M{
+		"jira_project_key": jiraProjectKey,
+		"status":           models.ExperimentStatusRunning,
+	}).Decode(&experiment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// Create creates a new experiment
+func (r *ExperimentRepository) Create(ctx context.Context, experiment *models.Experiment) error {
+	experiment.CreatedAt = time.Now()
+	experiment.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, experiment)
+	if err != nil {
+		return err
+	}
+
+	experiment.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Update updates an existing experiment
+func (r *ExperimentRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Delete deletes an experiment
+func (r *ExperimentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
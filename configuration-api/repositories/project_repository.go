@@ -65,6 +65,18 @@ func (r *ProjectRepository) FindByJiraProjectKey(ctx context.Context, jiraProjec
 	return &project, nil
 }
 
+// CountByAgentProfileID counts the projects referencing an agent profile, either
+// directly, from one of their repositories or from the best-of-N candidates
+func (r *ProjectRepository) CountByAgentProfileID(ctx context.Context, agentProfileID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"$or": []bson.M{
+			{"agent_profile_id": agentProfileID},
+			{"repositories.agent_profile_id": agentProfileID},
+			{"best_of_n.agent_profile_ids": agentProfileID},
+		},
+	})
+}
+
 // Create creates a new project
 func (r *ProjectRepository) Create(ctx context.Context, project *models.Project) error {
 	project.CreatedAt = time.Now()
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PromptTemplateRepository handles database operations for prompt templates
+type PromptTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPromptTemplateRepository creates a new prompt template repository
+func NewPromptTemplateRepository(db *mongo.Database) *PromptTemplateRepository {
+	return &PromptTemplateRepository{
+		collection: db.Collection("prompt_templates"),
+	}
+}
+
+// FindAll returns all prompt templates sorted by name
+func (r *PromptTemplateRepository) FindAll(ctx context.Context) ([]models.PromptTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []models.PromptTemplate
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// FindByID returns a prompt template by ID
+func (r *PromptTemplateRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.PromptTemplate, error) {
+	var promptTemplate models.PromptTemplate
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&promptTemplate)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &promptTemplate, nil
+}
+
+// FindByName returns a prompt template by name
+func (r *PromptTemplateRepository) FindByName(ctx context.Context, name string) (*models.PromptTemplate, error) {
+	var promptTemplate models.PromptTemplate
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&promptTemplate)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &promptTemplate, nil
+}
+
+// Create creates a new prompt template
+func (r *PromptTemplateRepository) Create(ctx context.Context, promptTemplate *models.PromptTemplate) error {
+	promptTemplate.Version = 1
+	promptTemplate.CreatedAt = time.Now()
+	promptTemplate.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, promptTemplate)
+	if err != nil {
+		return err
+	}
+
+	promptTemplate.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Update updates an existing prompt template and bumps its version. When the content
+// changes, the previous content is appended to the history.
+func (r *PromptTemplateRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M, previous *models.PromptTemplateRevision) error {
+	update["updated_at"] = time.Now()
+
+	change := bson.M{
+		"$set": update,
+		"$inc": bson.M{"version": 1},
+	}
+	if previous != nil {
+		change["$push"] = bson.M{"history": previous}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, change)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Delete deletes a prompt template
+func (r *PromptTemplateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"github.com/storos/sdlc-agent/configuration-api/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrAgentProfileNotFound      = errors.New("agent profile not found")
+	ErrDuplicateAgentProfileName = errors.New("agent profile with this name already exists")
+	ErrInvalidAgentProfile       = errors.New("invalid agent profile")
+	ErrInvalidAgentProfileID     = errors.New("invalid agent profile ID")
+	ErrAgentProfileInUse         = errors.New("agent profile is still referenced")
+)
+
+var validPermissionModes = []string{
+	models.PermissionModeDefault,
+	models.PermissionModeAcceptEdits,
+	models.PermissionModePlan,
+	models.PermissionModeBypassPermissions,
+}
+
+// AgentProfileService handles business logic for agent profiles
+type AgentProfileService struct {
+	repo           *repositories.AgentProfileRepository
+	projectRepo    *repositories.ProjectRepository
+	experimentRepo *repositories.ExperimentRepository
+}
+
+// NewAgentProfileService creates a new agent profile service
+func NewAgentProfileService(repo *repositories.AgentProfileRepository, projectRepo *repositories.ProjectRepository, experimentRepo *repositories.ExperimentRepository) *AgentProfileService {
+	return &AgentProfileService{
+		repo:           repo,
+		projectRepo:    projectRepo,
+		experimentRepo: experimentRepo,
+	}
+}
+
+// GetAllProfiles returns all agent profiles
+func (s *AgentProfileService) GetAllProfiles(ctx context.Context) ([]models.AgentProfile, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// GetProfileByID returns an agent profile by ID
+func (s *AgentProfileService) GetProfileByID(ctx context.Context, id string) (*models.AgentProfile, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidAgentProfileID
+	}
+
+	profile, err := s.repo.FindByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile == nil {
+		return nil, ErrAgentProfileNotFound
+	}
+
+	return profile, nil
+}
+
+// CreateProfile creates a new agent profile
+func (s *AgentProfileService) CreateProfile(ctx context.Context, req *models.CreateAgentProfileRequest) (*models.AgentProfile, error) {
+	// Default to the mode the consumer has always used
+	permissionMode := req.PermissionMode
+	if permissionMode == "" {
+		permissionMode = models.PermissionModeAcceptEdits
+	}
+
+	if err := validateAgentProfileFields(permissionMode, req.MCPConfig); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByName(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDuplicateAgentProfileName
+	}
+
+	profile := &models.AgentProfile{
+		Name:            req.Name,
+		Description:     req.Description,
+		Model:           req.Model,
+		MaxTurns:        req.MaxTurns,
+		TimeoutSeconds:  req.TimeoutSeconds,
+		PermissionMode:  permissionMode,
+		AllowedTools:    req.AllowedTools,
+		DisallowedTools: req.DisallowedTools,
+		AddDirs:         req.AddDirs,
+		Env:             req.Env,
+		MCPConfig:       req.MCPConfig,
+		MaxFixAttempts:  req.MaxFixAttempts,
+	}
+
+	if err := s.repo.Create(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// UpdateProfile updates an existing agent profile
+func (s *AgentProfileService) UpdateProfile(ctx context.Context, id string, req *models.UpdateAgentProfileRequest) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidAgentProfileID
+	}
+
+	mcpConfig := ""
+	if req.MCPConfig != nil {
+		mcpConfig = *req.MCPConfig
+	}
+	if err := validateAgentProfileFields(req.PermissionMode, mcpConfig); err != nil {
+		return err
+	}
+
+	// Build update document
+	update := bson.M{}
+	if req.Name != "" {
+		existing, err := s.repo.FindByName(ctx, req.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.ID != objectID {
+			return ErrDuplicateAgentProfileName
+		}
+		update["name"] = req.Name
+	}
+	if req.Description != "" {
+		update["description"] = req.Description
+	}
+	if req.Model != "" {
+		update["model"] = req.Model
+	}
+	if req.MaxTurns != nil {
+		update["max_turns"] = *req.MaxTurns
+	}
+	if req.TimeoutSeconds != nil {
+		update["timeout_seconds"] = *req.TimeoutSeconds
+	}
+	if req.PermissionMode != "" {
+		update["permission_mode"] = req.PermissionMode
+	}
+	if req.AllowedTools != nil {
+		update["allowed_tools"] = req.AllowedTools
+	}
+	if req.DisallowedTools != nil {
+		update["disallowed_tools"] = req.DisallowedTools
+	}
+	if req.AddDirs != nil {
+		update["add_dirs"] = req.AddDirs
+	}
+	if req.Env != nil {
+		update["env"] = req.Env
+	}
+	if req.MCPConfig != nil {
+		update["mcp_config"] = *req.MCPConfig
+	}
+	if req.MaxFixAttempts != nil {
+		update["max_fix_attempts"] = *req.MaxFixAttempts
+	}
+
+	if len(update) == 0 {
+		return nil
+	}
+
+	err = s.repo.Update(ctx, objectID, update)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrAgentProfileNotFound
+	}
+	return err
+}
+
+// DeleteProfile deletes an agent profile
+func (s *AgentProfileService) DeleteProfile(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidAgentProfileID
+	}
+
+	// Projects, repositories and running experiments fall back silently when their
+	// profile disappears, so the reference has to be removed first
+	projects, err := s.projectRepo.CountByAgentProfileID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if projects > 0 {
+		return fmt.Errorf("%w by %d project(s)", ErrAgentProfileInUse, projects)
+	}
+	experiments, err := s.experimentRepo.Find(ctx, bson.M{
+		"status":                    models.ExperimentStatusRunning,
+		"variants.agent_profile_id": id,
+	})
+	if err != nil {
+		return err
+	}
+	if len(experiments) > 0 {
+		return fmt.Errorf("%w by running experiment %q", ErrAgentProfileInUse, experiments[0].Name)
+	}
+
+	err = s.repo.Delete(ctx, objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrAgentProfileNotFound
+	}
+	return err
+}
+
+// validateAgentProfileFields checks the permission mode and MCP configuration
+func validateAgentProfileFields(permissionMode, mcpConfig string) error {
+	if permissionMode != "" {
+		valid := false
+		for _, mode := range validPermissionModes {
+			if mode == permissionMode {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("%w: unsupported permission mode %q", ErrInvalidAgentProfile, permissionMode)
+		}
+	}
+
+	if mcpConfig != "" && !json.Valid([]byte(mcpConfig)) {
+		return fmt.Errorf("%w: mcp_config must be valid JSON", ErrInvalidAgentProfile)
+	}
+
+	return nil
+}
@@ -2,10 +2,17 @@ package services
 
 import (
 	"context"
+	"errors"
 
 	"github.com/storos/sdlc-agent/configuration-api/models"
 	"github.com/storos/sdlc-agent/configuration-api/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrDevelopmentNotFound = errors.New("development not found")
+	ErrNotAwaitingApproval = errors.New("development is not awaiting plan approval")
 )
 
 type DevelopmentService struct {
@@ -33,3 +40,39 @@ func (s *DevelopmentService) GetByID(ctx context.Context, id string) (*models.De
 func (s *DevelopmentService) GetByJiraProjectKey(ctx context.Context, jiraProjectKey string) ([]models.Development, error) {
 	return s.repo.GetByJiraProjectKey(ctx, jiraProjectKey)
 }
+
+// ApprovePlan approves the plan of a development awaiting approval. The consumer
+// picks the development up again and starts the implementation.
+func (s *DevelopmentService) ApprovePlan(ctx context.Context, id string, req *models.ApprovePlanRequest) error {
+	return s.reviewPlan(ctx, id, models.DevelopmentStatusPlanApproved, models.PlanStatusApproved, "", req.ReviewedBy)
+}
+
+// RejectPlan rejects the plan of a development awaiting approval. The consumer
+// re-plans using the feedback.
+func (s *DevelopmentService) RejectPlan(ctx context.Context, id string, req *models.RejectPlanRequest) error {
+	return s.reviewPlan(ctx, id, models.DevelopmentStatusPlanRejected, models.PlanStatusRejected, req.Feedback, req.ReviewedBy)
+}
+
+func (s *DevelopmentService) reviewPlan(ctx context.Context, id, status, planStatus, feedback, reviewedBy string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrDevelopmentNotFound
+	}
+
+	reviewed, err := s.repo.ReviewPlan(ctx, objectID, status, planStatus, feedback, reviewedBy)
+	if err != nil {
+		return err
+	}
+	if reviewed {
+		return nil
+	}
+
+	// Distinguish a missing development from one in another status
+	if _, err := s.repo.GetByID(ctx, objectID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrDevelopmentNotFound
+		}
+		return err
+	}
+	return ErrNotAwaitingApproval
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"github.com/storos/sdlc-agent/configuration-api/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrExperimentNotFound = errors.New("experiment not found")
+	ErrInvalidExperiment  = errors.New("invalid experiment")
+	ErrExperimentRunning  = errors.New("project already has a running experiment")
+)
+
+// ExperimentService handles business logic for prompt and profile experiments
+type ExperimentService struct {
+	repo               *repositories.ExperimentRepository
+	developmentRepo    *repositories.DevelopmentRepository
+	projectRepo        *repositories.ProjectRepository
+	promptTemplateRepo *repositories.PromptTemplateRepository
+	agentProfileRepo   *repositories.AgentProfileRepository
+}
+
+// NewExperimentService creates a new experiment service
+func NewExperimentService(
+	repo *repositories.ExperimentRepository,
+	developmentRepo *repositories.DevelopmentRepository,
+	projectRepo *repositories.ProjectRepository,
+	promptTemplateRepo *repositories.PromptTemplateRepository,
+	agentProfileRepo *repositories.AgentProfileRepository,
+) *ExperimentService {
+	return &ExperimentService{
+		repo:               repo,
+		developmentRepo:    developmentRepo,
+		projectRepo:        projectRepo,
+		promptTemplateRepo: promptTemplateRepo,
+		agentProfileRepo:   agentProfileRepo,
+	}
+}
+
+// GetExperiments returns experiments, optionally filtered by JIRA project key and status
+func (s *ExperimentService) GetExperiments(ctx context.Context, jiraProjectKey, status string) ([]models.Experiment, error) {
+	filter := bson.M{}
+	if jiraProjectKey != "" {
+		filter["jira_project_key"] = jiraProjectKey
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	return s.repo.Find(ctx, filter)
+}
+
+// GetExperimentByID returns an experiment by ID
+func (s *ExperimentService) GetExperimentByID(ctx context.Context, id string) (*models.Experiment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid experiment ID: %w", err)
+	}
+
+	experiment, err := s.repo.FindByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if experiment == nil {
+		return nil, ErrExperimentNotFound
+	}
+
+	return experiment, nil
+}
+
+// CreateExperiment creates a running experiment. A project runs one experiment at a time.
+func (s *ExperimentService) CreateExperiment(ctx context.Context, req *models.CreateExperimentRequest) (*models.Experiment, error) {
+	project, err := s.projectRepo.FindByJiraProjectKey(ctx, req.JiraProjectKey)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, fmt.Errorf("%w: no project with JIRA key %s", ErrInvalidExperiment, req.JiraProjectKey)
+	}
+
+	if err := s.validateVariants(ctx, req.Variants); err != nil {
+		return nil, err
+	}
+
+	running, err := s.repo.FindRunningByJiraProjectKey(ctx, req.JiraProjectKey)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		return nil, ErrExperimentRunning
+	}
+
+	experiment := &models.Experiment{
+		Name:           req.Name,
+		Description:    req.Description,
+		JiraProjectKey: req.JiraProjectKey,
+		Status:         models.ExperimentStatusRunning,
+		Variants:       req.Variants,
+	}
+
+	if err := s.repo.Create(ctx, experiment); err != nil {
+		return nil, err
+	}
+
+	return experiment, nil
+}
+
+// UpdateExperiment updates an experiment. Variants of an experiment can be changed,
+// but developments already assigned keep their variant.
+func (s *ExperimentService) UpdateExperiment(ctx context.Context, id string, req *models.UpdateExperimentRequest) error {
+	experiment, err := s.GetExperimentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Build update document
+	update := bson.M{}
+	if req.Name != "" {
+		update["name"] = req.Name
+	}
+	if req.Description != "" {
+		update["description"] = req.Description
+	}
+	if req.Variants != nil {
+		if err := s.validateVariants(ctx, req.Variants); err != nil {
+			return err
+		}
+		update["variants"] = req.Variants
+	}
+	if req.Status != "" && req.Status != experiment.Status {
+		switch req.Status {
+		case models.ExperimentStatusStopped:
+		case models.ExperimentStatusRunning:
+			running, err := s.repo.FindRunningByJiraProjectKey(ctx, experiment.JiraProjectKey)
+			if err != nil {
+				return err
+			}
+			if running != nil {
+				return ErrExperimentRunning
+			}
+		default:
+			return fmt.Errorf("%w: status must be %q or %q", ErrInvalidExperiment, models.ExperimentStatusRunning, models.ExperimentStatusStopped)
+		}
+		update["status"] = req.Status
+	}
+
+	if len(update) == 0 {
+		return nil
+	}
+
+	err = s.repo.Update(ctx, experiment.ID, update)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrExperimentNotFound
+	}
+	return err
+}
+
+// DeleteExperiment deletes an experiment. Assignments recorded on developments are kept.
+func (s *ExperimentService) DeleteExperiment(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid experiment ID: %w", err)
+	}
+
+	err = s.repo.Delete(ctx, objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrExperimentNotFound
+	}
+	return err
+}
+
+// GetResults aggregates the outcomes of the developments assigned to each variant
+func (s *ExperimentService) GetResults(ctx context.Context, id string) (*models.ExperimentResults, error) {
+	experiment, err := s.GetExperimentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	developments, err := s.developmentRepo.GetByExperimentID(ctx, experiment.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ExperimentResults{
+		ExperimentID: experiment.ID.Hex(),
+		Name:         experiment.Name,
+		Status:       experiment.Status,
+		Variants:     aggregateVariantOutcomes(experiment.Variants, developments),
+	}, nil
+}
+
+// PromoteVariant applies the variant's prompt template and agent profile to the project
+// and ends the experiment
+func (s *ExperimentService) PromoteVariant(ctx context.Context, id string, req *models.PromoteExperimentRequest) error {
+	experiment, err := s.GetExperimentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var variant *models.ExperimentVariant
+	for i := range experiment.Variants {
+		if experiment.Variants[i].Name == req.Variant {
+			variant = &experiment.Variants[i]
+			break
+		}
+	}
+	if variant == nil {
+		return fmt.Errorf("%w: unknown variant %q", ErrInvalidExperiment, req.Variant)
+	}
+
+	project, err := s.projectRepo.FindByJiraProjectKey(ctx, experiment.JiraProjectKey)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return ErrProjectNotFound
+	}
+
+	projectUpdate := bson.M{}
+	if variant.PromptTemplateID != "" {
+		projectUpdate["prompt_template_id"] = variant.PromptTemplateID
+	}
+	if variant.AgentProfileID != "" {
+		projectUpdate["agent_profile_id"] = variant.AgentProfileID
+	}
+	if len(projectUpdate) > 0 {
+		if err := s.projectRepo.Update(ctx, project.ID, projectUpdate); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Update(ctx, experiment.ID, bson.M{
+		"status":           models.ExperimentStatusPromoted,
+		"promoted_variant": variant.Name,
+	})
+}
+
+// validateVariants checks that there are at least two uniquely named variants whose
+// weights add up to 100 and whose template and profile exist
+func (s *ExperimentService) validateVariants(ctx context.Context, variants []models.ExperimentVariant) error {
+	if len(variants) < 2 {
+		return fmt.Errorf("%w: at least two variants are required", ErrInvalidExperiment)
+	}
+
+	names := map[string]bool{}
+	total := 0
+	for _, variant := range variants {
+		if variant.Name == "" {
+			return fmt.Errorf("%w: every variant needs a name", ErrInvalidExperiment)
+		}
+		if names[variant.Name] {
+			return fmt.Errorf("%w: duplicate variant name %q", ErrInvalidExperiment, variant.Name)
+		}
+		names[variant.Name] = true
+
+		if variant.Weight <= 0 {
+			return fmt.Errorf("%w: variant %q needs a positive weight", ErrInvalidExperiment, variant.Name)
+		}
+		total += variant.Weight
+
+		if variant.PromptTemplateID != "" {
+			templateID, err := primitive.ObjectIDFromHex(variant.PromptTemplateID)
+			if err != nil {
+				return fmt.Errorf("%w: variant %q has an invalid prompt template ID", ErrInvalidExperiment, variant.Name)
+			}
+			promptTemplate, err := s.promptTemplateRepo.FindByID(ctx, templateID)
+			if err != nil {
+				return err
+			}
+			if promptTemplate == nil {
+				return fmt.Errorf("%w: prompt template of variant %q not found", ErrInvalidExperiment, variant.Name)
+			}
+		}
+		if variant.AgentProfileID != "" {
+			profileID, err := primitive.ObjectIDFromHex(variant.AgentProfileID)
+			if err != nil {
+				return fmt.Errorf("%w: variant %q has an invalid agent profile ID", ErrInvalidExperiment, variant.Name)
+			}
+			profile, err := s.agentProfileRepo.FindByID(ctx, profileID)
+			if err != nil {
+				return err
+			}
+			if profile == nil {
+				return fmt.Errorf("%w: agent profile of variant %q not found", ErrInvalidExperiment, variant.Name)
+			}
+		}
+	}
+
+	if total != 100 {
+		return fmt.Errorf("%w: variant weights must add up to 100, got %d", ErrInvalidExperiment, total)
+	}
+
+	return nil
+}
+
+// aggregateVariantOutcomes computes the outcome of every variant, in variant order.
+// Developments of variants that were removed from the experiment are listed last.
+func aggregateVariantOutcomes(variants []models.ExperimentVariant, developments []models.Development) []models.VariantOutcome {
+	outcomes := []models.VariantOutcome{}
+	index := map[string]int{}
+	for _, variant := range variants {
+		index[variant.Name] = len(outcomes)
+		outcomes = append(outcomes, models.VariantOutcome{Variant: variant.Name})
+	}
+
+	type totals struct {
+		verified, verificationPassed, reviews, reviewed, fixIterations int
+	}
+	sums := make([]totals, len(outcomes))
+
+	for _, dev := range developments {
+		i, ok := index[dev.Experiment.Variant]
+		if !ok {
+			i = len(outcomes)
+			index[dev.Experiment.Variant] = i
+			outcomes = append(outcomes, models.VariantOutcome{Variant: dev.Experiment.Variant})
+			sums = append(sums, totals{})
+		}
+		outcome := &outcomes[i]
+		sum := &sums[i]
+
+		outcome.Developments++
+		switch dev.Status {
+		case "completed":
+			outcome.Completed++
+		case "failed":
+			outcome.Failed++
+		}
+
+		if dev.Verification != nil {
+			sum.verified++
+			if dev.Verification.Passed {
+				sum.verificationPassed++
+			}
+		}
+
+		if dev.PullRequest != nil {
+			switch dev.PullRequest.State {
+			case models.PullRequestStateMerged:
+				outcome.PRsMerged++
+			case models.PullRequestStateClosed:
+				outcome.PRsClosed++
+			default:
+				outcome.PRsOpen++
+			}
+			sum.reviews += dev.PullRequest.Reviews
+			sum.reviewed++
+		}
+
+		for _, iteration := range dev.Iterations {
+			outcome.AgentRuns++
+			if iteration.Kind == "fix" {
+				sum.fixIterations++
+			}
+			if !iteration.CompletedAt.IsZero() && iteration.CompletedAt.After(iteration.StartedAt) {
+				outcome.AgentMinutes += iteration.CompletedAt.Sub(iteration.StartedAt).Minutes()
+			}
+		}
+	}
+
+	for i := range outcomes {
+		outcome := &outcomes[i]
+		sum := sums[i]
+		outcome.SuccessRate = ratio(outcome.Completed, outcome.Completed+outcome.Failed)
+		outcome.VerificationRate = ratio(sum.verificationPassed, sum.verified)
+		outcome.MergeRate = ratio(outcome.PRsMerged, outcome.PRsMerged+outcome.PRsClosed)
+		outcome.AvgReviews = ratio(sum.reviews, sum.reviewed)
+		outcome.AvgFixIterations = ratio(sum.fixIterations, outcome.Developments)
+		if outcome.Developments > 0 {
+			outcome.AvgAgentMinutes = outcome.AgentMinutes / float64(outcome.Developments)
+		}
+	}
+
+	return outcomes
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
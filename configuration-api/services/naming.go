@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/storos/sdlc-agent/configuration-api/models"
+)
+
+// The naming data, functions and ref name rules below mirror the ones the developer agent
+// consumer renders branch names and commit messages with.
+
+// NamingData is the data branch name and commit message templates are rendered with
+type NamingData struct {
+	Key        string
+	ProjectKey string
+	Type       string
+	Summary    string
+	Slug       string
+	Labels     []string
+	Group      string
+}
+
+// namingSamples are the issues naming templates are checked against, the second one as
+// a grouped commit
+var namingSamples = func() []NamingData {
+	grouped := newNamingData("SAMPLE-2", "Bug", "Fix: login fails with 100+ active sessions!", nil)
+	grouped.Group = "tests"
+	return []NamingData{
+		newNamingData("SAMPLE-1", "Story", "Add a health check endpoint", []string{"backend"}),
+		grouped,
+	}
+}()
+
+var namingTemplateFuncs = func() template.FuncMap {
+	funcs := template.FuncMap{"slug": slugify}
+	for name, fn := range promptTemplateFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}()
+
+func newNamingData(key, issueType, summary string, labels []string) NamingData {
+	projectKey, _, _ := strings.Cut(key, "-")
+	return NamingData{
+		Key:        key,
+		ProjectKey: projectKey,
+		Type:       issueType,
+		Summary:    summary,
+		Slug:       slugify(summary),
+		Labels:     labels,
+	}
+}
+
+// validateNamingSettings checks that the branch and commit templates parse and render valid
+// branch names and non-empty commit messages for sample issues
+func validateNamingSettings(settings *models.NamingSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	for _, data := range namingSamples {
+		if settings.BranchTemplate != "" {
+			branchName, err := renderNamingTemplate(settings.BranchTemplate, data)
+			if err != nil {
+				return fmt.Errorf("%w: branch template: %v", ErrInvalidRepository, err)
+			}
+			if err := validateBranchName(strings.TrimSpace(branchName)); err != nil {
+				return fmt.Errorf("%w: branch template: %v", ErrInvalidRepository, err)
+			}
+		}
+		if settings.CommitTemplate != "" {
+			message, err := renderNamingTemplate(settings.CommitTemplate, data)
+			if err != nil {
+				return fmt.Errorf("%w: commit template: %v", ErrInvalidRepository, err)
+			}
+			if strings.TrimSpace(message) == "" {
+				return fmt.Errorf("%w: commit template renders an empty message for %s", ErrInvalidRepository, data.Key)
+			}
+		}
+	}
+	return nil
+}
+
+func renderNamingTemplate(content string, data NamingData) (string, error) {
+	tmpl, err := template.New("naming").Funcs(namingTemplateFuncs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// slugify lower-cases a text and joins its ASCII letters and digits with dashes, cut to
+// at most 50 characters at a word boundary when possible
+func slugify(text string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	result := slug.String()
+	if len(result) > 50 {
+		result = result[:50]
+		if i := strings.LastIndex(result, "-"); i > 0 {
+			result = result[:i]
+		}
+	}
+	return result
+}
+
+// validateBranchName checks a branch name against the rules of git check-ref-format
+func validateBranchName(name string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid branch name %q: %s", name, reason)
+	}
+
+	switch {
+	case name == "" || name == "@":
+		return invalid("must not be empty or @")
+	case strings.HasPrefix(name, "-"):
+		return invalid("must not start with a dash")
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.Contains(name, "//"):
+		return invalid("must not start or end with a slash or contain empty components")
+	case strings.HasSuffix(name, "."):
+		return invalid("must not end with a dot")
+	case strings.Contains(name, ".."):
+		return invalid("must not contain ..")
+	case strings.Contains(name, "@{"):
+		return invalid("must not contain @{")
+	}
+
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return invalid(fmt.Sprintf("must not contain %q", r))
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return invalid("components must not start with a dot or end with .lock")
+		}
+	}
+	return nil
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
 
 	"github.com/storos/sdlc-agent/configuration-api/models"
 	"github.com/storos/sdlc-agent/configuration-api/repositories"
@@ -16,17 +20,27 @@ var (
 	ErrProjectNotFound     = errors.New("project not found")
 	ErrRepositoryNotFound  = errors.New("repository not found")
 	ErrDuplicateProjectKey = errors.New("project with this JIRA key already exists")
+	ErrInvalidRepository   = errors.New("invalid repository settings")
+	ErrInvalidProject      = errors.New("invalid project settings")
+
+	// Partial clone filter limiting the size of the blobs fetched, e.g. blob:limit=1m
+	cloneBlobLimitPattern = regexp.MustCompile(`^blob:limit=[0-9]+[kmg]?$`)
+
+	// Scp-like SSH repository URL, e.g. git@github.com:company/backend.git
+	scpURLPattern = regexp.MustCompile(`^([A-Za-z0-9._-]+@)?[A-Za-z0-9.-]+:[^/].*$`)
 )
 
 // ProjectService handles business logic for projects
 type ProjectService struct {
-	repo *repositories.ProjectRepository
+	repo             *repositories.ProjectRepository
+	agentProfileRepo *repositories.AgentProfileRepository
 }
 
 // NewProjectService creates a new project service
-func NewProjectService(repo *repositories.ProjectRepository) *ProjectService {
+func NewProjectService(repo *repositories.ProjectRepository, agentProfileRepo *repositories.AgentProfileRepository) *ProjectService {
 	return &ProjectService{
-		repo: repo,
+		repo:             repo,
+		agentProfileRepo: agentProfileRepo,
 	}
 }
 
@@ -70,6 +84,24 @@ func (s *ProjectService) GetProjectByJiraKey(ctx context.Context, jiraProjectKey
 
 // CreateProject creates a new project
 func (s *ProjectService) CreateProject(ctx context.Context, req *models.CreateProjectRequest) (*models.Project, error) {
+	if err := validateBestOfNSettings(req.BestOfN); err != nil {
+		return nil, err
+	}
+	if err := validateReadinessSettings(req.Readiness); err != nil {
+		return nil, err
+	}
+	if err := validateCommitSettings(req.Commit); err != nil {
+		return nil, err
+	}
+	if err := s.validateAgentProfileRefs(ctx, req.AgentProfileID, req.BestOfN, ErrInvalidProject); err != nil {
+		return nil, err
+	}
+	for i := range req.Repositories {
+		if err := s.validateRepository(ctx, &req.Repositories[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if project with same JIRA key already exists
 	existing, err := s.repo.FindByJiraProjectKey(ctx, req.JiraProjectKey)
 	if err != nil {
@@ -80,13 +112,19 @@ func (s *ProjectService) CreateProject(ctx context.Context, req *models.CreatePr
 	}
 
 	project := &models.Project{
-		Name:            req.Name,
-		Description:     req.Description,
-		Scope:           req.Scope,
-		JiraProjectKey:  req.JiraProjectKey,
-		JiraProjectName: req.JiraProjectName,
-		JiraProjectURL:  req.JiraProjectURL,
-		Repositories:    req.Repositories,
+		Name:             req.Name,
+		Description:      req.Description,
+		Scope:            req.Scope,
+		JiraProjectKey:   req.JiraProjectKey,
+		JiraProjectName:  req.JiraProjectName,
+		JiraProjectURL:   req.JiraProjectURL,
+		Repositories:     req.Repositories,
+		AgentProfileID:   req.AgentProfileID,
+		PromptTemplateID: req.PromptTemplateID,
+		BestOfN:          req.BestOfN,
+		PlanFirst:        req.PlanFirst,
+		Readiness:        req.Readiness,
+		Commit:           req.Commit,
 	}
 
 	// Initialize repositories if nil
@@ -113,6 +151,28 @@ func (s *ProjectService) UpdateProject(ctx context.Context, id string, req *mode
 		return fmt.Errorf("invalid project ID: %w", err)
 	}
 
+	if err := validateBestOfNSettings(req.BestOfN); err != nil {
+		return err
+	}
+	if err := validateReadinessSettings(req.Readiness); err != nil {
+		return err
+	}
+	if err := validateCommitSettings(req.Commit); err != nil {
+		return err
+	}
+	agentProfileID := ""
+	if req.AgentProfileID != nil {
+		agentProfileID = *req.AgentProfileID
+	}
+	if err := s.validateAgentProfileRefs(ctx, agentProfileID, req.BestOfN, ErrInvalidProject); err != nil {
+		return err
+	}
+	for i := range req.Repositories {
+		if err := s.validateRepository(ctx, &req.Repositories[i]); err != nil {
+			return err
+		}
+	}
+
 	// Build update document
 	update := bson.M{}
 	if req.Name != "" {
@@ -144,6 +204,25 @@ func (s *ProjectService) UpdateProject(ctx context.Context, id string, req *mode
 	if req.Repositories != nil {
 		update["repositories"] = req.Repositories
 	}
+	if req.AgentProfileID != nil {
+		// An empty ID clears the reference
+		update["agent_profile_id"] = *req.AgentProfileID
+	}
+	if req.PromptTemplateID != "" {
+		update["prompt_template_id"] = req.PromptTemplateID
+	}
+	if req.BestOfN != nil {
+		update["best_of_n"] = req.BestOfN
+	}
+	if req.PlanFirst != nil {
+		update["plan_first"] = req.PlanFirst
+	}
+	if req.Readiness != nil {
+		update["readiness"] = req.Readiness
+	}
+	if req.Commit != nil {
+		update["commit"] = req.Commit
+	}
 
 	if len(update) == 0 {
 		return nil
@@ -177,6 +256,46 @@ func (s *ProjectService) AddRepository(ctx context.Context, projectID string, re
 		return fmt.Errorf("invalid project ID: %w", err)
 	}
 
+	if err := validateVerificationSettings(req.Verification); err != nil {
+		return err
+	}
+	if err := validateConventionSettings(req.Conventions); err != nil {
+		return err
+	}
+	if err := validateRepoMapSettings(req.RepoMap); err != nil {
+		return err
+	}
+	if err := validateRetrievalSettings(req.Retrieval); err != nil {
+		return err
+	}
+	if err := validateCloneSettings(req.Clone); err != nil {
+		return err
+	}
+	if err := validateRepositoryURL(req.URL); err != nil {
+		return err
+	}
+	if err := validateSSHKey(req.SSHKey); err != nil {
+		return err
+	}
+	if err := validateRerunSettings(req.Rerun); err != nil {
+		return err
+	}
+	if err := validateNamingSettings(req.Naming); err != nil {
+		return err
+	}
+	if err := validateBaseSyncSettings(req.BaseSync); err != nil {
+		return err
+	}
+	if err := validateCommitGroupSettings(req.CommitGroups); err != nil {
+		return err
+	}
+	if err := validateForkSettings(req.Fork); err != nil {
+		return err
+	}
+	if err := s.validateAgentProfileRef(ctx, req.AgentProfileID, ErrInvalidRepository); err != nil {
+		return err
+	}
+
 	// Default base branch to "main" if not specified
 	baseBranch := req.BaseBranch
 	if baseBranch == "" {
@@ -188,6 +307,18 @@ func (s *ProjectService) AddRepository(ctx context.Context, projectID string, re
 		Description:    req.Description,
 		GitAccessToken: req.GitAccessToken,
 		BaseBranch:     baseBranch,
+		AgentProfileID: req.AgentProfileID,
+		Verification:   req.Verification,
+		Conventions:    req.Conventions,
+		RepoMap:        req.RepoMap,
+		Retrieval:      req.Retrieval,
+		Clone:          req.Clone,
+		SSHKey:         req.SSHKey,
+		Rerun:          req.Rerun,
+		Naming:         req.Naming,
+		BaseSync:       req.BaseSync,
+		CommitGroups:   req.CommitGroups,
+		Fork:           req.Fork,
 	}
 
 	err = s.repo.AddRepository(ctx, objectID, repo)
@@ -207,6 +338,9 @@ func (s *ProjectService) UpdateRepository(ctx context.Context, projectID, repoID
 	// Build update document
 	update := bson.M{}
 	if req.URL != "" {
+		if err := validateRepositoryURL(req.URL); err != nil {
+			return err
+		}
 		update["url"] = req.URL
 	}
 	if req.Description != "" {
@@ -218,6 +352,79 @@ func (s *ProjectService) UpdateRepository(ctx context.Context, projectID, repoID
 	if req.BaseBranch != "" {
 		update["base_branch"] = req.BaseBranch
 	}
+	if req.AgentProfileID != nil {
+		if err := s.validateAgentProfileRef(ctx, *req.AgentProfileID, ErrInvalidRepository); err != nil {
+			return err
+		}
+		// An empty ID clears the override
+		update["agent_profile_id"] = *req.AgentProfileID
+	}
+	if req.Verification != nil {
+		if err := validateVerificationSettings(req.Verification); err != nil {
+			return err
+		}
+		update["verification"] = req.Verification
+	}
+	if req.Conventions != nil {
+		if err := validateConventionSettings(req.Conventions); err != nil {
+			return err
+		}
+		update["conventions"] = req.Conventions
+	}
+	if req.RepoMap != nil {
+		if err := validateRepoMapSettings(req.RepoMap); err != nil {
+			return err
+		}
+		update["repo_map"] = req.RepoMap
+	}
+	if req.Retrieval != nil {
+		if err := validateRetrievalSettings(req.Retrieval); err != nil {
+			return err
+		}
+		update["retrieval"] = req.Retrieval
+	}
+	if req.Clone != nil {
+		if err := validateCloneSettings(req.Clone); err != nil {
+			return err
+		}
+		update["clone"] = req.Clone
+	}
+	if req.SSHKey != nil {
+		if err := validateSSHKey(req.SSHKey); err != nil {
+			return err
+		}
+		update["ssh_key"] = req.SSHKey
+	}
+	if req.Rerun != nil {
+		if err := validateRerunSettings(req.Rerun); err != nil {
+			return err
+		}
+		update["rerun"] = req.Rerun
+	}
+	if req.Naming != nil {
+		if err := validateNamingSettings(req.Naming); err != nil {
+			return err
+		}
+		update["naming"] = req.Naming
+	}
+	if req.BaseSync != nil {
+		if err := validateBaseSyncSettings(req.BaseSync); err != nil {
+			return err
+		}
+		update["base_sync"] = req.BaseSync
+	}
+	if req.CommitGroups != nil {
+		if err := validateCommitGroupSettings(req.CommitGroups); err != nil {
+			return err
+		}
+		update["commit_groups"] = req.CommitGroups
+	}
+	if req.Fork != nil {
+		if err := validateForkSettings(req.Fork); err != nil {
+			return err
+		}
+		update["fork"] = req.Fork
+	}
 
 	if len(update) == 0 {
 		return nil
@@ -243,3 +450,343 @@ func (s *ProjectService) DeleteRepository(ctx context.Context, projectID, repoID
 	}
 	return err
 }
+
+// validateRepository checks the settings of a repository given in full, as on project
+// creation or when the repositories of a project are replaced
+func (s *ProjectService) validateRepository(ctx context.Context, repo *models.Repository) error {
+	if err := s.validateAgentProfileRef(ctx, repo.AgentProfileID, ErrInvalidRepository); err != nil {
+		return err
+	}
+	if err := validateRepositoryURL(repo.URL); err != nil {
+		return err
+	}
+	if err := validateSSHKey(repo.SSHKey); err != nil {
+		return err
+	}
+	if err := validateRerunSettings(repo.Rerun); err != nil {
+		return err
+	}
+	if err := validateNamingSettings(repo.Naming); err != nil {
+		return err
+	}
+	if err := validateBaseSyncSettings(repo.BaseSync); err != nil {
+		return err
+	}
+	if err := validateCommitGroupSettings(repo.CommitGroups); err != nil {
+		return err
+	}
+	if err := validateForkSettings(repo.Fork); err != nil {
+		return err
+	}
+	if err := validateVerificationSettings(repo.Verification); err != nil {
+		return err
+	}
+	if err := validateConventionSettings(repo.Conventions); err != nil {
+		return err
+	}
+	if err := validateRepoMapSettings(repo.RepoMap); err != nil {
+		return err
+	}
+	if err := validateRetrievalSettings(repo.Retrieval); err != nil {
+		return err
+	}
+	if err := validateCloneSettings(repo.Clone); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateVerificationSettings checks the verification policy of a repository
+func validateVerificationSettings(settings *models.VerificationSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	switch settings.Policy {
+	case "", models.VerificationPolicyBlock, models.VerificationPolicyDraft,
+		models.VerificationPolicyAnnotate, models.VerificationPolicyDisabled:
+	default:
+		return fmt.Errorf("%w: unsupported verification policy %q", ErrInvalidRepository, settings.Policy)
+	}
+
+	if settings.TimeoutSeconds < 0 {
+		return fmt.Errorf("%w: verification timeout must not be negative", ErrInvalidRepository)
+	}
+
+	return nil
+}
+
+// validateConventionSettings checks the convention file globs and size budget of a repository
+func validateConventionSettings(settings *models.ConventionSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	for _, glob := range settings.Globs {
+		if strings.TrimSpace(glob) == "" {
+			return fmt.Errorf("%w: convention globs must not be empty", ErrInvalidRepository)
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("%w: invalid convention glob %q", ErrInvalidRepository, glob)
+		}
+	}
+
+	if settings.MaxBytes < 0 || settings.MaxFileBytes < 0 {
+		return fmt.Errorf("%w: convention budgets must not be negative", ErrInvalidRepository)
+	}
+
+	return nil
+}
+
+// validateRepoMapSettings checks the repository map budget of a repository
+func validateRepoMapSettings(settings *models.RepoMapSettings) error {
+	if settings != nil && settings.MaxTokens < 0 {
+		return fmt.Errorf("%w: repository map budget must not be negative", ErrInvalidRepository)
+	}
+	return nil
+}
+
+// validateRetrievalSettings checks the number of relevant files of a repository
+func validateRetrievalSettings(settings *models.RetrievalSettings) error {
+	if settings != nil && (settings.TopK < 0 || settings.TopK > 20) {
+		return fmt.Errorf("%w: retrieval top_k must be between 0 and 20", ErrInvalidRepository)
+	}
+	return nil
+}
+
+// validateCloneSettings checks the clone depth, filter and sparse checkout paths of a
+// repository
+func validateCloneSettings(settings *models.CloneSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	if settings.Depth < 0 {
+		return fmt.Errorf("%w: clone depth must not be negative", ErrInvalidRepository)
+	}
+	if settings.Filter != "" && settings.Filter != "blob:none" && settings.Filter != "tree:0" && !cloneBlobLimitPattern.MatchString(settings.Filter) {
+		return fmt.Errorf("%w: clone filter must be blob:none, blob:limit=<size> or tree:0", ErrInvalidRepository)
+	}
+	for _, sparsePath := range settings.SparsePaths {
+		cleaned := path.Clean(sparsePath)
+		if sparsePath == "" || path.IsAbs(sparsePath) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.ContainsAny(sparsePath, "*?[") {
+			return fmt.Errorf("%w: sparse path %q must be a directory relative to the repository root", ErrInvalidRepository, sparsePath)
+		}
+	}
+
+	return nil
+}
+
+// validateRepositoryURL accepts HTTP(S) and SSH repository URLs, including the scp-like
+// form git@host:org/repo.git
+func validateRepositoryURL(repoURL string) error {
+	if scpURLPattern.MatchString(repoURL) && !strings.Contains(repoURL, "://") {
+		return nil
+	}
+
+	parsed, err := url.Parse(repoURL)
+	if err != nil || parsed.Host == "" || strings.Trim(parsed.Path, "/") == "" {
+		return fmt.Errorf("%w: invalid repository URL %q", ErrInvalidRepository, repoURL)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "ssh":
+		return nil
+	}
+	return fmt.Errorf("%w: repository URL %q must use https or ssh", ErrInvalidRepository, repoURL)
+}
+
+// validateSSHKey checks that a deploy key holds a private key
+func validateSSHKey(credential *models.SSHKeyCredential) error {
+	if credential == nil {
+		return nil
+	}
+
+	if !strings.Contains(credential.PrivateKey, "-----BEGIN") {
+		return fmt.Errorf("%w: ssh_key private_key must be a PEM or OpenSSH private key", ErrInvalidRepository)
+	}
+	if strings.TrimSpace(credential.KnownHosts) == "" {
+		return fmt.Errorf("%w: ssh_key known_hosts is required to pin the server's host keys", ErrInvalidRepository)
+	}
+	return nil
+}
+
+// validateRerunSettings checks the policy applied to branches left by previous runs
+func validateRerunSettings(settings *models.RerunSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	switch settings.Policy {
+	case "", "append", "replace":
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported rerun policy %q", ErrInvalidRepository, settings.Policy)
+}
+
+// validateBaseSyncSettings checks the strategy updating branches whose base branch moved
+func validateBaseSyncSettings(settings *models.BaseSyncSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	switch settings.Strategy {
+	case "", "rebase", "merge", "disabled":
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported base sync strategy %q", ErrInvalidRepository, settings.Strategy)
+}
+
+// validateForkSettings checks that a fork is either given by URL or created by the agent
+func validateForkSettings(settings *models.ForkSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	if settings.URL == "" {
+		if !settings.Create {
+			return fmt.Errorf("%w: fork needs a url or create", ErrInvalidRepository)
+		}
+		return nil
+	}
+	if err := validateRepositoryURL(settings.URL); err != nil {
+		return fmt.Errorf("%w: invalid fork url %q", ErrInvalidRepository, settings.URL)
+	}
+	return nil
+}
+
+// validateCommitGroupSettings checks the groups the agent's changes are committed in
+func validateCommitGroupSettings(settings *models.CommitGroupSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	names := map[string]bool{}
+	fallbacks := 0
+	for _, group := range settings.Groups {
+		name := strings.TrimSpace(group.Name)
+		if name == "" {
+			return fmt.Errorf("%w: commit groups must have a name", ErrInvalidRepository)
+		}
+		if names[name] {
+			return fmt.Errorf("%w: duplicate commit group %q", ErrInvalidRepository, name)
+		}
+		names[name] = true
+
+		if len(group.Globs) == 0 {
+			fallbacks++
+		}
+		for _, glob := range group.Globs {
+			if strings.TrimSpace(glob) == "" {
+				return fmt.Errorf("%w: commit group globs must not be empty", ErrInvalidRepository)
+			}
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("%w: invalid commit group glob %q", ErrInvalidRepository, glob)
+			}
+		}
+	}
+	if fallbacks > 1 {
+		return fmt.Errorf("%w: only one commit group may be without globs", ErrInvalidRepository)
+	}
+
+	return nil
+}
+
+// validateBestOfNSettings limits the number of parallel candidates per project
+func validateBestOfNSettings(settings *models.BestOfNSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	if settings.Candidates < 0 || settings.Candidates > 5 {
+		return fmt.Errorf("%w: best_of_n candidates must be between 0 and 5", ErrInvalidProject)
+	}
+
+	return nil
+}
+
+// validateAgentProfileRefs checks the project agent profile and the best-of-N
+// candidate profiles exist
+func (s *ProjectService) validateAgentProfileRefs(ctx context.Context, agentProfileID string, bestOfN *models.BestOfNSettings, invalid error) error {
+	if err := s.validateAgentProfileRef(ctx, agentProfileID, invalid); err != nil {
+		return err
+	}
+	if bestOfN != nil {
+		for _, id := range bestOfN.AgentProfileIDs {
+			if id == "" {
+				return fmt.Errorf("%w: best_of_n agent_profile_ids must not be empty", invalid)
+			}
+			if err := s.validateAgentProfileRef(ctx, id, invalid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateAgentProfileRef checks an agent profile reference points to an existing
+// profile, an empty reference means none
+func (s *ProjectService) validateAgentProfileRef(ctx context.Context, agentProfileID string, invalid error) error {
+	if agentProfileID == "" {
+		return nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(agentProfileID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid agent profile ID %q", invalid, agentProfileID)
+	}
+	profile, err := s.agentProfileRepo.FindByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("%w: agent profile %q not found", invalid, agentProfileID)
+	}
+
+	return nil
+}
+
+// validateCommitSettings checks the commit identity, the signing format and the user
+// mapping of a project
+func validateCommitSettings(settings *models.CommitSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	if settings.AuthorEmail != "" && !strings.Contains(settings.AuthorEmail, "@") {
+		return fmt.Errorf("%w: commit author_email %q is not an email address", ErrInvalidProject, settings.AuthorEmail)
+	}
+	if signing := settings.Signing; signing != nil {
+		if signing.Format != "gpg" && signing.Format != "ssh" {
+			return fmt.Errorf("%w: commit signing format must be gpg or ssh", ErrInvalidProject)
+		}
+		if signing.KeySecret == "" {
+			return fmt.Errorf("%w: commit signing key_secret is required", ErrInvalidProject)
+		}
+		if strings.ContainsAny(signing.KeySecret+signing.PassphraseSecret, `/\`) {
+			return fmt.Errorf("%w: commit signing secrets must be secret names, not paths", ErrInvalidProject)
+		}
+	}
+	for _, mapping := range settings.UserMapping {
+		if mapping.JiraUser == "" || mapping.Name == "" || !strings.Contains(mapping.Email, "@") {
+			return fmt.Errorf("%w: commit user_mapping entries need jira_user, name and email", ErrInvalidProject)
+		}
+	}
+
+	return nil
+}
+
+// validateReadinessSettings checks the readiness threshold and description length
+func validateReadinessSettings(settings *models.ReadinessSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	if settings.Threshold < 0 || settings.Threshold > 100 {
+		return fmt.Errorf("%w: readiness threshold must be between 0 and 100", ErrInvalidProject)
+	}
+	if settings.MinDescriptionLength < 0 {
+		return fmt.Errorf("%w: readiness min_description_length must not be negative", ErrInvalidProject)
+	}
+
+	return nil
+}
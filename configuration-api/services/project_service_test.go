@@ -89,7 +89,7 @@ func (m *MockProjectRepository) Delete(ctx context.Context, id primitive.ObjectI
 
 func TestCreateProject(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -112,7 +112,7 @@ func TestCreateProject(t *testing.T) {
 
 func TestCreateProject_DuplicateKey(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project1 := &models.Project{
 		Name:            "Test Project 1",
@@ -145,7 +145,7 @@ func TestCreateProject_DuplicateKey(t *testing.T) {
 
 func TestGetAllProjects(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -170,7 +170,7 @@ func TestGetAllProjects(t *testing.T) {
 
 func TestGetProjectByID(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -195,7 +195,7 @@ func TestGetProjectByID(t *testing.T) {
 
 func TestGetProjectByID_NotFound(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	id := primitive.NewObjectID()
 	_, err := service.GetProjectByID(context.Background(), id)
@@ -206,7 +206,7 @@ func TestGetProjectByID_NotFound(t *testing.T) {
 
 func TestGetProjectByJiraKey(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -231,7 +231,7 @@ func TestGetProjectByJiraKey(t *testing.T) {
 
 func TestUpdateProject(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -258,7 +258,7 @@ func TestUpdateProject(t *testing.T) {
 
 func TestUpdateProject_NotFound(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	id := primitive.NewObjectID()
 	project := &models.Project{
@@ -278,7 +278,7 @@ func TestUpdateProject_NotFound(t *testing.T) {
 
 func TestDeleteProject(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -304,7 +304,7 @@ func TestDeleteProject(t *testing.T) {
 
 func TestAddRepository(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -336,7 +336,7 @@ func TestAddRepository(t *testing.T) {
 
 func TestUpdateRepository(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -376,7 +376,7 @@ func TestUpdateRepository(t *testing.T) {
 
 func TestDeleteRepository(t *testing.T) {
 	mockRepo := NewMockProjectRepository()
-	service := NewProjectService(mockRepo)
+	service := NewProjectService(mockRepo, nil)
 
 	project := &models.Project{
 		Name:            "Test Project",
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/storos/sdlc-agent/configuration-api/models"
+)
+
+// The template data and functions below mirror the ones the developer agent consumer
+// renders prompts with, so a preview matches the prompt the agent receives. The field
+// sets are compared with the consumer's by TestPromptData_MatchesConfigurationAPI in the
+// consumer; change both sides together.
+
+// PromptData is the data a prompt template is rendered with
+type PromptData struct {
+	Request    PromptRequest
+	Issue      PromptIssue
+	Project    PromptProject
+	Repository PromptRepository
+	Analysis   *PromptAnalysis
+}
+
+// PromptRequest mirrors the development request received by the consumer
+type PromptRequest struct {
+	JiraIssueID    string
+	JiraIssueKey   string
+	JiraProjectKey string
+	Summary        string
+	Description    string
+	IssueType      string
+	Labels         []string
+	Repository     string
+	Action         string
+	Comment        string
+	CommentAuthor  string
+	Assignee       *PromptAssignee
+	DevelopmentID  string
+	ResumeToken    string
+}
+
+// PromptAssignee mirrors the JIRA user an issue is assigned to
+type PromptAssignee struct {
+	AccountID   string
+	Name        string
+	Email       string
+	DisplayName string
+}
+
+// PromptIssue is the JIRA issue enriched with project details
+type PromptIssue struct {
+	Key         string
+	ProjectKey  string
+	Summary     string
+	Description string
+	Type        string
+	Labels      []string
+	URL         string
+}
+
+// PromptProject holds the project fields available to templates
+type PromptProject struct {
+	Name            string
+	Description     string
+	Scope           string
+	JiraProjectKey  string
+	JiraProjectName string
+	JiraProjectURL  string
+}
+
+// PromptRepository holds the repository fields available to templates
+type PromptRepository struct {
+	URL         string
+	Description string
+	BaseBranch  string
+}
+
+// PromptAnalysis mirrors the repository analysis of the consumer
+type PromptAnalysis struct {
+	EntryPoints          []string
+	KeyDirectories       []string
+	ConfigFiles          []string
+	Languages            []string
+	Patterns             map[string]string
+	ProjectType          string
+	DependencyManagers   []string
+	VerificationCommands []PromptVerificationCommand
+	Conventions          []PromptConventionFile
+	Manifests            []PromptManifest
+	Frameworks           []string
+	TestFrameworks       []string
+	Workspaces           []string
+	Modules              []PromptModule
+	TargetModules        []string
+	RepoMap              *PromptRepoMap
+	RelevantFiles        []PromptRetrievedFile
+}
+
+// PromptVerificationCommand mirrors a build, lint or test command detected by the consumer
+type PromptVerificationCommand struct {
+	Stage   string
+	Command string
+	Dir     string
+}
+
+// PromptRetrievedFile mirrors a file ranked against the issue
+type PromptRetrievedFile struct {
+	Path      string
+	Score     float64
+	Symbols   []string
+	Excerpt   string
+	StartLine int
+}
+
+// PromptRepoMap mirrors the repository map of the consumer
+type PromptRepoMap struct {
+	Files     []PromptMapFile
+	Content   string
+	Truncated bool
+}
+
+// PromptMapFile mirrors a file of the repository map
+type PromptMapFile struct {
+	Path     string
+	Language string
+	Symbols  []string
+}
+
+// PromptModule mirrors a module of a monorepo
+type PromptModule struct {
+	Path           string
+	Name           string
+	ProjectType    string
+	Languages      []string
+	Manifests      []string
+	Frameworks     []string
+	TestFrameworks []string
+	EntryPoints    []string
+	KeyDirectories []string
+	Relevance      int
+}
+
+// PromptManifest mirrors a dependency manifest parsed by the consumer
+type PromptManifest struct {
+	Path            string
+	Ecosystem       string
+	Name            string
+	LanguageVersion string
+	Dependencies    []PromptDependency
+	Frameworks      []string
+	TestFrameworks  []string
+	Scripts         map[string]string
+}
+
+// PromptDependency mirrors a direct dependency declared in a manifest
+type PromptDependency struct {
+	Name    string
+	Version string
+	Dev     bool
+}
+
+// PromptConventionFile mirrors a convention document read by the consumer
+type PromptConventionFile struct {
+	Path      string
+	Content   string
+	Truncated bool
+}
+
+var promptTemplateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	"hasLabel": func(labels []string, label string) bool {
+		for _, l := range labels {
+			if strings.EqualFold(l, label) {
+				return true
+			}
+		}
+		return false
+	},
+	"default": func(fallback, value string) string {
+		if strings.TrimSpace(value) == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// parsePromptTemplate parses a prompt template with the template functions
+func parsePromptTemplate(content string) (*template.Template, error) {
+	return template.New("prompt").Funcs(promptTemplateFuncs).Option("missingkey=error").Parse(content)
+}
+
+// renderPromptTemplate renders a prompt template with the given data
+func renderPromptTemplate(content string, data *PromptData) (string, error) {
+	tmpl, err := parsePromptTemplate(content)
+	if err != nil {
+		return "", err
+	}
+
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, data); err != nil {
+		return "", err
+	}
+
+	return prompt.String(), nil
+}
+
+// newPreviewData builds the template data from a sample issue, an optional project and analysis.
+// The first repository of the project is used.
+func newPreviewData(issue *models.SampleIssue, project *models.Project, analysis *models.SampleAnalysis) *PromptData {
+	if issue == nil {
+		issue = &models.SampleIssue{
+			Key:         "SAMPLE-1",
+			Summary:     "Add a health check endpoint",
+			Description: "Expose GET /health returning the service status.\n\nAcceptance Criteria:\n- [ ] returns 200 with {\"status\": \"healthy\"}",
+			IssueType:   "Story",
+		}
+	}
+	if analysis == nil {
+		analysis = &models.SampleAnalysis{
+			ProjectType:    "go",
+			Languages:      []string{"Go"},
+			EntryPoints:    []string{"main.go"},
+			KeyDirectories: []string{"handlers", "services", "models"},
+			Frameworks:     []string{"Gin"},
+			TestFrameworks: []string{"go test"},
+		}
+	}
+
+	projectKey := issue.Key
+	if i := strings.Index(projectKey, "-"); i > 0 {
+		projectKey = projectKey[:i]
+	}
+
+	data := &PromptData{
+		Request: PromptRequest{
+			JiraIssueKey:   issue.Key,
+			JiraProjectKey: projectKey,
+			Summary:        issue.Summary,
+			Description:    issue.Description,
+			IssueType:      issue.IssueType,
+			Labels:         issue.Labels,
+		},
+		Issue: PromptIssue{
+			Key:         issue.Key,
+			ProjectKey:  projectKey,
+			Summary:     issue.Summary,
+			Description: issue.Description,
+			Type:        issue.IssueType,
+			Labels:      issue.Labels,
+		},
+		Analysis: &PromptAnalysis{
+			EntryPoints:          analysis.EntryPoints,
+			KeyDirectories:       analysis.KeyDirectories,
+			ConfigFiles:          analysis.ConfigFiles,
+			Languages:            analysis.Languages,
+			Patterns:             analysis.Patterns,
+			ProjectType:          analysis.ProjectType,
+			VerificationCommands: []PromptVerificationCommand{},
+			Conventions:          []PromptConventionFile{},
+			Manifests:            []PromptManifest{},
+			Modules:              []PromptModule{},
+			RepoMap:              &PromptRepoMap{Files: []PromptMapFile{}},
+			RelevantFiles:        []PromptRetrievedFile{},
+			Frameworks:           analysis.Frameworks,
+			TestFrameworks:       analysis.TestFrameworks,
+		},
+	}
+	for _, convention := range analysis.Conventions {
+		data.Analysis.Conventions = append(data.Analysis.Conventions, PromptConventionFile{
+			Path:      convention.Path,
+			Content:   convention.Content,
+			Truncated: convention.Truncated,
+		})
+	}
+
+	if project != nil {
+		data.Project = PromptProject{
+			Name:            project.Name,
+			Description:     project.Description,
+			Scope:           project.Scope,
+			JiraProjectKey:  project.JiraProjectKey,
+			JiraProjectName: project.JiraProjectName,
+			JiraProjectURL:  project.JiraProjectURL,
+		}
+		if len(project.Repositories) > 0 {
+			repo := project.Repositories[0]
+			data.Request.Repository = repo.URL
+			data.Repository = PromptRepository{
+				URL:         repo.URL,
+				Description: repo.Description,
+				BaseBranch:  repo.BaseBranch,
+			}
+		}
+		if project.JiraProjectURL != "" {
+			data.Issue.URL = issueURL(project.JiraProjectURL, issue.Key)
+		}
+	}
+
+	return data
+}
+
+// issueURL builds the browse URL of an issue from the JIRA project URL
+func issueURL(projectURL, issueKey string) string {
+	base := strings.TrimRight(projectURL, "/")
+	for _, marker := range []string{"/jira/", "/projects/", "/browse/"} {
+		if i := strings.Index(base, marker); i >= 0 {
+			base = base[:i]
+		}
+	}
+	return fmt.Sprintf("%s/browse/%s", base, issueKey)
+}
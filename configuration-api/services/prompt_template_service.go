@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/storos/sdlc-agent/configuration-api/models"
+	"github.com/storos/sdlc-agent/configuration-api/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrPromptTemplateNotFound      = errors.New("prompt template not found")
+	ErrDuplicatePromptTemplateName = errors.New("prompt template with this name already exists")
+	ErrInvalidPromptTemplate       = errors.New("invalid prompt template")
+)
+
+// PromptTemplateService handles business logic for prompt templates
+type PromptTemplateService struct {
+	repo        *repositories.PromptTemplateRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewPromptTemplateService creates a new prompt template service
+func NewPromptTemplateService(repo *repositories.PromptTemplateRepository, projectRepo *repositories.ProjectRepository) *PromptTemplateService {
+	return &PromptTemplateService{
+		repo:        repo,
+		projectRepo: projectRepo,
+	}
+}
+
+// GetAllTemplates returns all prompt templates
+func (s *PromptTemplateService) GetAllTemplates(ctx context.Context) ([]models.PromptTemplate, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// GetTemplateByID returns a prompt template by ID
+func (s *PromptTemplateService) GetTemplateByID(ctx context.Context, id string) (*models.PromptTemplate, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template ID: %w", err)
+	}
+
+	promptTemplate, err := s.repo.FindByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if promptTemplate == nil {
+		return nil, ErrPromptTemplateNotFound
+	}
+
+	return promptTemplate, nil
+}
+
+// CreateTemplate creates a new prompt template
+func (s *PromptTemplateService) CreateTemplate(ctx context.Context, req *models.CreatePromptTemplateRequest) (*models.PromptTemplate, error) {
+	if err := validatePromptTemplateContent(req.Content); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByName(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDuplicatePromptTemplateName
+	}
+
+	promptTemplate := &models.PromptTemplate{
+		Name:        req.Name,
+		Description: req.Description,
+		Content:     req.Content,
+	}
+
+	if err := s.repo.Create(ctx, promptTemplate); err != nil {
+		return nil, err
+	}
+
+	return promptTemplate, nil
+}
+
+// UpdateTemplate updates an existing prompt template. A content change keeps the
+// previous content in the template history.
+func (s *PromptTemplateService) UpdateTemplate(ctx context.Context, id string, req *models.UpdatePromptTemplateRequest) error {
+	existing, err := s.GetTemplateByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Build update document
+	update := bson.M{}
+	var previous *models.PromptTemplateRevision
+	if req.Name != "" {
+		named, err := s.repo.FindByName(ctx, req.Name)
+		if err != nil {
+			return err
+		}
+		if named != nil && named.ID != existing.ID {
+			return ErrDuplicatePromptTemplateName
+		}
+		update["name"] = req.Name
+	}
+	if req.Description != "" {
+		update["description"] = req.Description
+	}
+	if req.Content != "" && req.Content != existing.Content {
+		if err := validatePromptTemplateContent(req.Content); err != nil {
+			return err
+		}
+		update["content"] = req.Content
+		previous = &models.PromptTemplateRevision{
+			Version:   existing.Version,
+			Content:   existing.Content,
+			UpdatedAt: time.Now(),
+		}
+	}
+
+	if len(update) == 0 {
+		return nil
+	}
+
+	err = s.repo.Update(ctx, existing.ID, update, previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrPromptTemplateNotFound
+	}
+	return err
+}
+
+// DeleteTemplate deletes a prompt template
+func (s *PromptTemplateService) DeleteTemplate(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid prompt template ID: %w", err)
+	}
+
+	err = s.repo.Delete(ctx, objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrPromptTemplateNotFound
+	}
+	return err
+}
+
+// PreviewTemplate renders a stored template or inline content against a sample issue
+func (s *PromptTemplateService) PreviewTemplate(ctx context.Context, req *models.PreviewPromptTemplateRequest) (*models.PreviewPromptTemplateResponse, error) {
+	response := &models.PreviewPromptTemplateResponse{}
+	content := req.Content
+	if content == "" {
+		if req.TemplateID == "" {
+			return nil, fmt.Errorf("%w: template_id or content is required", ErrInvalidPromptTemplate)
+		}
+		promptTemplate, err := s.GetTemplateByID(ctx, req.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		content = promptTemplate.Content
+		response.TemplateVersion = promptTemplate.Version
+	}
+
+	var project *models.Project
+	if req.ProjectID != "" {
+		projectID, err := primitive.ObjectIDFromHex(req.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project ID: %w", err)
+		}
+		project, err = s.projectRepo.FindByID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		if project == nil {
+			return nil, ErrProjectNotFound
+		}
+	}
+
+	prompt, err := renderPromptTemplate(content, newPreviewData(req.Issue, project, req.Analysis))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+	response.Prompt = prompt
+
+	return response, nil
+}
+
+// validatePromptTemplateContent checks that the content is a valid template
+func validatePromptTemplateContent(content string) error {
+	if _, err := parsePromptTemplate(content); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+	return nil
+}
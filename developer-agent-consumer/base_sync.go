@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/services"
+)
+
+// maxConflictRounds limits how often the agent is asked to resolve conflicts during one
+// sync; every commit of a rebase may stop on conflicts of its own
+const maxConflictRounds = 5
+
+// baseSyncStrategy returns how the repository's branches are brought up to date with
+// the base branch, rebase by default
+func baseSyncStrategy(repository *models.Repository) string {
+	if repository.BaseSync != nil && repository.BaseSync.Strategy != "" {
+		return repository.BaseSync.Strategy
+	}
+	return models.BaseSyncRebase
+}
+
+// syncWithBase rebases the committed changes onto the base branch, or merges it in, when
+// the base branch moved while the agent ran. Conflicts are handed to the agent; when they
+// cannot be resolved the branch is restored and services.ErrUnresolvedConflicts returned.
+// An updated branch is verified again and the new verification result returned.
+func (p *Pipeline) syncWithBase(
+	ctx context.Context,
+	dev *models.Development,
+	request *models.DevelopmentRequest,
+	repository *models.Repository,
+	analysis *models.RepositoryAnalysis,
+	workspace *services.GitWorkspace,
+	profile *models.AgentProfile,
+	options services.CommitOptions,
+	verification *models.VerificationResult,
+) (*models.VerificationResult, error) {
+	strategy := baseSyncStrategy(repository)
+	if strategy == models.BaseSyncDisabled {
+		return verification, nil
+	}
+
+	result, err := p.gitService.SyncWithBase(workspace, services.NewGitAuth(repository), repository.BaseBranch, strategy, options)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Updated {
+		return verification, nil
+	}
+
+	conflicts := result.Conflicts
+	for round := 1; len(conflicts) > 0; round++ {
+		if round > maxConflictRounds {
+			p.gitService.AbortSync(workspace)
+			return nil, fmt.Errorf("%w: still conflicting after %d rounds in %s", services.ErrUnresolvedConflicts, maxConflictRounds, strings.Join(conflicts, ", "))
+		}
+
+		p.logger.WithFields(logrus.Fields{
+			"round": round,
+			"files": conflicts,
+		}).Info("Asking Claude to resolve conflicts with the base branch")
+
+		startedAt := time.Now()
+		response, err := p.claudeService.ResolveConflicts(request, workspace.Path, repository.BaseBranch, conflicts, profile)
+		if err != nil {
+			p.gitService.AbortSync(workspace)
+			return nil, fmt.Errorf("%w: %v", services.ErrUnresolvedConflicts, err)
+		}
+		p.recordIteration(ctx, dev, 0, models.IterationKindConflict, round, response, nil, startedAt)
+
+		conflicts, err = p.gitService.ContinueSync(workspace, options)
+		if err != nil {
+			p.gitService.AbortSync(workspace)
+			return nil, err
+		}
+	}
+
+	if services.ResolvePolicy(repository.Verification) == models.VerificationPolicyDisabled {
+		return verification, nil
+	}
+
+	p.logger.Info("Verifying the updated branch")
+	commands := services.ResolveCommands(analysis, repository.Verification)
+	verification, err = p.verify(workspace, commands, repository.Verification)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.devRepo.UpdateVerification(ctx, dev.ID, verification); err != nil {
+		p.logger.WithError(err).Warn("Failed to save verification result")
+	}
+
+	return verification, nil
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -78,6 +79,124 @@ func (c *ConfigAPIClient) GetProjectByJiraKey(jiraProjectKey string) (*models.Pr
 	return &project, nil
 }
 
+func (c *ConfigAPIClient) GetAgentProfile(profileID string) (*models.AgentProfile, error) {
+	endpoint := fmt.Sprintf("%s/api/agent-profiles/%s", c.baseURL, url.PathEscape(profileID))
+
+	c.logger.WithFields(logrus.Fields{
+		"url":              endpoint,
+		"agent_profile_id": profileID,
+	}).Debug("Fetching agent profile from Configuration API")
+
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("agent profile not found: %s", profileID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var profile models.AgentProfile
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"agent_profile_id":      profile.ID,
+		"agent_profile_name":    profile.Name,
+		"agent_profile_version": profile.Version,
+	}).Info("Agent profile fetched successfully")
+
+	return &profile, nil
+}
+
+func (c *ConfigAPIClient) GetPromptTemplate(templateID string) (*models.PromptTemplate, error) {
+	endpoint := fmt.Sprintf("%s/api/prompt-templates/%s", c.baseURL, url.PathEscape(templateID))
+
+	c.logger.WithFields(logrus.Fields{
+		"url":                endpoint,
+		"prompt_template_id": templateID,
+	}).Debug("Fetching prompt template from Configuration API")
+
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("prompt template not found: %s", templateID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var promptTemplate models.PromptTemplate
+	if err := json.NewDecoder(resp.Body).Decode(&promptTemplate); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"prompt_template_id":      promptTemplate.ID,
+		"prompt_template_name":    promptTemplate.Name,
+		"prompt_template_version": promptTemplate.Version,
+	}).Info("Prompt template fetched successfully")
+
+	return &promptTemplate, nil
+}
+
+// GetRunningExperiment returns the running experiment of a JIRA project, or nil when there is none
+func (c *ConfigAPIClient) GetRunningExperiment(jiraProjectKey string) (*models.Experiment, error) {
+	endpoint := fmt.Sprintf("%s/api/experiments?jira_project_key=%s&status=running", c.baseURL, url.QueryEscape(jiraProjectKey))
+
+	c.logger.WithFields(logrus.Fields{
+		"url":              endpoint,
+		"jira_project_key": jiraProjectKey,
+	}).Debug("Fetching running experiment from Configuration API")
+
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var experiments []models.Experiment
+	if err := json.NewDecoder(resp.Body).Decode(&experiments); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(experiments) == 0 {
+		return nil, nil
+	}
+	return &experiments[0], nil
+}
+
+// ResolveAgentProfile returns the agent profile assigned to the repository,
+// falling back to the project profile. Returns nil when none is assigned.
+func (c *ConfigAPIClient) ResolveAgentProfile(project *models.Project, repository *models.Repository) (*models.AgentProfile, error) {
+	profileID := repository.AgentProfileID
+	if profileID == "" {
+		profileID = project.AgentProfileID
+	}
+	if profileID == "" {
+		return nil, nil
+	}
+	return c.GetAgentProfile(profileID)
+}
+
 func (c *ConfigAPIClient) FindRepositoryInProject(project *models.Project, repoURL string) (*models.Repository, error) {
 	// Normalize repository URLs for comparison (remove trailing slashes, .git suffixes)
 	normalizedSearchURL := normalizeRepoURL(repoURL)
@@ -86,7 +205,7 @@ func (c *ConfigAPIClient) FindRepositoryInProject(project *models.Project, repoU
 		normalizedRepoURL := normalizeRepoURL(repo.URL)
 		if normalizedRepoURL == normalizedSearchURL {
 			c.logger.WithFields(logrus.Fields{
-				"repository_id": repo.RepositoryID,
+				"repository_id":  repo.RepositoryID,
 				"repository_url": repo.URL,
 			}).Info("Repository matched in project")
 			return &repo, nil
@@ -107,5 +226,19 @@ func normalizeRepoURL(repoURL string) string {
 		repoURL = repoURL[:len(repoURL)-4]
 	}
 
+	// SSH URLs (git@host:org/repo, ssh://git@host:port/org/repo) match the HTTPS URL of
+	// the same repository
+	if strings.HasPrefix(repoURL, "ssh://") {
+		if parsed, err := url.Parse(repoURL); err == nil {
+			return "https://" + parsed.Hostname() + parsed.Path
+		}
+	} else if !strings.Contains(repoURL, "://") {
+		if at := strings.Index(repoURL, "@"); at >= 0 {
+			if host, path, ok := strings.Cut(repoURL[at+1:], ":"); ok {
+				return "https://" + host + "/" + strings.TrimPrefix(path, "/")
+			}
+		}
+	}
+
 	return repoURL
 }
@@ -0,0 +1,75 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// JiraClient posts comments to JIRA issues using the REST API with basic auth (email + API token)
+type JiraClient struct {
+	baseURL    string
+	userEmail  string
+	apiToken   string
+	httpClient *http.Client
+	logger     *logrus.Logger
+}
+
+func NewJiraClient(baseURL, userEmail, apiToken string, logger *logrus.Logger) *JiraClient {
+	return &JiraClient{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		userEmail: userEmail,
+		apiToken:  apiToken,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// Enabled reports whether JIRA credentials are configured
+func (c *JiraClient) Enabled() bool {
+	return c.baseURL != "" && c.apiToken != ""
+}
+
+// AddComment adds a comment to an issue. Without JIRA credentials the comment is only logged.
+func (c *JiraClient) AddComment(issueKey, body string) error {
+	if !c.Enabled() {
+		c.logger.WithField("jira_issue_key", issueKey).Warn("JIRA credentials not configured, skipping comment")
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/rest/api/2/issue/%s/comment", c.baseURL, url.PathEscape(issueKey))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(c.userEmail, c.apiToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	c.logger.WithField("jira_issue_key", issueKey).Info("JIRA comment added")
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/services"
+)
+
+// commitChanges commits the agent's changes as a single commit, or as one commit per
+// group of files when the repository enables commit groups. With verification enabled,
+// every grouped commit but the last (the verified result) is set up and built on its own;
+// when one of them does not build, the changes are committed as a single commit instead.
+func (p *Pipeline) commitChanges(
+	request *models.DevelopmentRequest,
+	repository *models.Repository,
+	analysis *models.RepositoryAnalysis,
+	workspace *services.GitWorkspace,
+	options services.CommitOptions,
+) error {
+	data := services.NewNamingData(request)
+	message, err := services.CommitMessage(repository.Naming, data)
+	if err != nil {
+		return err
+	}
+	if repository.CommitGroups == nil || !repository.CommitGroups.Enabled {
+		return p.gitService.CommitChanges(workspace, message, options)
+	}
+
+	changes, err := p.gitService.StatusChanges(workspace)
+	if err != nil {
+		return err
+	}
+	groups := services.GroupChanges(changes, repository.CommitGroups.Groups)
+	if len(groups) <= 1 {
+		return p.gitService.CommitChanges(workspace, message, options)
+	}
+
+	base, err := p.gitService.HeadCommit(workspace)
+	if err != nil {
+		return err
+	}
+
+	commits := []string{}
+	for i, group := range groups {
+		data.Group = group.Name
+		groupMessage, err := services.CommitMessage(repository.Naming, data)
+		if err != nil {
+			return err
+		}
+		// The last commit takes everything left, so nothing stays behind uncommitted
+		if i == len(groups)-1 {
+			err = p.gitService.CommitChanges(workspace, groupMessage, options)
+		} else if err = p.gitService.CommitFiles(workspace, group.Files, groupMessage, options); err != nil {
+			p.logger.WithError(err).Warn("Failed to commit group, committing the changes as a single commit")
+			if err := p.gitService.CommitChanges(workspace, message, options); err != nil {
+				return err
+			}
+			return p.gitService.SquashCommits(workspace, base, message, options)
+		}
+		if err != nil {
+			return err
+		}
+
+		commit, err := p.gitService.HeadCommit(workspace)
+		if err != nil {
+			return err
+		}
+		commits = append(commits, commit)
+	}
+
+	p.logger.WithField("commits", len(commits)).Info("Committed changes in groups")
+
+	if services.ResolvePolicy(repository.Verification) == models.VerificationPolicyDisabled {
+		return nil
+	}
+	if p.groupedCommitsBuild(repository, analysis, workspace, commits[:len(commits)-1]) {
+		return nil
+	}
+	return p.gitService.SquashCommits(workspace, base, message, options)
+}
+
+// groupedCommitsBuild runs the setup and build commands on each of the commits in its own
+// worktree and reports whether all of them passed
+func (p *Pipeline) groupedCommitsBuild(
+	repository *models.Repository,
+	analysis *models.RepositoryAnalysis,
+	workspace *services.GitWorkspace,
+	commits []string,
+) bool {
+	commands := []models.VerificationCommand{}
+	for _, command := range services.ResolveCommands(analysis, repository.Verification) {
+		if command.Stage == models.VerificationStageSetup || command.Stage == models.VerificationStageBuild {
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) == 0 {
+		return true
+	}
+
+	for _, commit := range commits {
+		dir, cleanup, err := p.gitService.AddWorktree(workspace, commit)
+		if err != nil {
+			p.logger.WithError(err).Warn("Failed to check out grouped commit, committing as a single commit")
+			return false
+		}
+		result := p.verifierService.Verify(dir, commands, repository.Verification)
+		cleanup()
+
+		if !result.Passed {
+			p.logger.WithFields(logrus.Fields{
+				"commit":       commit,
+				"failed_steps": services.FailedStepsSummary(result),
+			}).Warn("Grouped commit does not build on its own, committing as a single commit")
+			return false
+		}
+	}
+	return true
+}
@@ -195,6 +195,32 @@ func (c *RabbitMQConsumer) processMessage(ctx context.Context, msg amqp.Delivery
 	}).Info("Message processed successfully")
 }
 
+// Publish puts a request onto the development queue, where it is handled like a request
+// from the webhook
+func (c *RabbitMQConsumer) Publish(request *models.DevelopmentRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	err = c.channel.Publish(
+		"",        // exchange
+		queueName, // routing key
+		false,     // mandatory
+		false,     // immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish request: %w", err)
+	}
+
+	return nil
+}
+
 func (c *RabbitMQConsumer) sendToErrorQueue(body []byte, errorMsg string) {
 	errorMessage := map[string]interface{}{
 		"original_message": string(body),
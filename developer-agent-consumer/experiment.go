@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/services"
+)
+
+// applyExperiment assigns the development to a variant of the project's running experiment
+// and returns the project and agent profile with the variant overrides applied.
+// Without a running experiment the project configuration is returned unchanged.
+func (p *Pipeline) applyExperiment(
+	ctx context.Context,
+	dev *models.Development,
+	request *models.DevelopmentRequest,
+	project *models.Project,
+	profile *models.AgentProfile,
+) (*models.Project, *models.AgentProfile, error) {
+	experiment, err := p.configClient.GetRunningExperiment(project.JiraProjectKey)
+	if err != nil {
+		// Experiments are optional, an unreachable endpoint must not block the development
+		p.logger.WithError(err).Warn("Failed to fetch running experiment, using project configuration")
+		return project, profile, nil
+	}
+	if experiment == nil {
+		return project, profile, nil
+	}
+
+	variant := services.AssignVariant(experiment, request.JiraIssueKey)
+	if variant == nil {
+		return project, profile, nil
+	}
+
+	if variant.PromptTemplateID != "" {
+		variantProject := *project
+		variantProject.PromptTemplateID = variant.PromptTemplateID
+		project = &variantProject
+	}
+	if variant.AgentProfileID != "" {
+		profile, err = p.configClient.GetAgentProfile(variant.AgentProfileID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	dev.Experiment = &models.ExperimentAssignment{
+		ID:      experiment.ID,
+		Name:    experiment.Name,
+		Variant: variant.Name,
+	}
+	if err := p.devRepo.UpdateExperiment(ctx, dev.ID, dev.Experiment); err != nil {
+		p.logger.WithError(err).Warn("Failed to record experiment variant")
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"experiment": experiment.Name,
+		"variant":    variant.Name,
+	}).Info("Experiment variant assigned")
+
+	return project, profile, nil
+}
@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -14,7 +14,6 @@ import (
 
 	"github.com/storos/sdlc-agent/developer-agent-consumer/clients"
 	"github.com/storos/sdlc-agent/developer-agent-consumer/consumer"
-	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
 	"github.com/storos/sdlc-agent/developer-agent-consumer/repositories"
 	"github.com/storos/sdlc-agent/developer-agent-consumer/services"
 )
@@ -36,6 +35,29 @@ func main() {
 	// Claude CLI configuration
 	claudeCLIPath := getEnv("CLAUDE_CLI_PATH", "/app/claude")
 
+	// JIRA credentials for posting comments (comments are skipped when not set)
+	jiraBaseURL := getEnv("JIRA_BASE_URL", "")
+	jiraUserEmail := getEnv("JIRA_USER_EMAIL", "")
+	jiraAPIToken := getEnv("JIRA_API_TOKEN", "")
+
+	// Directory where best-of-N candidate diffs are kept
+	artifactsDir := getEnv("ARTIFACTS_DIR", "/tmp/sdlc-artifacts")
+
+	// Local mirrors the repositories are cloned from
+	mirrorCacheDir := getEnv("MIRROR_CACHE_DIR", services.DefaultMirrorCacheDir)
+	mirrorCacheMaxMB, _ := strconv.Atoi(getEnv("MIRROR_CACHE_MAX_MB", "10240"))
+
+	// Directory of the mounted secrets holding commit signing keys
+	secretsDir := getEnv("SECRETS_DIR", services.DefaultSecretsDir)
+
+	// Analysis cache keyed by repository URL, commit SHA and analyzer version
+	analysisCacheDir := getEnv("ANALYSIS_CACHE_DIR", services.DefaultAnalysisCacheDir)
+	analysisCacheMaxMB, _ := strconv.Atoi(getEnv("ANALYSIS_CACHE_MAX_MB", "256"))
+	analysisCacheTTLHours, _ := strconv.Atoi(getEnv("ANALYSIS_CACHE_TTL_HOURS", "168"))
+
+	// Developments waiting for a plan review or an answer longer than this are failed (0 keeps them)
+	parkedTTLHours, _ := strconv.Atoi(getEnv("PARKED_TTL_HOURS", "168"))
+
 	// Connect to MongoDB
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -59,10 +81,22 @@ func main() {
 
 	// Initialize services
 	configClient := clients.NewConfigAPIClient(configAPIURL, logger)
-	gitService := services.NewGitService(logger)
+	jiraClient := clients.NewJiraClient(jiraBaseURL, jiraUserEmail, jiraAPIToken, logger)
+	mirrorCache := services.NewMirrorCache(mirrorCacheDir, int64(mirrorCacheMaxMB)*1024*1024, logger)
+	gitService := services.NewGitService(mirrorCache, logger)
+	secretStore := services.NewSecretStore(secretsDir)
 	analyzerService := services.NewAnalyzerService(logger)
+	analysisCache := services.NewAnalysisCache(
+		analysisCacheDir,
+		int64(analysisCacheMaxMB)*1024*1024,
+		time.Duration(analysisCacheTTLHours)*time.Hour,
+		logger,
+	)
 	claudeService := services.NewClaudeService(claudeCLIPath, logger)
+	readinessService := services.NewReadinessService(claudeService, logger)
+	verifierService := services.NewVerifierService(logger)
 	prService := services.NewPRService(logger)
+	artifactService := services.NewArtifactService(artifactsDir, logger)
 
 	logger.Info("Using Claude Code CLI for code generation")
 
@@ -71,18 +105,24 @@ func main() {
 	defer appCancel()
 
 	// Create message handler
-	handler := createMessageHandler(
+	pipeline := NewPipeline(
 		devRepo,
 		configClient,
 		gitService,
+		secretStore,
 		analyzerService,
+		analysisCache,
+		readinessService,
 		claudeService,
+		verifierService,
 		prService,
+		artifactService,
+		jiraClient,
 		logger,
 	)
 
 	// Initialize RabbitMQ consumer
-	rabbitConsumer, err := consumer.NewRabbitMQConsumer(rabbitMQURL, handler, logger)
+	rabbitConsumer, err := consumer.NewRabbitMQConsumer(rabbitMQURL, pipeline.Handle, logger)
 	if err != nil {
 		logger.Fatalf("Failed to create RabbitMQ consumer: %v", err)
 	}
@@ -93,6 +133,12 @@ func main() {
 		logger.Fatalf("Failed to start consumer: %v", err)
 	}
 
+	// Resume developments whose plan has been reviewed or question answered through the queue
+	go pipeline.RunResumer(appCtx, resumePollInterval, rabbitConsumer.Publish, time.Duration(parkedTTLHours)*time.Hour)
+
+	// Record merged/closed pull requests for experiment outcomes
+	go pipeline.RunPRTracker(appCtx, prTrackInterval)
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -107,173 +153,6 @@ func main() {
 	logger.Info("Developer Agent Consumer stopped")
 }
 
-func createMessageHandler(
-	devRepo *repositories.DevelopmentRepository,
-	configClient *clients.ConfigAPIClient,
-	gitService *services.GitService,
-	analyzerService *services.AnalyzerService,
-	claudeService *services.ClaudeService,
-	prService *services.PRService,
-	logger *logrus.Logger,
-) consumer.MessageHandler {
-	return func(ctx context.Context, request *models.DevelopmentRequest) error {
-		logger.WithFields(logrus.Fields{
-			"jira_issue_key":    request.JiraIssueKey,
-			"jira_project_key":  request.JiraProjectKey,
-		}).Info("Processing development request")
-
-		// Create development record
-		dev := &models.Development{
-			JiraIssueID:    request.JiraIssueID,
-			JiraIssueKey:   request.JiraIssueKey,
-			JiraProjectKey: request.JiraProjectKey,
-		}
-
-		if err := devRepo.Create(ctx, dev); err != nil {
-			logger.Errorf("Failed to create development record: %v", err)
-			return err
-		}
-
-		logger.WithFields(logrus.Fields{
-			"development_id": dev.ID.Hex(),
-		}).Info("Development record created")
-
-		// Step 1: Get project configuration
-		logger.Info("Fetching project configuration")
-		project, err := configClient.GetProjectByJiraKey(request.JiraProjectKey)
-		if err != nil {
-			devRepo.MarkFailed(ctx, dev.ID, err.Error())
-			return err
-		}
-
-		// Step 2: Find repository in project
-		var repository *models.Repository
-		if request.Repository != "" {
-			repository, err = configClient.FindRepositoryInProject(project, request.Repository)
-			if err != nil {
-				devRepo.MarkFailed(ctx, dev.ID, err.Error())
-				return err
-			}
-		} else {
-			// Use first repository if not specified
-			if len(project.Repositories) == 0 {
-				err := "no repositories configured for project"
-				devRepo.MarkFailed(ctx, dev.ID, err)
-				return &ErrNoRepositories{}
-			}
-			repository = &project.Repositories[0]
-		}
-
-		dev.RepositoryURL = repository.URL
-		// Set branch name early (format: feature/JIRA-KEY)
-		dev.BranchName = fmt.Sprintf("feature/%s", request.JiraIssueKey)
-
-		logger.WithFields(logrus.Fields{
-			"repository_url": repository.URL,
-			"branch_name":    dev.BranchName,
-		}).Info("Repository selected")
-
-		// Update repository URL and branch name in database
-		if err := devRepo.UpdateRepositoryInfo(ctx, dev.ID, repository.URL, dev.BranchName); err != nil {
-			logger.WithError(err).Warn("Failed to update repository info")
-		}
-
-		// Step 3: Clone repository
-		logger.Info("Cloning repository")
-		workspace, err := gitService.CloneRepository(repository.URL, repository.GitAccessToken, request.JiraIssueKey)
-		if err != nil {
-			devRepo.MarkFailed(ctx, dev.ID, err.Error())
-			return err
-		}
-		defer gitService.Cleanup(workspace)
-
-		// Step 4: Analyze repository
-		logger.Info("Analyzing repository structure")
-		analysis, err := analyzerService.AnalyzeRepository(workspace.Path)
-		if err != nil {
-			devRepo.MarkFailed(ctx, dev.ID, err.Error())
-			return err
-		}
-
-		// Step 5: Build prompt and save to database
-		logger.Info("Building prompt for Claude Code")
-		prompt := claudeService.BuildPrompt(request, project, analysis)
-
-		logger.WithFields(logrus.Fields{
-			"prompt_length": len(prompt),
-		}).Info("Saving prompt to database")
-
-		if err := devRepo.UpdatePrompt(ctx, dev.ID, prompt); err != nil {
-			logger.WithError(err).Warn("Failed to save prompt to database")
-		}
-
-		// Step 6: Create feature branch FIRST (before generating code)
-		logger.Info("Creating feature branch")
-		if err := gitService.CreateAndCheckoutBranch(workspace, request.JiraIssueKey); err != nil {
-			devRepo.MarkFailed(ctx, dev.ID, err.Error())
-			return err
-		}
-
-		// Step 7: Generate code with Claude (on the feature branch)
-		logger.Info("Generating code with Claude Code CLI")
-		claudeResponse, err := claudeService.GenerateCode(request, project, analysis, workspace.Path)
-		if err != nil {
-			devRepo.MarkFailed(ctx, dev.ID, err.Error())
-			return err
-		}
-
-		dev.BranchName = workspace.BranchName
-
-		// Update branch name in database
-		if err := devRepo.UpdateRepositoryInfo(ctx, dev.ID, dev.RepositoryURL, workspace.BranchName); err != nil {
-			logger.WithError(err).Warn("Failed to update branch name")
-		}
-
-		logger.Info("Committing changes")
-		if err := gitService.CommitChanges(workspace, request.JiraIssueKey, request.Summary); err != nil {
-			devRepo.MarkFailed(ctx, dev.ID, err.Error())
-			return err
-		}
-
-		logger.Info("Pushing branch")
-		if err := gitService.PushBranch(workspace, repository.GitAccessToken); err != nil {
-			devRepo.MarkFailed(ctx, dev.ID, err.Error())
-			return err
-		}
-
-		// Step 8: Create PR/MR
-		logger.Info("Creating pull/merge request")
-		prURL, err := prService.CreatePullRequest(
-			repository.URL,
-			workspace.BranchName,
-			repository.BaseBranch,
-			request.JiraIssueKey,
-			request.Summary,
-			request.Description,
-			repository.GitAccessToken,
-		)
-		if err != nil {
-			devRepo.MarkFailed(ctx, dev.ID, err.Error())
-			return err
-		}
-
-		// Step 9: Update development record
-		logger.Info("Marking development as completed")
-		if err := devRepo.MarkCompleted(ctx, dev.ID, prURL, claudeResponse.DevelopmentDetails); err != nil {
-			logger.Errorf("Failed to mark as completed: %v", err)
-			return err
-		}
-
-		logger.WithFields(logrus.Fields{
-			"jira_issue_key": request.JiraIssueKey,
-			"pr_url":         prURL,
-			"files_changed":  claudeResponse.FilesChanged,
-		}).Info("Development request processed successfully")
-
-		return nil
-	}
-}
-
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -8,77 +8,606 @@ import (
 
 // DevelopmentRequest represents incoming message from RabbitMQ
 type DevelopmentRequest struct {
-	JiraIssueID    string `json:"jira_issue_id"`
-	JiraIssueKey   string `json:"jira_issue_key"`
-	JiraProjectKey string `json:"jira_project_key"`
-	Summary        string `json:"summary"`
-	Description    string `json:"description"`
-	Repository     string `json:"repository,omitempty"`
+	JiraIssueID    string    `json:"jira_issue_id"`
+	JiraIssueKey   string    `json:"jira_issue_key"`
+	JiraProjectKey string    `json:"jira_project_key"`
+	Summary        string    `json:"summary"`
+	Description    string    `json:"description"`
+	IssueType      string    `json:"issue_type,omitempty"`
+	Labels         []string  `json:"labels,omitempty"`
+	Repository     string    `json:"repository,omitempty"`
+	Action         string    `json:"action,omitempty"`         // Set for JIRA command comments (see Action* constants)
+	Comment        string    `json:"comment,omitempty"`        // Comment text following the command
+	CommentAuthor  string    `json:"comment_author,omitempty"` // Display name of the comment author
+	Assignee       *Assignee `json:"assignee,omitempty"`
+	DevelopmentID  string    `json:"development_id,omitempty"` // Set for ActionResume
+	ResumeToken    string    `json:"resume_token,omitempty"`   // Claim the resume was published with, see ActionResume
 }
 
+// Assignee identifies the JIRA user an issue is assigned to
+type Assignee struct {
+	AccountID   string `json:"account_id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// Actions carried by development requests created from JIRA comments
+const (
+	ActionApprovePlan    = "approve_plan"
+	ActionRejectPlan     = "reject_plan"
+	ActionAnswerQuestion = "answer_question"
+)
+
+// ActionResume is carried by the requests the resumer publishes to continue a parked
+// development whose plan was reviewed or question answered
+const ActionResume = "resume"
+
+// Development statuses used while a development waits for a human decision
+const (
+	DevelopmentStatusAwaitingApproval = "awaiting_approval"
+	DevelopmentStatusPlanApproved     = "plan_approved"
+	DevelopmentStatusPlanRejected     = "plan_rejected"
+	DevelopmentStatusAwaitingInput    = "awaiting_input"
+	DevelopmentStatusInputReceived    = "input_received"
+	DevelopmentStatusNeedsRefinement  = "needs_refinement"
+)
+
+// Failure types of developments that failed for a reason needing a specific follow-up
+const (
+	FailureTypeMergeConflict = "merge_conflict"
+)
+
 // Development represents development record in MongoDB
 type Development struct {
-	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	JiraIssueID       string             `bson:"jira_issue_id" json:"jira_issue_id"`
-	JiraIssueKey      string             `bson:"jira_issue_key" json:"jira_issue_key"`
-	JiraProjectKey    string             `bson:"jira_project_key" json:"jira_project_key"`
-	RepositoryURL     string             `bson:"repository_url" json:"repository_url"`
-	BranchName        string             `bson:"branch_name" json:"branch_name"`
-	PRMRUrl           string             `bson:"pr_mr_url,omitempty" json:"pr_mr_url,omitempty"`
-	Status            string             `bson:"status" json:"status"` // ready, completed, failed
-	DevelopmentDetails string            `bson:"development_details,omitempty" json:"development_details,omitempty"`
-	ErrorMessage      string             `bson:"error_message,omitempty" json:"error_message,omitempty"`
-	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
-	CompletedAt       *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ID                    primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	JiraIssueID           string                `bson:"jira_issue_id" json:"jira_issue_id"`
+	JiraIssueKey          string                `bson:"jira_issue_key" json:"jira_issue_key"`
+	JiraProjectKey        string                `bson:"jira_project_key" json:"jira_project_key"`
+	RepositoryURL         string                `bson:"repository_url" json:"repository_url"`
+	BranchName            string                `bson:"branch_name" json:"branch_name"`
+	PRMRUrl               string                `bson:"pr_mr_url,omitempty" json:"pr_mr_url,omitempty"`
+	Status                string                `bson:"status" json:"status"` // ready, awaiting_approval, plan_approved, plan_rejected, awaiting_input, input_received, needs_refinement, completed, failed
+	DevelopmentDetails    string                `bson:"development_details,omitempty" json:"development_details,omitempty"`
+	ErrorMessage          string                `bson:"error_message,omitempty" json:"error_message,omitempty"`
+	FailureType           string                `bson:"failure_type,omitempty" json:"failure_type,omitempty"` // Set for failures needing a specific follow-up, e.g. merge_conflict
+	AgentProfileID        string                `bson:"agent_profile_id,omitempty" json:"agent_profile_id,omitempty"`
+	AgentProfileVersion   int                   `bson:"agent_profile_version,omitempty" json:"agent_profile_version,omitempty"`
+	PromptTemplateID      string                `bson:"prompt_template_id,omitempty" json:"prompt_template_id,omitempty"`
+	PromptTemplateVersion int                   `bson:"prompt_template_version,omitempty" json:"prompt_template_version,omitempty"`
+	Experiment            *ExperimentAssignment `bson:"experiment,omitempty" json:"experiment,omitempty"`
+	PullRequest           *PullRequestStatus    `bson:"pull_request,omitempty" json:"pull_request,omitempty"` // Tracked after the PR is created
+	Verification          *VerificationResult   `bson:"verification,omitempty" json:"verification,omitempty"`
+	Iterations            []Iteration           `bson:"iterations,omitempty" json:"iterations,omitempty"`
+	Candidates            []Candidate           `bson:"candidates,omitempty" json:"candidates,omitempty"`
+	Request               *DevelopmentRequest   `bson:"request,omitempty" json:"request,omitempty"` // Original request, used to resume parked developments
+	Plan                  *Plan                 `bson:"plan,omitempty" json:"plan,omitempty"`
+	PlanHistory           []Plan                `bson:"plan_history,omitempty" json:"plan_history,omitempty"` // Rejected plans
+	Questions             []Question            `bson:"questions,omitempty" json:"questions,omitempty"`
+	Readiness             *ReadinessResult      `bson:"readiness,omitempty" json:"readiness,omitempty"`
+	Retrieval             *Retrieval            `bson:"retrieval,omitempty" json:"retrieval,omitempty"`
+	PreviousAttempt       *PreviousAttempt      `bson:"previous_attempt,omitempty" json:"previous_attempt,omitempty"` // Set when the issue branch already existed
+	WorkspacePath         string                `bson:"workspace_path,omitempty" json:"workspace_path,omitempty"`     // Kept while waiting for an answer
+	ParkedAt              *time.Time            `bson:"parked_at,omitempty" json:"parked_at,omitempty"`               // Last time the development started waiting for a decision
+	ResumeToken           string                `bson:"resume_token,omitempty" json:"resume_token,omitempty"`         // Claim of the worker resuming the development
+	ResumedAt             *time.Time            `bson:"resumed_at,omitempty" json:"resumed_at,omitempty"`             // Last heartbeat of the resume
+	CreatedAt             time.Time             `bson:"created_at" json:"created_at"`
+	CompletedAt           *time.Time            `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// PreviousAttempt links a development to the earlier run whose branch it continued or replaced
+type PreviousAttempt struct {
+	DevelopmentID string `bson:"development_id,omitempty" json:"development_id,omitempty"` // Latest earlier development of the issue
+	BranchHead    string `bson:"branch_head" json:"branch_head"`                           // Remote branch head before this run
+	PRMRUrl       string `bson:"pr_mr_url,omitempty" json:"pr_mr_url,omitempty"`
+	Policy        string `bson:"policy" json:"policy"` // append or replace
 }
 
 // Project represents project configuration from Configuration API
 type Project struct {
-	ID              string       `json:"id"`
-	Name            string       `json:"name"`
-	Description     string       `json:"description"`
-	Scope           string       `json:"scope"`
-	JiraProjectKey  string       `json:"jira_project_key"`
-	JiraProjectName string       `json:"jira_project_name"`
-	JiraProjectURL  string       `json:"jira_project_url"`
-	Repositories    []Repository `json:"repositories"`
-	CreatedAt       string       `json:"created_at"`
-	UpdatedAt       string       `json:"updated_at"`
+	ID               string             `json:"id"`
+	Name             string             `json:"name"`
+	Description      string             `json:"description"`
+	Scope            string             `json:"scope"`
+	JiraProjectKey   string             `json:"jira_project_key"`
+	JiraProjectName  string             `json:"jira_project_name"`
+	JiraProjectURL   string             `json:"jira_project_url"`
+	Repositories     []Repository       `json:"repositories"`
+	AgentProfileID   string             `json:"agent_profile_id,omitempty"`
+	PromptTemplateID string             `json:"prompt_template_id,omitempty"`
+	BestOfN          *BestOfNSettings   `json:"best_of_n,omitempty"`
+	PlanFirst        *PlanFirstSettings `json:"plan_first,omitempty"`
+	Readiness        *ReadinessSettings `json:"readiness,omitempty"`
+	Commit           *CommitSettings    `json:"commit,omitempty"`
+	CreatedAt        string             `json:"created_at"`
+	UpdatedAt        string             `json:"updated_at"`
+}
+
+// CommitSettings controls the identity, signature and co-author trailers of agent commits
+type CommitSettings struct {
+	AuthorName  string                 `json:"author_name,omitempty"`
+	AuthorEmail string                 `json:"author_email,omitempty"`
+	Signing     *CommitSigningSettings `json:"signing,omitempty"`
+	UserMapping []UserMapping          `json:"user_mapping,omitempty"` // Maps JIRA assignees to co-authors
+}
+
+// CommitSigningSettings names the secrets holding the commit signing key
+type CommitSigningSettings struct {
+	Format           string `json:"format"`                      // gpg or ssh
+	KeySecret        string `json:"key_secret"`                  // Armored GPG or OpenSSH private key
+	PassphraseSecret string `json:"passphrase_secret,omitempty"` // Passphrase of the key
+}
+
+// UserMapping maps a JIRA user to a git identity
+type UserMapping struct {
+	JiraUser string `json:"jira_user"` // JIRA account ID, username or email address
+	Name     string `json:"name"`
+	Email    string `json:"email"`
 }
 
 // Repository represents repository configuration
 type Repository struct {
-	RepositoryID   string `json:"repository_id"`
-	URL            string `json:"url"`
-	Description    string `json:"description"`
-	GitAccessToken string `json:"git_access_token"`
-	BaseBranch     string `json:"base_branch"` // Base branch for PRs (e.g., "main", "master", "develop")
+	RepositoryID   string                `json:"repository_id"`
+	URL            string                `json:"url"`
+	Description    string                `json:"description"`
+	GitAccessToken string                `json:"git_access_token"`
+	BaseBranch     string                `json:"base_branch"`                // Base branch for PRs (e.g., "main", "master", "develop")
+	AgentProfileID string                `json:"agent_profile_id,omitempty"` // Overrides the project agent profile
+	Verification   *VerificationSettings `json:"verification,omitempty"`
+	Conventions    *ConventionSettings   `json:"conventions,omitempty"`
+	RepoMap        *RepoMapSettings      `json:"repo_map,omitempty"`
+	Retrieval      *RetrievalSettings    `json:"retrieval,omitempty"`
+	Clone          *CloneSettings        `json:"clone,omitempty"`
+	SSHKey         *SSHKeyCredential     `json:"ssh_key,omitempty"` // Deploy key for SSH URLs
+	Rerun          *RerunSettings        `json:"rerun,omitempty"`
+	Naming         *NamingSettings       `json:"naming,omitempty"`
+	BaseSync       *BaseSyncSettings     `json:"base_sync,omitempty"`
+	CommitGroups   *CommitGroupSettings  `json:"commit_groups,omitempty"`
+	Fork           *ForkSettings         `json:"fork,omitempty"`
+}
+
+// ForkSettings makes the agent push its branches to a fork and open cross-repository pull
+// requests against the repository, which the access token then only needs to read
+type ForkSettings struct {
+	URL       string `json:"url,omitempty"`       // Existing fork the branches are pushed to
+	Create    bool   `json:"create,omitempty"`    // Create the fork through the provider API when URL is empty
+	Namespace string `json:"namespace,omitempty"` // Organization or group the fork is created in, the token owner by default
+}
+
+// CommitGroupSettings splits the agent's changes into one commit per group of files
+// instead of a single commit
+type CommitGroupSettings struct {
+	Enabled bool          `json:"enabled"`
+	Groups  []CommitGroup `json:"groups,omitempty"` // Replace the default groups: migrations, implementation, tests, docs
+}
+
+// CommitGroup is a group of changed files committed together. Groups are committed in
+// order; a file belongs to the first group with a matching glob, files matching no glob
+// to the group without globs.
+type CommitGroup struct {
+	Name  string   `json:"name"`
+	Globs []string `json:"globs,omitempty"`
+}
+
+// Strategies bringing the agent's commits up to date with a base branch that moved
+const (
+	BaseSyncRebase   = "rebase"
+	BaseSyncMerge    = "merge"
+	BaseSyncDisabled = "disabled"
+)
+
+// BaseSyncSettings controls how the branch is updated when the base branch moved while the
+// agent was running
+type BaseSyncSettings struct {
+	Strategy string `json:"strategy,omitempty"` // rebase (default), merge or disabled
+}
+
+// NamingSettings holds the templates for branch names and commit messages, rendered with
+// the issue key, type, summary, slugified summary and labels
+type NamingSettings struct {
+	BranchTemplate string `json:"branch_template,omitempty"` // Default: feature/{{.Key}}
+	CommitTemplate string `json:"commit_template,omitempty"` // Default: [{{.Key}}] {{.Summary}}
+}
+
+// Re-run policies for issues whose branch already exists on the remote
+const (
+	RerunPolicyAppend  = "append"  // Add commits on top of the existing branch
+	RerunPolicyReplace = "replace" // Start again from the base branch and force-with-lease the branch
+)
+
+// RerunSettings controls how a run continues the branch of a previous run
+type RerunSettings struct {
+	Policy string `json:"policy,omitempty"` // append (default) or replace
+}
+
+// SSHKeyCredential is a deploy key used to clone and push repositories with SSH URLs
+type SSHKeyCredential struct {
+	PrivateKey string `json:"private_key"`           // PEM or OpenSSH private key
+	Passphrase string `json:"passphrase,omitempty"`  // Passphrase of an encrypted key
+	KnownHosts string `json:"known_hosts,omitempty"` // known_hosts lines the host key is pinned to
+}
+
+// CloneSettings reduce what is cloned of large repositories, or complete the checkout with
+// submodules and Git LFS objects
+type CloneSettings struct {
+	Depth        int      `json:"depth,omitempty"`         // Number of commits of history, 0 for all
+	SingleBranch bool     `json:"single_branch,omitempty"` // Clone only the base branch
+	Filter       string   `json:"filter,omitempty"`        // Partial clone filter, e.g. "blob:none"
+	SparsePaths  []string `json:"sparse_paths,omitempty"`  // Directories checked out (cone mode)
+	Submodules   bool     `json:"submodules,omitempty"`    // Initialize submodules recursively
+	LFS          bool     `json:"lfs,omitempty"`           // Fetch Git LFS objects and push those of new commits
+}
+
+// RetrievalSettings controls the relevant files added to the agent prompt
+type RetrievalSettings struct {
+	Disabled bool `json:"disabled,omitempty"`
+	TopK     int  `json:"top_k,omitempty"` // Number of files, defaults to 5
+}
+
+// RepoMapSettings controls whether the repository map is added to the agent prompt
+type RepoMapSettings struct {
+	Enabled   bool `json:"enabled"`
+	MaxTokens int  `json:"max_tokens,omitempty"` // Budget of the map in the prompt
+}
+
+// ConventionSettings overrides which convention documents the analyzer includes in
+// the agent context. Globs are matched against slash-separated paths relative to the
+// repository root; a glob without a slash matches the file name in any directory.
+type ConventionSettings struct {
+	Globs        []string `json:"globs,omitempty"`          // Replace the default globs
+	MaxBytes     int      `json:"max_bytes,omitempty"`      // Total budget for all convention files
+	MaxFileBytes int      `json:"max_file_bytes,omitempty"` // Budget for a single file
+}
+
+// PromptTemplate is a versioned text/template used to build the agent prompt
+type PromptTemplate struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Version int    `json:"version"`
+}
+
+// Experiment splits a project's developments between prompt template and agent profile variants
+type Experiment struct {
+	ID             string              `json:"id"`
+	Name           string              `json:"name"`
+	JiraProjectKey string              `json:"jira_project_key"`
+	Status         string              `json:"status"`
+	Variants       []ExperimentVariant `json:"variants"`
+}
+
+// ExperimentVariant overrides the project's prompt template and/or agent profile
+type ExperimentVariant struct {
+	Name             string `json:"name"`
+	Weight           int    `json:"weight"` // Percentage of developments
+	PromptTemplateID string `json:"prompt_template_id,omitempty"`
+	AgentProfileID   string `json:"agent_profile_id,omitempty"`
+}
+
+// ExperimentAssignment records the experiment variant a development was assigned to
+type ExperimentAssignment struct {
+	ID      string `bson:"id" json:"id"`
+	Name    string `bson:"name" json:"name"`
+	Variant string `bson:"variant" json:"variant"`
+}
+
+// Pull request states
+const (
+	PullRequestStateOpen   = "open"
+	PullRequestStateMerged = "merged"
+	PullRequestStateClosed = "closed"
+)
+
+// PullRequestStatus is the state of a pull/merge request and the number of reviews it received
+type PullRequestStatus struct {
+	State     string     `bson:"state" json:"state"` // open, merged, closed
+	Reviews   int        `bson:"reviews" json:"reviews"`
+	MergedAt  *time.Time `bson:"merged_at,omitempty" json:"merged_at,omitempty"`
+	ClosedAt  *time.Time `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+	CheckedAt time.Time  `bson:"checked_at" json:"checked_at"`
+}
+
+// ReadinessSettings configures the pre-flight check that rejects underspecified tickets
+// before any code is generated
+type ReadinessSettings struct {
+	Enabled                   bool `json:"enabled"`
+	Threshold                 int  `json:"threshold,omitempty"`              // Minimum score (0-100), 60 by default
+	MinDescriptionLength      int  `json:"min_description_length,omitempty"` // 100 characters by default
+	RequireAcceptanceCriteria bool `json:"require_acceptance_criteria"`
+	CheckReferences           bool `json:"check_references"` // Files and endpoints mentioned in the ticket must exist
+	LLMJudgment               bool `json:"llm_judgment"`     // Ask the agent to judge the ticket
+}
+
+// ReadinessCheck is the outcome of a single readiness rule
+type ReadinessCheck struct {
+	Name   string  `bson:"name" json:"name"`
+	Weight int     `bson:"weight" json:"weight"`
+	Score  float64 `bson:"score" json:"score"` // Fraction of the weight earned (0-1)
+	Passed bool    `bson:"passed" json:"passed"`
+	Detail string  `bson:"detail,omitempty" json:"detail,omitempty"`
+}
+
+// ReadinessResult is the readiness score of a ticket
+type ReadinessResult struct {
+	Score     int              `bson:"score" json:"score"`
+	Threshold int              `bson:"threshold" json:"threshold"`
+	Passed    bool             `bson:"passed" json:"passed"`
+	Checks    []ReadinessCheck `bson:"checks" json:"checks"`
+}
+
+// PlanFirstSettings makes the consumer ask for an implementation plan and wait for
+// approval before any code is generated
+type PlanFirstSettings struct {
+	Enabled bool     `json:"enabled"`
+	Labels  []string `json:"labels,omitempty"` // Restrict to issues with one of these labels
+}
+
+// Plan statuses
+const (
+	PlanStatusPending  = "pending"
+	PlanStatusApproved = "approved"
+	PlanStatusRejected = "rejected"
+)
+
+// Plan is an implementation plan produced by the agent in plan-first mode
+type Plan struct {
+	Revision   int        `bson:"revision" json:"revision"`
+	Content    string     `bson:"content" json:"content"`
+	Status     string     `bson:"status" json:"status"`
+	Feedback   string     `bson:"feedback,omitempty" json:"feedback,omitempty"` // Reviewer feedback when rejected
+	ReviewedBy string     `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	ReviewedAt *time.Time `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+}
+
+// AgentQuestion is the structured question the agent writes when a task is too ambiguous to implement
+type AgentQuestion struct {
+	Question string `json:"question"`
+	Context  string `json:"context,omitempty"` // What the agent found and why it needs an answer
+}
+
+// Question records a clarifying question asked on JIRA and its answer
+type Question struct {
+	Question   string     `bson:"question" json:"question"`
+	Context    string     `bson:"context,omitempty" json:"context,omitempty"`
+	Answer     string     `bson:"answer,omitempty" json:"answer,omitempty"`
+	AnsweredBy string     `bson:"answered_by,omitempty" json:"answered_by,omitempty"`
+	AskedAt    time.Time  `bson:"asked_at" json:"asked_at"`
+	AnsweredAt *time.Time `bson:"answered_at,omitempty" json:"answered_at,omitempty"`
+}
+
+// BestOfNSettings configures parallel candidate generation for a project
+type BestOfNSettings struct {
+	Candidates      int      `json:"candidates"`                  // Number of parallel agent runs
+	AgentProfileIDs []string `json:"agent_profile_ids,omitempty"` // Assigned to candidates round-robin
+	Labels          []string `json:"labels,omitempty"`            // Restrict to issues with one of these labels
+}
+
+// Verification policies applied when the post-generation checks fail
+const (
+	VerificationPolicyBlock    = "block"    // Do not push or open a PR
+	VerificationPolicyDraft    = "draft"    // Open the PR as draft
+	VerificationPolicyAnnotate = "annotate" // Open a regular PR with the results in the body
+	VerificationPolicyDisabled = "disabled" // Skip verification entirely
+)
+
+// VerificationSettings represents per-repository verification configuration
+type VerificationSettings struct {
+	Policy         string   `json:"policy,omitempty"`
+	SetupCommands  []string `json:"setup_commands,omitempty"`
+	BuildCommands  []string `json:"build_commands,omitempty"`
+	LintCommands   []string `json:"lint_commands,omitempty"`
+	TestCommands   []string `json:"test_commands,omitempty"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"` // Per command
+}
+
+// AgentProfile represents agent invocation options from Configuration API
+type AgentProfile struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Model           string            `json:"model,omitempty"`
+	MaxTurns        int               `json:"max_turns,omitempty"`
+	TimeoutSeconds  int               `json:"timeout_seconds,omitempty"`
+	PermissionMode  string            `json:"permission_mode"`
+	AllowedTools    []string          `json:"allowed_tools,omitempty"`
+	DisallowedTools []string          `json:"disallowed_tools,omitempty"`
+	AddDirs         []string          `json:"add_dirs,omitempty"`
+	Env             map[string]string `json:"env,omitempty"`
+	MCPConfig       string            `json:"mcp_config,omitempty"`
+	MaxFixAttempts  int               `json:"max_fix_attempts,omitempty"` // Re-invocations after failed verification
+	Version         int               `json:"version"`
 }
 
 // RepositoryAnalysis represents analyzed repository structure
 type RepositoryAnalysis struct {
-	EntryPoints        []string          `json:"entry_points"`
-	KeyDirectories     []string          `json:"key_directories"`
-	ConfigFiles        []string          `json:"config_files"`
-	Languages          []string          `json:"languages"`
-	Patterns           map[string]string `json:"patterns"`
-	ProjectType        string            `json:"project_type"`
-	DependencyManagers []string          `json:"dependency_managers"`
+	EntryPoints          []string              `json:"entry_points"`
+	KeyDirectories       []string              `json:"key_directories"`
+	ConfigFiles          []string              `json:"config_files"`
+	Languages            []string              `json:"languages"`
+	Patterns             map[string]string     `json:"patterns"`
+	ProjectType          string                `json:"project_type"`
+	DependencyManagers   []string              `json:"dependency_managers"`
+	VerificationCommands []VerificationCommand `json:"verification_commands"`
+	Conventions          []ConventionFile      `json:"conventions,omitempty"`
+	Manifests            []Manifest            `json:"manifests,omitempty"`
+	Frameworks           []string              `json:"frameworks,omitempty"`      // Across all manifests
+	TestFrameworks       []string              `json:"test_frameworks,omitempty"` // Across all manifests
+	Workspaces           []string              `json:"workspaces,omitempty"`      // Monorepo tooling, e.g. "go.work", "pnpm workspaces"
+	Modules              []Module              `json:"modules,omitempty"`         // Set when the repository has several modules
+	TargetModules        []string              `json:"target_modules,omitempty"`  // Paths of the modules relevant to the issue, best match first
+	RepoMap              *RepositoryMap        `json:"repo_map,omitempty"`
+	RelevantFiles        []RetrievedFile       `json:"relevant_files,omitempty"` // Files ranked against the issue, best match first
+}
+
+// Retrieval is the ranking of repository files against the issue. The files the agent
+// changed are added on commit, to evaluate the retrieval quality.
+type Retrieval struct {
+	Terms        []string        `bson:"terms" json:"terms"` // Query terms taken from the issue
+	Files        []RetrievedFile `bson:"files" json:"files"` // Best match first
+	ChangedFiles []string        `bson:"changed_files,omitempty" json:"changed_files,omitempty"`
+	Hits         int             `bson:"hits" json:"hits"` // Retrieved files among the changed files
+	CreatedAt    time.Time       `bson:"created_at" json:"created_at"`
+}
+
+// RetrievedFile is a repository file ranked against the issue
+type RetrievedFile struct {
+	Path      string   `bson:"path" json:"path"`
+	Score     float64  `bson:"score" json:"score"`                               // BM25 score
+	Symbols   []string `bson:"symbols,omitempty" json:"symbols,omitempty"`       // Symbols of the file matching the issue
+	Excerpt   string   `bson:"excerpt,omitempty" json:"excerpt,omitempty"`       // Lines with the most issue terms
+	StartLine int      `bson:"start_line,omitempty" json:"start_line,omitempty"` // First line of the excerpt, 1-based
+}
+
+// RepositoryMap is a compact view of the repository: the file tree honoring .gitignore
+// with the top-level exported symbols of each source file
+type RepositoryMap struct {
+	Files     []MapFile `json:"files"`
+	Content   string    `json:"content"`             // Rendered within the default token budget
+	Truncated bool      `json:"truncated,omitempty"` // Files or symbols were left out, of the map or its content
+}
+
+// MapFile is a file of the repository map
+type MapFile struct {
+	Path     string   `json:"path"` // Slash-separated, relative to repository root
+	Language string   `json:"language,omitempty"`
+	Symbols  []string `json:"symbols,omitempty"` // e.g. "type Project struct", "func NewProjectService", "ProjectService.GetAll"
+}
+
+// Module is a sub-project of a monorepo, rooted at the directory of its manifest
+type Module struct {
+	Path           string   `json:"path"` // Relative to repository root
+	Name           string   `json:"name"`
+	ProjectType    string   `json:"project_type"`
+	Languages      []string `json:"languages"` // Most used first
+	Manifests      []string `json:"manifests"`
+	Frameworks     []string `json:"frameworks,omitempty"`
+	TestFrameworks []string `json:"test_frameworks,omitempty"`
+	EntryPoints    []string `json:"entry_points,omitempty"`
+	KeyDirectories []string `json:"key_directories,omitempty"`
+	Relevance      int      `json:"relevance,omitempty"` // Match score against the issue
+}
+
+// Manifest ecosystems
+const (
+	EcosystemGo     = "go"
+	EcosystemNode   = "node"
+	EcosystemPython = "python"
+	EcosystemJava   = "java"
+	EcosystemRust   = "rust"
+)
+
+// Manifest is a parsed dependency manifest (go.mod, package.json, requirements.txt,
+// pyproject.toml, pom.xml or Cargo.toml) found in the repository
+type Manifest struct {
+	Path            string            `json:"path"` // Relative to repository root
+	Ecosystem       string            `json:"ecosystem"`
+	Name            string            `json:"name,omitempty"`             // Module, package or artifact name
+	LanguageVersion string            `json:"language_version,omitempty"` // e.g. "1.21" for go, ">=18" for node
+	Dependencies    []Dependency      `json:"dependencies,omitempty"`     // Direct dependencies only
+	Frameworks      []string          `json:"frameworks,omitempty"`
+	TestFrameworks  []string          `json:"test_frameworks,omitempty"`
+	Scripts         map[string]string `json:"scripts,omitempty"` // Runnable scripts by name
+}
+
+// Dependency is a direct dependency declared in a manifest
+type Dependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"` // Version or constraint as declared
+	Dev     bool   `json:"dev,omitempty"`     // Development or test only
+}
+
+// ConventionFile is a repository convention document (contributing guide, agent
+// instructions, lint configuration, ADR) included in the agent context
+type ConventionFile struct {
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"` // Content was cut to fit the size budget
+}
+
+// Verification stages, in execution order
+const (
+	VerificationStageSetup = "setup"
+	VerificationStageBuild = "build"
+	VerificationStageLint  = "lint"
+	VerificationStageTest  = "test"
+)
+
+// VerificationCommand is a command run to verify generated code
+type VerificationCommand struct {
+	Stage   string `json:"stage" bson:"stage"`
+	Command string `json:"command" bson:"command"`
+	Dir     string `json:"dir,omitempty" bson:"dir,omitempty"` // Relative to repository root
+}
+
+// VerificationStep is the outcome of a single verification command
+type VerificationStep struct {
+	Stage      string `json:"stage" bson:"stage"`
+	Command    string `json:"command" bson:"command"`
+	Dir        string `json:"dir,omitempty" bson:"dir,omitempty"`
+	Passed     bool   `json:"passed" bson:"passed"`
+	Skipped    bool   `json:"skipped,omitempty" bson:"skipped,omitempty"`
+	TimedOut   bool   `json:"timed_out,omitempty" bson:"timed_out,omitempty"`
+	ExitCode   int    `json:"exit_code" bson:"exit_code"`
+	DurationMs int64  `json:"duration_ms" bson:"duration_ms"`
+	Output     string `json:"output,omitempty" bson:"output,omitempty"` // Tail of combined stdout/stderr
+}
+
+// VerificationResult represents the build/lint/test results for a workspace
+type VerificationResult struct {
+	Passed      bool               `json:"passed" bson:"passed"`
+	Policy      string             `json:"policy" bson:"policy"`
+	Steps       []VerificationStep `json:"steps" bson:"steps"`
+	StartedAt   time.Time          `json:"started_at" bson:"started_at"`
+	CompletedAt time.Time          `json:"completed_at" bson:"completed_at"`
+}
+
+// Iteration kinds
+const (
+	IterationKindGenerate = "generate"
+	IterationKindFix      = "fix"
+	IterationKindConflict = "resolve_conflicts"
+)
+
+// Iteration records one agent invocation and the verification that followed it
+type Iteration struct {
+	Candidate    int                 `bson:"candidate,omitempty" json:"candidate,omitempty"` // Set in best-of-N runs
+	Number       int                 `bson:"number" json:"number"`
+	Kind         string              `bson:"kind" json:"kind"`
+	FilesChanged int                 `bson:"files_changed" json:"files_changed"`
+	Verification *VerificationResult `bson:"verification,omitempty" json:"verification,omitempty"`
+	StartedAt    time.Time           `bson:"started_at" json:"started_at"`
+	CompletedAt  time.Time           `bson:"completed_at" json:"completed_at"`
+}
+
+// Candidate represents one best-of-N generation attempt and its ranking
+type Candidate struct {
+	Index            int                 `bson:"index" json:"index"`
+	AgentProfileID   string              `bson:"agent_profile_id,omitempty" json:"agent_profile_id,omitempty"`
+	AgentProfileName string              `bson:"agent_profile_name,omitempty" json:"agent_profile_name,omitempty"`
+	Score            float64             `bson:"score" json:"score"`
+	Rank             int                 `bson:"rank" json:"rank"`
+	Selected         bool                `bson:"selected" json:"selected"`
+	FilesChanged     int                 `bson:"files_changed" json:"files_changed"`
+	DiffLines        int                 `bson:"diff_lines" json:"diff_lines"`
+	DiffPath         string              `bson:"diff_path,omitempty" json:"diff_path,omitempty"` // Artifact with the candidate's full diff
+	Verification     *VerificationResult `bson:"verification,omitempty" json:"verification,omitempty"`
+	Error            string              `bson:"error,omitempty" json:"error,omitempty"`
 }
 
 // ClaudeCodeRequest represents request to Claude Code API
 type ClaudeCodeRequest struct {
-	Prompt           string `json:"prompt"`
-	ProjectContext   string `json:"project_context,omitempty"`
-	RepositoryPath   string `json:"repository_path,omitempty"`
-	SessionToken     string `json:"session_token"`
+	Prompt         string `json:"prompt"`
+	ProjectContext string `json:"project_context,omitempty"`
+	RepositoryPath string `json:"repository_path,omitempty"`
+	SessionToken   string `json:"session_token"`
 }
 
 // ClaudeCodeResponse represents response from Claude Code API
 type ClaudeCodeResponse struct {
-	Success          bool   `json:"success"`
-	Message          string `json:"message"`
-	FilesChanged     int    `json:"files_changed"`
+	Success            bool   `json:"success"`
+	Message            string `json:"message"`
+	FilesChanged       int    `json:"files_changed"`
 	DevelopmentDetails string `json:"development_details"`
-	Error            string `json:"error,omitempty"`
+	Output             string `json:"output,omitempty"` // Raw CLI output
+	Error              string `json:"error,omitempty"`
 }
@@ -0,0 +1,966 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/clients"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/repositories"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/services"
+)
+
+// Pipeline processes development requests from clone to pull request
+type Pipeline struct {
+	devRepo          *repositories.DevelopmentRepository
+	configClient     *clients.ConfigAPIClient
+	gitService       *services.GitService
+	secretStore      *services.SecretStore
+	analyzerService  *services.AnalyzerService
+	analysisCache    *services.AnalysisCache
+	readinessService *services.ReadinessService
+	claudeService    *services.ClaudeService
+	verifierService  *services.VerifierService
+	prService        *services.PRService
+	artifactService  *services.ArtifactService
+	jiraClient       *clients.JiraClient
+	logger           *logrus.Logger
+}
+
+func NewPipeline(
+	devRepo *repositories.DevelopmentRepository,
+	configClient *clients.ConfigAPIClient,
+	gitService *services.GitService,
+	secretStore *services.SecretStore,
+	analyzerService *services.AnalyzerService,
+	analysisCache *services.AnalysisCache,
+	readinessService *services.ReadinessService,
+	claudeService *services.ClaudeService,
+	verifierService *services.VerifierService,
+	prService *services.PRService,
+	artifactService *services.ArtifactService,
+	jiraClient *clients.JiraClient,
+	logger *logrus.Logger,
+) *Pipeline {
+	return &Pipeline{
+		devRepo:          devRepo,
+		configClient:     configClient,
+		gitService:       gitService,
+		secretStore:      secretStore,
+		analyzerService:  analyzerService,
+		analysisCache:    analysisCache,
+		readinessService: readinessService,
+		claudeService:    claudeService,
+		verifierService:  verifierService,
+		prService:        prService,
+		artifactService:  artifactService,
+		jiraClient:       jiraClient,
+		logger:           logger,
+	}
+}
+
+// Handle processes a single development request
+func (p *Pipeline) Handle(ctx context.Context, request *models.DevelopmentRequest) error {
+	if request.Action == models.ActionResume {
+		return p.resume(ctx, request)
+	}
+	if request.Action != "" {
+		return p.handleCommand(ctx, request)
+	}
+
+	devRepo := p.devRepo
+	logger := p.logger
+
+	logger.WithFields(logrus.Fields{
+		"jira_issue_key":   request.JiraIssueKey,
+		"jira_project_key": request.JiraProjectKey,
+	}).Info("Processing development request")
+
+	// Create development record
+	dev := &models.Development{
+		JiraIssueID:    request.JiraIssueID,
+		JiraIssueKey:   request.JiraIssueKey,
+		JiraProjectKey: request.JiraProjectKey,
+		Request:        request,
+	}
+
+	if err := devRepo.Create(ctx, dev); err != nil {
+		logger.Errorf("Failed to create development record: %v", err)
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"development_id": dev.ID.Hex(),
+	}).Info("Development record created")
+
+	return p.process(ctx, dev, request)
+}
+
+// process runs the pipeline for a development record. It is used both for new
+// requests and to resume developments whose plan has been reviewed or question answered.
+func (p *Pipeline) process(ctx context.Context, dev *models.Development, request *models.DevelopmentRequest) error {
+	devRepo := p.devRepo
+	logger := p.logger
+
+	// Step 1: Get project configuration
+	logger.Info("Fetching project configuration")
+	project, err := p.configClient.GetProjectByJiraKey(request.JiraProjectKey)
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	// Step 2: Find repository in project
+	var repository *models.Repository
+	if request.Repository != "" {
+		repository, err = p.configClient.FindRepositoryInProject(project, request.Repository)
+		if err != nil {
+			devRepo.MarkFailed(ctx, dev.ID, err.Error())
+			return err
+		}
+	} else {
+		// Use first repository if not specified
+		if len(project.Repositories) == 0 {
+			err := "no repositories configured for project"
+			devRepo.MarkFailed(ctx, dev.ID, err)
+			return &ErrNoRepositories{}
+		}
+		repository = &project.Repositories[0]
+	}
+
+	dev.RepositoryURL = repository.URL
+	// Set branch name early from the repository's branch template
+	branchName, err := services.BranchName(repository.Naming, services.NewNamingData(request))
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+	dev.BranchName = branchName
+
+	logger.WithFields(logrus.Fields{
+		"repository_url": repository.URL,
+		"branch_name":    dev.BranchName,
+	}).Info("Repository selected")
+
+	// Update repository URL and branch name in database
+	if err := devRepo.UpdateRepositoryInfo(ctx, dev.ID, repository.URL, dev.BranchName); err != nil {
+		logger.WithError(err).Warn("Failed to update repository info")
+	}
+
+	// Resolve agent profile (repository profile overrides project profile)
+	profile, err := p.configClient.ResolveAgentProfile(project, repository)
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	// A running experiment may override the prompt template and agent profile
+	project, profile, err = p.applyExperiment(ctx, dev, request, project, profile)
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+	if profile != nil {
+		logger.WithFields(logrus.Fields{
+			"agent_profile":         profile.Name,
+			"agent_profile_version": profile.Version,
+		}).Info("Agent profile selected")
+
+		if err := devRepo.UpdateAgentProfile(ctx, dev.ID, profile.ID, profile.Version); err != nil {
+			logger.WithError(err).Warn("Failed to record agent profile")
+		}
+	}
+
+	// Step 3: Clone repository (or reopen the workspace kept while waiting for input)
+	workspace, err := p.prepareWorkspace(dev, repository, request)
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+	parked := false
+	defer func(workspace *services.GitWorkspace) {
+		if !parked {
+			p.gitService.Cleanup(workspace)
+		}
+	}(workspace)
+
+	// Step 4: Analyze repository (a cache lookup when the commit was analyzed before)
+	retrievalEnabled := repository.Retrieval == nil || !repository.Retrieval.Disabled
+	analysis, index, err := p.analyzeRepository(workspace, repository, retrievalEnabled)
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+	p.analyzerService.SelectModules(analysis, request)
+	if len(analysis.TargetModules) > 0 {
+		logger.WithField("modules", analysis.TargetModules).Info("Selected monorepo modules for the issue")
+	}
+
+	// Rank the repository files against the issue for the prompt
+	if retrievalEnabled {
+		topK := 0
+		if repository.Retrieval != nil {
+			topK = repository.Retrieval.TopK
+		}
+		dev.Retrieval = p.analyzerService.RetrieveRelevantFiles(index, analysis, request, topK)
+		if err := devRepo.UpdateRetrieval(ctx, dev.ID, dev.Retrieval); err != nil {
+			logger.WithError(err).Warn("Failed to save retrieval ranking")
+		}
+	}
+
+	// Pre-flight: reject underspecified tickets before spending an agent run on them
+	if project.Readiness != nil && project.Readiness.Enabled && dev.Readiness == nil {
+		logger.Info("Scoring issue readiness")
+		readiness := p.readinessService.Score(request, workspace.Path, project.Readiness, profile)
+		dev.Readiness = readiness
+		if err := devRepo.UpdateReadiness(ctx, dev.ID, readiness); err != nil {
+			logger.WithError(err).Warn("Failed to save readiness result")
+		}
+
+		if !readiness.Passed {
+			if err := devRepo.UpdateStatus(ctx, dev.ID, models.DevelopmentStatusNeedsRefinement); err != nil {
+				return err
+			}
+			if err := p.jiraClient.AddComment(request.JiraIssueKey, services.FormatReadinessComment(readiness)); err != nil {
+				logger.WithError(err).Warn("Failed to post readiness comment to JIRA")
+			}
+			logger.WithField("score", readiness.Score).Info("Issue needs refinement, skipping code generation")
+			return nil
+		}
+	}
+
+	// Step 5: Build prompt from the project template (or the default prompt) and save to database
+	logger.Info("Building prompt for Claude Code")
+	taskPrompt, err := p.buildTaskPrompt(ctx, dev, request, project, repository, analysis)
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	// Plan-first mode: ask for a plan and wait for approval before writing any code
+	if planFirstEnabled(project, request) && (dev.Plan == nil || dev.Plan.Status != models.PlanStatusApproved) {
+		return p.planAndPark(ctx, dev, request, taskPrompt, workspace, profile)
+	}
+
+	candidateCount := bestOfNCandidates(project, request)
+	// Clarifying questions are only offered to single runs, up to maxQuestions per development
+	allowQuestion := candidateCount <= 1 && len(dev.Questions) < maxQuestions
+	prompt := services.WithApprovedPlan(taskPrompt, dev.Plan)
+	prompt = services.WithClarifications(prompt, dev.Questions, allowQuestion)
+
+	logger.WithFields(logrus.Fields{
+		"prompt_length": len(prompt),
+	}).Info("Saving prompt to database")
+
+	if err := devRepo.UpdatePrompt(ctx, dev.ID, prompt); err != nil {
+		logger.WithError(err).Warn("Failed to save prompt to database")
+	}
+
+	// Step 6: Create feature branch FIRST (before generating code); a reopened workspace is already on it.
+	// A branch left on the remote by a previous run is continued or replaced per policy.
+	if workspace.BranchName == "" {
+		logger.Info("Creating feature branch")
+		if err := p.checkoutIssueBranch(ctx, dev, repository, request, workspace); err != nil {
+			devRepo.MarkFailed(ctx, dev.ID, err.Error())
+			return err
+		}
+	} else if dev.PreviousAttempt != nil && dev.PreviousAttempt.Policy == models.RerunPolicyReplace {
+		workspace.LeaseHead = dev.PreviousAttempt.BranchHead
+	}
+
+	// Step 7: Generate and verify code with Claude (on the feature branch)
+	var claudeResponse *models.ClaudeCodeResponse
+	var verification *models.VerificationResult
+	if candidateCount > 1 {
+		var selected *services.GitWorkspace
+		selected, claudeResponse, verification, err = p.runCandidates(ctx, dev, request, project, prompt, repository, analysis, workspace, profile, candidateCount)
+		if err != nil {
+			devRepo.MarkFailed(ctx, dev.ID, err.Error())
+			return err
+		}
+		if selected != workspace {
+			defer p.gitService.Cleanup(selected)
+			workspace = selected
+		}
+	} else {
+		claudeResponse, verification, err = p.generateAndVerify(ctx, dev, request, prompt, repository, analysis, workspace, profile, 0)
+		var asked *questionAskedError
+		if errors.As(err, &asked) {
+			parked = true
+			return p.parkForInput(ctx, dev, request, workspace, asked.question)
+		}
+		if err != nil {
+			devRepo.MarkFailed(ctx, dev.ID, err.Error())
+			return err
+		}
+	}
+
+	if verification != nil {
+		if err := devRepo.UpdateVerification(ctx, dev.ID, verification); err != nil {
+			logger.WithError(err).Warn("Failed to save verification result")
+		}
+	}
+
+	dev.BranchName = workspace.BranchName
+
+	// Update branch name in database
+	if err := devRepo.UpdateRepositoryInfo(ctx, dev.ID, dev.RepositoryURL, workspace.BranchName); err != nil {
+		logger.WithError(err).Warn("Failed to update branch name")
+	}
+
+	// Compare the retrieved files with the files the agent changed
+	if dev.Retrieval != nil {
+		if changedFiles, err := p.gitService.ChangedFiles(workspace); err != nil {
+			logger.WithError(err).Warn("Failed to list changed files")
+		} else if err := devRepo.UpdateRetrievalOutcome(ctx, dev.ID, changedFiles, services.RetrievalHits(dev.Retrieval, changedFiles)); err != nil {
+			logger.WithError(err).Warn("Failed to save retrieval outcome")
+		}
+	}
+
+	logger.Info("Committing changes")
+	commitOptions, err := services.NewCommitOptions(project.Commit, request.Assignee, p.secretStore)
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+	if err := p.commitChanges(request, repository, analysis, workspace, commitOptions); err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	// Step 8: Bring the branch up to date with a base branch that moved while the agent ran
+	verification, err = p.syncWithBase(ctx, dev, request, repository, analysis, workspace, profile, commitOptions, verification)
+	if errors.Is(err, services.ErrUnresolvedConflicts) {
+		devRepo.MarkFailedWithType(ctx, dev.ID, models.FailureTypeMergeConflict, err.Error())
+		return err
+	}
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	// Step 9: Apply verification policy
+	prOptions := services.PullRequestOptions{HeadRepoURL: workspace.ForkURL}
+	if verification != nil {
+		prOptions.Notes = services.FormatVerificationSummary(verification)
+
+		if !verification.Passed {
+			switch verification.Policy {
+			case models.VerificationPolicyBlock:
+				err := fmt.Errorf("verification failed: %s", services.FailedStepsSummary(verification))
+				devRepo.MarkFailed(ctx, dev.ID, err.Error())
+				return err
+			case models.VerificationPolicyDraft:
+				prOptions.Draft = true
+			}
+			logger.WithField("policy", verification.Policy).Warn("Verification failed, continuing per policy")
+		}
+	}
+
+	if dev.PreviousAttempt != nil {
+		if prOptions.Notes != "" {
+			prOptions.Notes += "\n"
+		}
+		prOptions.Notes += services.FormatPreviousAttempt(dev.PreviousAttempt)
+	}
+
+	logger.Info("Pushing branch")
+	if err := p.gitService.PushBranch(workspace, services.NewGitAuth(repository)); err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	// Step 10: Create PR/MR
+	logger.Info("Creating pull/merge request")
+	prURL, err := p.prService.CreatePullRequest(
+		repository.URL,
+		workspace.BranchName,
+		repository.BaseBranch,
+		request.JiraIssueKey,
+		request.Summary,
+		request.Description,
+		repository.GitAccessToken,
+		prOptions,
+	)
+	if err != nil {
+		devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	// Step 11: Update development record
+	logger.Info("Marking development as completed")
+	if err := devRepo.MarkCompleted(ctx, dev.ID, prURL, claudeResponse.DevelopmentDetails); err != nil {
+		logger.Errorf("Failed to mark as completed: %v", err)
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"jira_issue_key": request.JiraIssueKey,
+		"pr_url":         prURL,
+		"files_changed":  claudeResponse.FilesChanged,
+	}).Info("Development request processed successfully")
+
+	return nil
+}
+
+// buildTaskPrompt renders the project's prompt template and records the template version
+// on the development. Projects without a template use the default prompt.
+func (p *Pipeline) buildTaskPrompt(
+	ctx context.Context,
+	dev *models.Development,
+	request *models.DevelopmentRequest,
+	project *models.Project,
+	repository *models.Repository,
+	analysis *models.RepositoryAnalysis,
+) (string, error) {
+	if project.PromptTemplateID == "" {
+		return withRepositoryMap(p.claudeService.BuildPrompt(request, project, analysis), repository, analysis), nil
+	}
+
+	promptTemplate, err := p.configClient.GetPromptTemplate(project.PromptTemplateID)
+	if err != nil {
+		return "", err
+	}
+
+	prompt, err := services.RenderPromptTemplate(promptTemplate.Content, services.NewPromptData(request, project, repository, analysis))
+	if err != nil {
+		return "", fmt.Errorf("prompt template %s (version %d): %w", promptTemplate.Name, promptTemplate.Version, err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"prompt_template":         promptTemplate.Name,
+		"prompt_template_version": promptTemplate.Version,
+	}).Info("Prompt rendered from template")
+
+	if err := p.devRepo.UpdatePromptTemplate(ctx, dev.ID, promptTemplate.ID, promptTemplate.Version); err != nil {
+		p.logger.WithError(err).Warn("Failed to record prompt template")
+	}
+
+	return withRepositoryMap(prompt, repository, analysis), nil
+}
+
+// withRepositoryMap appends the repository map when the repository enables it
+func withRepositoryMap(prompt string, repository *models.Repository, analysis *models.RepositoryAnalysis) string {
+	if repository.RepoMap == nil || !repository.RepoMap.Enabled {
+		return prompt
+	}
+	return services.WithRepositoryMap(prompt, analysis.RepoMap, repository.RepoMap.MaxTokens)
+}
+
+// analyzeRepository returns the cached analysis of the checked out commit, or analyzes the
+// repository and caches the result. The retrieval index is built from the repository map
+// when retrieval is enabled and cached with the analysis. Workspaces with uncommitted
+// changes (reopened after waiting for input) are always analyzed.
+func (p *Pipeline) analyzeRepository(workspace *services.GitWorkspace, repository *models.Repository, retrievalEnabled bool) (*models.RepositoryAnalysis, *services.LexicalIndex, error) {
+	key := ""
+	commit, err := p.gitService.HeadCommit(workspace)
+	if err != nil {
+		p.logger.WithError(err).Warn("Failed to resolve commit, skipping analysis cache")
+	} else if changed, err := p.gitService.ChangedFiles(workspace); err == nil && len(changed) == 0 {
+		sparsePaths := []string{}
+		if repository.Clone != nil {
+			sparsePaths = repository.Clone.SparsePaths
+		}
+		key = services.AnalysisCacheKey(repository.URL, commit, repository.Conventions, sparsePaths)
+	}
+
+	if key != "" {
+		if cached, ok := p.analysisCache.Get(key, workspace.Path); ok {
+			p.logger.WithField("commit", commit).Info("Using cached repository analysis")
+			if retrievalEnabled && cached.Index == nil {
+				cached.Index = services.BuildLexicalIndex(workspace.Path, cached.Analysis.RepoMap.Files)
+				p.cacheAnalysis(key, cached)
+			}
+			return cached.Analysis, cached.Index, nil
+		}
+	}
+
+	p.logger.Info("Analyzing repository structure")
+	analysis, err := p.analyzerService.AnalyzeRepository(workspace.Path, repository.Conventions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var index *services.LexicalIndex
+	if retrievalEnabled {
+		index = services.BuildLexicalIndex(workspace.Path, analysis.RepoMap.Files)
+	}
+
+	if key != "" {
+		p.cacheAnalysis(key, &services.CachedAnalysis{
+			RepositoryURL: repository.URL,
+			CommitSHA:     commit,
+			Analysis:      analysis,
+			Index:         index,
+		})
+	}
+	return analysis, index, nil
+}
+
+// cacheAnalysis stores an analysis in the cache; a failure only costs a later analysis
+func (p *Pipeline) cacheAnalysis(key string, cached *services.CachedAnalysis) {
+	if err := p.analysisCache.Put(key, cached); err != nil {
+		p.logger.WithError(err).Warn("Failed to cache repository analysis")
+	}
+}
+
+// prepareWorkspace reopens the workspace kept while the development waited for input,
+// or clones the repository
+func (p *Pipeline) prepareWorkspace(dev *models.Development, repository *models.Repository, request *models.DevelopmentRequest) (*services.GitWorkspace, error) {
+	if dev.WorkspacePath != "" {
+		workspace, err := p.gitService.OpenWorkspace(dev.WorkspacePath)
+		if err == nil {
+			return workspace, nil
+		}
+		p.logger.WithError(err).Warn("Kept workspace is not available, cloning again")
+	}
+
+	p.logger.Info("Cloning repository")
+	workspace, err := p.gitService.CloneRepository(repository.URL, services.NewGitAuth(repository), request.JiraIssueKey, repository.BaseBranch, repository.Clone)
+	if err != nil {
+		return nil, err
+	}
+	p.deepenForIssue(workspace, repository, request)
+
+	if err := p.useFork(workspace, repository); err != nil {
+		p.gitService.Cleanup(workspace)
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// useFork points the workspace's issue branch at the repository's fork, creating the
+// fork first when the repository asks for it. Repositories without a fork push to origin.
+func (p *Pipeline) useFork(workspace *services.GitWorkspace, repository *models.Repository) error {
+	fork := repository.Fork
+	if fork == nil {
+		return nil
+	}
+
+	forkURL := fork.URL
+	if forkURL == "" && fork.Create {
+		var err error
+		forkURL, err = p.prService.EnsureFork(repository.URL, repository.GitAccessToken, fork.Namespace)
+		if err != nil {
+			return err
+		}
+	}
+	if forkURL == "" {
+		return nil
+	}
+	return p.gitService.UseFork(workspace, forkURL)
+}
+
+// rerunPolicy returns how a run treats the branch left on the remote by a previous run
+// of the issue, adding commits on top of it by default
+func rerunPolicy(repository *models.Repository) string {
+	if repository.Rerun != nil && repository.Rerun.Policy != "" {
+		return repository.Rerun.Policy
+	}
+	return models.RerunPolicyAppend
+}
+
+// checkoutIssueBranch checks out the branch of the issue. When a previous run left
+// the branch on the remote, the development is linked to that attempt.
+func (p *Pipeline) checkoutIssueBranch(
+	ctx context.Context,
+	dev *models.Development,
+	repository *models.Repository,
+	request *models.DevelopmentRequest,
+	workspace *services.GitWorkspace,
+) error {
+	policy := rerunPolicy(repository)
+	head, err := p.gitService.CheckoutIssueBranch(workspace, services.NewGitAuth(repository), dev.BranchName, policy)
+	if err != nil || head == "" {
+		return err
+	}
+
+	attempt := &models.PreviousAttempt{BranchHead: head, Policy: policy}
+	if previous, err := p.devRepo.FindPreviousByJiraIssueKey(ctx, request.JiraIssueKey, dev.CreatedAt); err != nil {
+		p.logger.WithError(err).Warn("Failed to find the previous development of the issue")
+	} else if previous != nil {
+		attempt.DevelopmentID = previous.ID.Hex()
+		attempt.PRMRUrl = previous.PRMRUrl
+	}
+
+	dev.PreviousAttempt = attempt
+	if err := p.devRepo.UpdatePreviousAttempt(ctx, dev.ID, attempt); err != nil {
+		p.logger.WithError(err).Warn("Failed to record previous attempt")
+	}
+	return nil
+}
+
+// deepenForIssue deepens a shallow clone until the commits the issue refers to are
+// present, so the agent can inspect them
+func (p *Pipeline) deepenForIssue(workspace *services.GitWorkspace, repository *models.Repository, request *models.DevelopmentRequest) {
+	commits := services.CommitReferences(request.Summary + "\n" + request.Description)
+	if len(commits) == 0 || !p.gitService.IsShallow(workspace) {
+		return
+	}
+
+	found, err := p.gitService.DeepenHistory(workspace, services.NewGitAuth(repository), func() bool {
+		for _, commit := range commits {
+			if !p.gitService.HasCommit(workspace, commit) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		p.logger.WithError(err).Warn("Failed to deepen shallow clone")
+	} else if !found {
+		p.logger.WithField("commits", commits).Warn("Commits referenced by the issue are not in the cloned history")
+	}
+}
+
+// generateAndVerify runs the agent in the workspace and verifies the result. When
+// verification fails, the agent is re-invoked with the failure output and the current
+// diff, up to the profile's MaxFixAttempts. Every attempt is recorded as an iteration, the
+// generation also when verification is disabled. The returned verification result is nil
+// when verification is disabled.
+func (p *Pipeline) generateAndVerify(
+	ctx context.Context,
+	dev *models.Development,
+	request *models.DevelopmentRequest,
+	prompt string,
+	repository *models.Repository,
+	analysis *models.RepositoryAnalysis,
+	workspace *services.GitWorkspace,
+	profile *models.AgentProfile,
+	candidate int,
+) (*models.ClaudeCodeResponse, *models.VerificationResult, error) {
+	logger := p.logger
+
+	logger.Info("Generating code with Claude Code CLI")
+	startedAt := time.Now()
+	claudeResponse, err := p.claudeService.GenerateCode(request, prompt, workspace.Path, profile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	question, err := p.claudeService.TakeQuestion(workspace.Path)
+	if err != nil {
+		logger.WithError(err).Warn("Ignoring invalid question file")
+	}
+	if question != nil {
+		p.recordIteration(ctx, dev, candidate, models.IterationKindGenerate, 1, claudeResponse, nil, startedAt)
+		return nil, nil, &questionAskedError{question: question}
+	}
+
+	// The generation is recorded whether or not it is verified, so experiments count every agent run
+	policy := services.ResolvePolicy(repository.Verification)
+	if policy == models.VerificationPolicyDisabled {
+		p.recordIteration(ctx, dev, candidate, models.IterationKindGenerate, 1, claudeResponse, nil, startedAt)
+		return claudeResponse, nil, nil
+	}
+
+	commands := services.ResolveCommands(analysis, repository.Verification)
+
+	logger.WithField("policy", policy).Info("Verifying generated code")
+	verification, err := p.verify(workspace, commands, repository.Verification)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.recordIteration(ctx, dev, candidate, models.IterationKindGenerate, 1, claudeResponse, verification, startedAt)
+
+	maxFixAttempts := 0
+	if profile != nil {
+		maxFixAttempts = profile.MaxFixAttempts
+	}
+
+	for attempt := 1; !verification.Passed && attempt <= maxFixAttempts; attempt++ {
+		logger.WithFields(logrus.Fields{
+			"attempt":      attempt,
+			"max_attempts": maxFixAttempts,
+			"failed_steps": services.FailedStepsSummary(verification),
+		}).Info("Verification failed, asking Claude to fix the code")
+
+		diff, err := p.gitService.WorkingDiff(workspace)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to compute working diff")
+		}
+
+		startedAt = time.Now()
+		fixResponse, err := p.claudeService.FixCode(request, workspace.Path, verification, diff, profile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fix attempt %d failed: %w", attempt, err)
+		}
+
+		verification, err = p.verify(workspace, commands, repository.Verification)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.recordIteration(ctx, dev, candidate, models.IterationKindFix, attempt+1, fixResponse, verification, startedAt)
+
+		claudeResponse.DevelopmentDetails += fmt.Sprintf("\n\nFix attempt %d:\n%s", attempt, fixResponse.DevelopmentDetails)
+		p.countFilesChanged(workspace, claudeResponse, fixResponse)
+	}
+
+	return claudeResponse, verification, nil
+}
+
+// countFilesChanged sets the files changed by the generation and its fix attempts so far:
+// the distinct files changed in the workspace, the larger of the two counts if they cannot
+// be listed
+func (p *Pipeline) countFilesChanged(workspace *services.GitWorkspace, response, fixResponse *models.ClaudeCodeResponse) {
+	files, err := p.gitService.ChangedFiles(workspace)
+	if err != nil {
+		p.logger.WithError(err).Warn("Failed to list changed files")
+		if fixResponse.FilesChanged > response.FilesChanged {
+			response.FilesChanged = fixResponse.FilesChanged
+		}
+		return
+	}
+	response.FilesChanged = len(files)
+}
+
+// verify runs the verification commands in the workspace and then undoes the changes
+// they made, such as rewritten lockfiles or build output the .gitignore misses, so only
+// the agent's changes are committed
+func (p *Pipeline) verify(workspace *services.GitWorkspace, commands []models.VerificationCommand, settings *models.VerificationSettings) (*models.VerificationResult, error) {
+	snapshot, err := p.gitService.SnapshotWorkingTree(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := p.verifierService.Verify(workspace.Path, commands, settings)
+
+	restored, err := p.gitService.RestoreWorkingTree(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to undo the changes of verification: %w", err)
+	}
+	if len(restored) > 0 {
+		p.logger.WithField("files", restored).Info("Undid the changes of verification")
+	}
+
+	return verification, nil
+}
+
+func (p *Pipeline) recordIteration(
+	ctx context.Context,
+	dev *models.Development,
+	candidate int,
+	kind string,
+	number int,
+	response *models.ClaudeCodeResponse,
+	verification *models.VerificationResult,
+	startedAt time.Time,
+) {
+	iteration := models.Iteration{
+		Candidate:    candidate,
+		Number:       number,
+		Kind:         kind,
+		FilesChanged: response.FilesChanged,
+		Verification: verification,
+		StartedAt:    startedAt,
+		CompletedAt:  time.Now(),
+	}
+
+	if err := p.devRepo.AddIteration(ctx, dev.ID, iteration); err != nil {
+		p.logger.WithError(err).Warn("Failed to record iteration")
+	}
+}
+
+// bestOfNCandidates returns the number of candidates to generate for a request.
+// Best-of-N applies when the project enables it and, if labels are configured,
+// the issue carries one of them.
+func bestOfNCandidates(project *models.Project, request *models.DevelopmentRequest) int {
+	settings := project.BestOfN
+	if settings == nil || settings.Candidates <= 1 {
+		return 1
+	}
+
+	if len(settings.Labels) > 0 && !hasAnyLabel(request.Labels, settings.Labels) {
+		return 1
+	}
+
+	return settings.Candidates
+}
+
+// hasAnyLabel reports whether the issue carries one of the given labels
+func hasAnyLabel(issueLabels, labels []string) bool {
+	for _, label := range labels {
+		for _, issueLabel := range issueLabels {
+			if label == issueLabel {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// candidateRun holds the state of one best-of-N candidate while it is generated
+type candidateRun struct {
+	workspace    *services.GitWorkspace
+	profile      *models.AgentProfile
+	response     *models.ClaudeCodeResponse
+	verification *models.VerificationResult
+}
+
+// runCandidates generates candidates in parallel, each in its own workspace, verifies and
+// ranks them, and saves every candidate's diff as an artifact. The first candidate reuses
+// the primary workspace. Returns the workspace of the selected candidate; the workspaces of
+// the other candidates are removed.
+func (p *Pipeline) runCandidates(
+	ctx context.Context,
+	dev *models.Development,
+	request *models.DevelopmentRequest,
+	project *models.Project,
+	prompt string,
+	repository *models.Repository,
+	analysis *models.RepositoryAnalysis,
+	workspace *services.GitWorkspace,
+	defaultProfile *models.AgentProfile,
+	count int,
+) (*services.GitWorkspace, *models.ClaudeCodeResponse, *models.VerificationResult, error) {
+	logger := p.logger
+
+	logger.WithField("candidates", count).Info("Generating best-of-N candidates")
+
+	// The experiment variant's results are credited to its profile, so an experiment keeps
+	// the profile for every candidate
+	profileIDs := project.BestOfN.AgentProfileIDs
+	if dev.Experiment != nil && len(profileIDs) > 0 {
+		logger.WithField("experiment", dev.Experiment.Name).Warn("Ignoring best-of-N agent profiles while an experiment is assigned")
+		profileIDs = nil
+	}
+
+	runs := make([]candidateRun, count)
+	candidates := make([]models.Candidate, count)
+	for i := range runs {
+		profile, err := p.candidateProfile(profileIDs, i, defaultProfile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		runs[i].profile = profile
+		candidates[i].Index = i + 1
+		if profile != nil {
+			candidates[i].AgentProfileID = profile.ID
+			candidates[i].AgentProfileName = profile.Name
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range runs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			run := &runs[i]
+			candidate := &candidates[i]
+
+			// Candidates that have not started yet are dropped on shutdown
+			if err := ctx.Err(); err != nil {
+				candidate.Error = err.Error()
+				return
+			}
+
+			if i == 0 {
+				run.workspace = workspace
+			} else {
+				candidateKey := fmt.Sprintf("%s-candidate-%d", request.JiraIssueKey, candidate.Index)
+				candidateWorkspace, err := p.gitService.CloneRepository(repository.URL, services.NewGitAuth(repository), candidateKey, repository.BaseBranch, repository.Clone)
+				if err != nil {
+					candidate.Error = err.Error()
+					return
+				}
+				run.workspace = candidateWorkspace
+				p.deepenForIssue(candidateWorkspace, repository, request)
+				if workspace.ForkURL != "" {
+					if err := p.gitService.UseFork(candidateWorkspace, workspace.ForkURL); err != nil {
+						candidate.Error = err.Error()
+						return
+					}
+				}
+				if _, err := p.gitService.CheckoutIssueBranch(candidateWorkspace, services.NewGitAuth(repository), dev.BranchName, rerunPolicy(repository)); err != nil {
+					candidate.Error = err.Error()
+					return
+				}
+			}
+
+			if err := ctx.Err(); err != nil {
+				candidate.Error = err.Error()
+				return
+			}
+
+			response, verification, err := p.generateAndVerify(ctx, dev, request, prompt, repository, analysis, run.workspace, run.profile, candidate.Index)
+			if err != nil {
+				candidate.Error = err.Error()
+				return
+			}
+			run.response = response
+			run.verification = verification
+			candidate.FilesChanged = response.FilesChanged
+			candidate.Verification = verification
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range runs {
+		candidate := &candidates[i]
+		if runs[i].workspace == nil || candidate.Error != "" {
+			continue
+		}
+
+		diff, err := p.gitService.WorkingDiff(runs[i].workspace)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to compute candidate diff")
+			continue
+		}
+		candidate.DiffLines = services.DiffLineCount(diff)
+
+		path, err := p.artifactService.Save(dev.ID.Hex(), fmt.Sprintf("candidate-%d.diff", candidate.Index), []byte(diff))
+		if err != nil {
+			logger.WithError(err).Warn("Failed to save candidate diff")
+			continue
+		}
+		candidate.DiffPath = path
+	}
+
+	selected := services.RankCandidates(candidates)
+
+	if err := p.devRepo.UpdateCandidates(ctx, dev.ID, candidates); err != nil {
+		logger.WithError(err).Warn("Failed to record candidates")
+	}
+
+	for i := range runs {
+		if i != selected && i != 0 && runs[i].workspace != nil {
+			p.gitService.Cleanup(runs[i].workspace)
+		}
+	}
+
+	if selected < 0 {
+		return nil, nil, nil, fmt.Errorf("none of the %d candidates produced usable changes", count)
+	}
+
+	winner := runs[selected]
+	logger.WithFields(logrus.Fields{
+		"candidate":     candidates[selected].Index,
+		"score":         candidates[selected].Score,
+		"agent_profile": candidates[selected].AgentProfileName,
+	}).Info("Best-of-N candidate selected")
+
+	if winner.profile != nil {
+		if err := p.devRepo.UpdateAgentProfile(ctx, dev.ID, winner.profile.ID, winner.profile.Version); err != nil {
+			logger.WithError(err).Warn("Failed to record agent profile")
+		}
+	}
+
+	winner.response.DevelopmentDetails = fmt.Sprintf("Selected candidate %d of %d (score %.1f).\n\n%s",
+		candidates[selected].Index, count, candidates[selected].Score, winner.response.DevelopmentDetails)
+
+	return winner.workspace, winner.response, winner.verification, nil
+}
+
+// candidateProfile returns the agent profile for the candidate at index i. The given
+// profiles are assigned round-robin; without any, the resolved profile is used.
+func (p *Pipeline) candidateProfile(profileIDs []string, i int, defaultProfile *models.AgentProfile) (*models.AgentProfile, error) {
+	if len(profileIDs) == 0 {
+		return defaultProfile, nil
+	}
+	return p.configClient.GetAgentProfile(profileIDs[i%len(profileIDs)])
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/services"
+)
+
+// planFirstEnabled reports whether a plan must be approved before code is generated.
+// If labels are configured, only issues carrying one of them require a plan.
+func planFirstEnabled(project *models.Project, request *models.DevelopmentRequest) bool {
+	settings := project.PlanFirst
+	if settings == nil || !settings.Enabled {
+		return false
+	}
+	return len(settings.Labels) == 0 || hasAnyLabel(request.Labels, settings.Labels)
+}
+
+// planAndPark asks the agent for an implementation plan of the task prompt, stores it on the development and
+// posts it to JIRA. The development stays in awaiting_approval until the plan is reviewed.
+// A rejected plan is re-planned with the reviewer feedback.
+func (p *Pipeline) planAndPark(
+	ctx context.Context,
+	dev *models.Development,
+	request *models.DevelopmentRequest,
+	taskPrompt string,
+	workspace *services.GitWorkspace,
+	profile *models.AgentProfile,
+) error {
+	var previous *models.Plan
+	revision := 1
+	if dev.Plan != nil && dev.Plan.Status == models.PlanStatusRejected {
+		previous = dev.Plan
+		revision = dev.Plan.Revision + 1
+	}
+
+	p.logger.WithField("revision", revision).Info("Generating implementation plan")
+	prompt := p.claudeService.BuildPlanPrompt(request, taskPrompt, previous)
+	content, err := p.claudeService.GeneratePlan(request, prompt, workspace.Path, profile)
+	if err != nil {
+		p.devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	plan := &models.Plan{
+		Revision:  revision,
+		Content:   content,
+		Status:    models.PlanStatusPending,
+		CreatedAt: time.Now(),
+	}
+	if err := p.devRepo.SavePlan(ctx, dev.ID, plan, previous); err != nil {
+		p.devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+	dev.Plan = plan
+
+	if err := p.jiraClient.AddComment(request.JiraIssueKey, formatPlanComment(plan)); err != nil {
+		p.logger.WithError(err).Warn("Failed to post plan to JIRA")
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"jira_issue_key": request.JiraIssueKey,
+		"revision":       revision,
+	}).Info("Implementation plan awaiting approval")
+
+	return nil
+}
+
+// formatPlanComment renders a plan as a JIRA comment with review instructions
+func formatPlanComment(plan *models.Plan) string {
+	var comment strings.Builder
+
+	comment.WriteString(fmt.Sprintf("h3. Implementation plan (revision %d)\n\n", plan.Revision))
+	comment.WriteString(plan.Content)
+	comment.WriteString("\n\n----\n")
+	comment.WriteString("Reply with {{/approve}} to start the implementation, or {{/reject <feedback>}} to request a new plan.")
+
+	return comment.String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	// prTrackInterval is how often open pull requests are checked for merge or close
+	prTrackInterval = 10 * time.Minute
+
+	// prTrackWindow limits tracking to pull requests of recent developments
+	prTrackWindow = 30 * 24 * time.Hour
+)
+
+// RunPRTracker periodically records the state and review count of the pull requests
+// created by the pipeline, so outcomes can be compared per experiment variant.
+// It returns when ctx is cancelled.
+func (p *Pipeline) RunPRTracker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.trackPullRequests(ctx)
+		}
+	}
+}
+
+func (p *Pipeline) trackPullRequests(ctx context.Context) {
+	developments, err := p.devRepo.FindOpenPullRequests(ctx, time.Now().Add(-prTrackWindow))
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to find open pull requests")
+		return
+	}
+
+	// Projects are fetched once per run for the repository access tokens
+	projects := map[string]*models.Project{}
+	for i := range developments {
+		dev := &developments[i]
+		logger := p.logger.WithFields(logrus.Fields{
+			"jira_issue_key": dev.JiraIssueKey,
+			"pr_url":         dev.PRMRUrl,
+		})
+
+		project, ok := projects[dev.JiraProjectKey]
+		if !ok {
+			project, err = p.configClient.GetProjectByJiraKey(dev.JiraProjectKey)
+			if err != nil {
+				logger.WithError(err).Warn("Failed to fetch project for pull request tracking")
+			}
+			projects[dev.JiraProjectKey] = project
+		}
+		if project == nil {
+			continue
+		}
+
+		repository, err := p.configClient.FindRepositoryInProject(project, dev.RepositoryURL)
+		if err != nil {
+			logger.WithError(err).Warn("Repository not found for pull request tracking")
+			continue
+		}
+
+		status, err := p.prService.GetPullRequestStatus(dev.PRMRUrl, repository.GitAccessToken)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to fetch pull request status")
+			continue
+		}
+
+		if err := p.devRepo.UpdatePullRequest(ctx, dev.ID, status); err != nil {
+			logger.WithError(err).Warn("Failed to save pull request status")
+			continue
+		}
+		if status.State != models.PullRequestStateOpen {
+			logger.WithField("state", status.State).Info("Pull request outcome recorded")
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/services"
+)
+
+// maxQuestions limits how many clarifying questions the agent may ask per development
+const maxQuestions = 3
+
+// questionAskedError is returned by code generation when the agent asked a clarifying
+// question instead of implementing the task
+type questionAskedError struct {
+	question *models.AgentQuestion
+}
+
+func (e *questionAskedError) Error() string {
+	return fmt.Sprintf("agent asked a clarifying question: %s", e.question.Question)
+}
+
+// parkForInput posts the agent's question to JIRA and parks the development in
+// awaiting_input. The workspace is kept so the development resumes where it stopped.
+func (p *Pipeline) parkForInput(
+	ctx context.Context,
+	dev *models.Development,
+	request *models.DevelopmentRequest,
+	workspace *services.GitWorkspace,
+	asked *models.AgentQuestion,
+) error {
+	question := models.Question{
+		Question: asked.Question,
+		Context:  asked.Context,
+		AskedAt:  time.Now(),
+	}
+
+	if err := p.devRepo.AskQuestion(ctx, dev.ID, question, workspace.Path); err != nil {
+		p.devRepo.MarkFailed(ctx, dev.ID, err.Error())
+		return err
+	}
+
+	if err := p.jiraClient.AddComment(request.JiraIssueKey, formatQuestionComment(question)); err != nil {
+		p.logger.WithError(err).Warn("Failed to post question to JIRA")
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"jira_issue_key": request.JiraIssueKey,
+		"question":       question.Question,
+		"workspace":      workspace.Path,
+	}).Info("Development awaiting input")
+
+	return nil
+}
+
+// formatQuestionComment renders a clarifying question as a JIRA comment with answer instructions
+func formatQuestionComment(question models.Question) string {
+	var comment strings.Builder
+
+	comment.WriteString("h3. Clarification needed\n\n")
+	comment.WriteString(question.Question)
+	if question.Context != "" {
+		comment.WriteString("\n\n_Context:_ ")
+		comment.WriteString(question.Context)
+	}
+	comment.WriteString("\n\n----\n")
+	comment.WriteString("Reply with {{/answer <your answer>}} to continue the development.")
+
+	return comment.String()
+}
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type DevelopmentRepository struct {
@@ -99,6 +100,410 @@ func (r *DevelopmentRepository) UpdatePrompt(ctx context.Context, id primitive.O
 	return nil
 }
 
+func (r *DevelopmentRepository) UpdatePromptTemplate(ctx context.Context, id primitive.ObjectID, templateID string, version int) error {
+	update := bson.M{
+		"$set": bson.M{
+			"prompt_template_id":      templateID,
+			"prompt_template_version": version,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update prompt template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DevelopmentRepository) UpdateAgentProfile(ctx context.Context, id primitive.ObjectID, profileID string, version int) error {
+	update := bson.M{
+		"$set": bson.M{
+			"agent_profile_id":      profileID,
+			"agent_profile_version": version,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update agent profile: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DevelopmentRepository) UpdateExperiment(ctx context.Context, id primitive.ObjectID, assignment *models.ExperimentAssignment) error {
+	update := bson.M{
+		"$set": bson.M{
+			"experiment": assignment,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update experiment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DevelopmentRepository) UpdatePullRequest(ctx context.Context, id primitive.ObjectID, status *models.PullRequestStatus) error {
+	update := bson.M{
+		"$set": bson.M{
+			"pull_request": status,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update pull request status: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePreviousAttempt links the development to the earlier run of its issue
+func (r *DevelopmentRepository) UpdatePreviousAttempt(ctx context.Context, id primitive.ObjectID, attempt *models.PreviousAttempt) error {
+	update := bson.M{
+		"$set": bson.M{
+			"previous_attempt": attempt,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update previous attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DevelopmentRepository) UpdateRetrieval(ctx context.Context, id primitive.ObjectID, retrieval *models.Retrieval) error {
+	update := bson.M{
+		"$set": bson.M{
+			"retrieval": retrieval,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update retrieval: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRetrievalOutcome records the files the agent changed next to the retrieved files
+func (r *DevelopmentRepository) UpdateRetrievalOutcome(ctx context.Context, id primitive.ObjectID, changedFiles []string, hits int) error {
+	update := bson.M{
+		"$set": bson.M{
+			"retrieval.changed_files": changedFiles,
+			"retrieval.hits":          hits,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "retrieval": bson.M{"$exists": true}}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update retrieval outcome: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DevelopmentRepository) UpdateVerification(ctx context.Context, id primitive.ObjectID, result *models.VerificationResult) error {
+	update := bson.M{
+		"$set": bson.M{
+			"verification": result,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update verification: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DevelopmentRepository) AddIteration(ctx context.Context, id primitive.ObjectID, iteration models.Iteration) error {
+	update := bson.M{
+		"$push": bson.M{
+			"iterations": iteration,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to add iteration: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DevelopmentRepository) UpdateReadiness(ctx context.Context, id primitive.ObjectID, readiness *models.ReadinessResult) error {
+	update := bson.M{
+		"$set": bson.M{
+			"readiness": readiness,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update readiness: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DevelopmentRepository) UpdateCandidates(ctx context.Context, id primitive.ObjectID, candidates []models.Candidate) error {
+	update := bson.M{
+		"$set": bson.M{
+			"candidates": candidates,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update candidates: %w", err)
+	}
+
+	return nil
+}
+
+// SavePlan stores a new plan and parks the development until it is reviewed.
+// A previously rejected plan is moved to the plan history.
+func (r *DevelopmentRepository) SavePlan(ctx context.Context, id primitive.ObjectID, plan *models.Plan, previous *models.Plan) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"plan":      plan,
+			"status":    models.DevelopmentStatusAwaitingApproval,
+			"parked_at": &now,
+		},
+	}
+	if previous != nil {
+		update["$push"] = bson.M{"plan_history": previous}
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	return nil
+}
+
+// ReviewPlan approves or rejects the plan of a development awaiting approval.
+// Returns false when the development is not awaiting approval.
+func (r *DevelopmentRepository) ReviewPlan(ctx context.Context, id primitive.ObjectID, approved bool, feedback, reviewedBy string) (bool, error) {
+	now := time.Now()
+	status := models.DevelopmentStatusPlanRejected
+	planStatus := models.PlanStatusRejected
+	if approved {
+		status = models.DevelopmentStatusPlanApproved
+		planStatus = models.PlanStatusApproved
+	}
+
+	filter := bson.M{
+		"_id":    id,
+		"status": models.DevelopmentStatusAwaitingApproval,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           status,
+			"plan.status":      planStatus,
+			"plan.feedback":    feedback,
+			"plan.reviewed_by": reviewedBy,
+			"plan.reviewed_at": &now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to review plan: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// ClaimResume atomically moves a parked development from the given status to ready with a
+// new resume token, so that only one resume is published. Returns false when the status has
+// already changed.
+func (r *DevelopmentRepository) ClaimResume(ctx context.Context, id primitive.ObjectID, from string) (string, bool, error) {
+	return r.claimResume(ctx, bson.M{"_id": id, "status": from})
+}
+
+// ReclaimStaleResume gives a resumed development whose last heartbeat is older than before a
+// new resume token, so it can be published again. Returns false when another worker reclaimed
+// it or it is no longer ready.
+func (r *DevelopmentRepository) ReclaimStaleResume(ctx context.Context, id primitive.ObjectID, before time.Time) (string, bool, error) {
+	return r.claimResume(ctx, bson.M{
+		"_id":        id,
+		"status":     "ready",
+		"resumed_at": bson.M{"$lt": before},
+	})
+}
+
+func (r *DevelopmentRepository) claimResume(ctx context.Context, filter bson.M) (string, bool, error) {
+	now := time.Now()
+	token := primitive.NewObjectID().Hex()
+	update := bson.M{
+		"$set": bson.M{
+			"status":       "ready",
+			"resume_token": token,
+			"resumed_at":   &now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to claim development: %w", err)
+	}
+
+	return token, result.ModifiedCount == 1, nil
+}
+
+// StartResume takes over a ready development published with the given resume token and
+// returns it with the token of this run. Returns nil when the token is outdated, because the
+// development was resumed already or published again.
+func (r *DevelopmentRepository) StartResume(ctx context.Context, id primitive.ObjectID, token string) (*models.Development, string, error) {
+	now := time.Now()
+	runToken := primitive.NewObjectID().Hex()
+	filter := bson.M{
+		"_id":          id,
+		"status":       "ready",
+		"resume_token": token,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"resume_token": runToken,
+			"resumed_at":   &now,
+		},
+	}
+
+	var dev models.Development
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&dev)
+	if err == mongo.ErrNoDocuments {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to start resume: %w", err)
+	}
+
+	return &dev, runToken, nil
+}
+
+// HeartbeatResume records that the resume holding the token is still running. Returns false
+// when the development left ready or its resume was taken over.
+func (r *DevelopmentRepository) HeartbeatResume(ctx context.Context, id primitive.ObjectID, token string) (bool, error) {
+	filter := bson.M{
+		"_id":          id,
+		"status":       "ready",
+		"resume_token": token,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"resumed_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to record resume heartbeat: %w", err)
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// FindStaleResumes returns the ready developments whose resume had no heartbeat since before
+func (r *DevelopmentRepository) FindStaleResumes(ctx context.Context, before time.Time) ([]models.Development, error) {
+	return r.find(ctx, bson.M{
+		"status":     "ready",
+		"resumed_at": bson.M{"$lt": before},
+	})
+}
+
+// FindParkedBefore returns the developments in the given status that were parked before the given time
+func (r *DevelopmentRepository) FindParkedBefore(ctx context.Context, status string, before time.Time) ([]models.Development, error) {
+	return r.find(ctx, bson.M{
+		"status":    status,
+		"parked_at": bson.M{"$lt": before},
+	})
+}
+
+// ExpireParked marks a development still in the given status as failed and forgets its kept
+// workspace. Returns false when the status has already changed.
+func (r *DevelopmentRepository) ExpireParked(ctx context.Context, id primitive.ObjectID, status, errorMsg string) (bool, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":        "failed",
+			"error_message": errorMsg,
+			"completed_at":  &now,
+		},
+		"$unset": bson.M{
+			"workspace_path": "",
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": status}, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to expire development: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// AskQuestion records a clarifying question and parks the development until it is answered.
+// The workspace path is kept so the development can resume in the same workspace.
+func (r *DevelopmentRepository) AskQuestion(ctx context.Context, id primitive.ObjectID, question models.Question, workspacePath string) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":         models.DevelopmentStatusAwaitingInput,
+			"workspace_path": workspacePath,
+			"parked_at":      &now,
+		},
+		"$push": bson.M{
+			"questions": question,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to save question: %w", err)
+	}
+
+	return nil
+}
+
+// AnswerQuestion stores the answer to the question at the given index of a development
+// awaiting input. Returns false when the development is not awaiting input.
+func (r *DevelopmentRepository) AnswerQuestion(ctx context.Context, id primitive.ObjectID, index int, answer, answeredBy string) (bool, error) {
+	now := time.Now()
+	prefix := fmt.Sprintf("questions.%d.", index)
+	filter := bson.M{
+		"_id":    id,
+		"status": models.DevelopmentStatusAwaitingInput,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":               models.DevelopmentStatusInputReceived,
+			prefix + "answer":      answer,
+			prefix + "answered_by": answeredBy,
+			prefix + "answered_at": &now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to answer question: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
 func (r *DevelopmentRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, errorMsg string) error {
 	now := time.Now()
 	update := bson.M{
@@ -117,6 +522,26 @@ func (r *DevelopmentRepository) MarkFailed(ctx context.Context, id primitive.Obj
 	return nil
 }
 
+// MarkFailedWithType marks a development as failed and records the type of the failure
+func (r *DevelopmentRepository) MarkFailedWithType(ctx context.Context, id primitive.ObjectID, failureType, errorMsg string) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":        "failed",
+			"failure_type":  failureType,
+			"error_message": errorMsg,
+			"completed_at":  &now,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to mark as failed: %w", err)
+	}
+
+	return nil
+}
+
 func (r *DevelopmentRepository) FindByJiraIssueKey(ctx context.Context, jiraIssueKey string) (*models.Development, error) {
 	var dev models.Development
 	err := r.collection.FindOne(ctx, bson.M{"jira_issue_key": jiraIssueKey}).Decode(&dev)
@@ -130,8 +555,72 @@ func (r *DevelopmentRepository) FindByJiraIssueKey(ctx context.Context, jiraIssu
 	return &dev, nil
 }
 
+// FindLatestByJiraIssueKey returns the most recent development of an issue
+func (r *DevelopmentRepository) FindLatestByJiraIssueKey(ctx context.Context, jiraIssueKey string) (*models.Development, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var dev models.Development
+	err := r.collection.FindOne(ctx, bson.M{"jira_issue_key": jiraIssueKey}, opts).Decode(&dev)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find development: %w", err)
+	}
+
+	return &dev, nil
+}
+
+// FindPreviousByJiraIssueKey returns the most recent development of an issue created
+// before the given time
+func (r *DevelopmentRepository) FindPreviousByJiraIssueKey(ctx context.Context, jiraIssueKey string, before time.Time) (*models.Development, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var dev models.Development
+	err := r.collection.FindOne(ctx, bson.M{"jira_issue_key": jiraIssueKey, "created_at": bson.M{"$lt": before}}, opts).Decode(&dev)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find development: %w", err)
+	}
+
+	return &dev, nil
+}
+
 func (r *DevelopmentRepository) FindByStatus(ctx context.Context, status string) ([]models.Development, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{"status": status})
+	return r.find(ctx, bson.M{"status": status})
+}
+
+func (r *DevelopmentRepository) find(ctx context.Context, filter bson.M) ([]models.Development, error) {
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find developments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var developments []models.Development
+	if err := cursor.All(ctx, &developments); err != nil {
+		return nil, fmt.Errorf("failed to decode developments: %w", err)
+	}
+
+	return developments, nil
+}
+
+// FindOpenPullRequests returns completed developments created since the given time whose
+// pull request has not been merged or closed yet
+func (r *DevelopmentRepository) FindOpenPullRequests(ctx context.Context, since time.Time) ([]models.Development, error) {
+	filter := bson.M{
+		"status":     "completed",
+		"pr_mr_url":  bson.M{"$nin": bson.A{nil, ""}},
+		"created_at": bson.M{"$gte": since},
+		"pull_request.state": bson.M{"$nin": bson.A{
+			models.PullRequestStateMerged,
+			models.PullRequestStateClosed,
+		}},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find developments: %w", err)
 	}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/services"
+)
+
+const (
+	// resumePollInterval is how often parked developments are checked for a decision
+	resumePollInterval = 15 * time.Second
+
+	// resumeHeartbeatInterval is how often a running resume refreshes its claim
+	resumeHeartbeatInterval = time.Minute
+
+	// resumeStaleAfter is how long a ready development may go without a heartbeat before it
+	// is published again, e.g. because the consumer died while resuming it
+	resumeStaleAfter = 10 * time.Minute
+)
+
+// parkedStatuses are the statuses of developments waiting for a human decision
+var parkedStatuses = []string{
+	models.DevelopmentStatusAwaitingApproval,
+	models.DevelopmentStatusAwaitingInput,
+}
+
+// ResumePublisher publishes a request onto the development queue
+type ResumePublisher func(*models.DevelopmentRequest) error
+
+// resumableStatuses are the statuses of parked developments that are ready to continue
+var resumableStatuses = []string{
+	models.DevelopmentStatusPlanApproved,
+	models.DevelopmentStatusPlanRejected,
+	models.DevelopmentStatusInputReceived,
+}
+
+// handleCommand applies a command from a JIRA comment to the latest development of the issue
+func (p *Pipeline) handleCommand(ctx context.Context, request *models.DevelopmentRequest) error {
+	logger := p.logger.WithFields(logrus.Fields{
+		"jira_issue_key": request.JiraIssueKey,
+		"action":         request.Action,
+	})
+
+	dev, err := p.devRepo.FindLatestByJiraIssueKey(ctx, request.JiraIssueKey)
+	if err != nil {
+		return err
+	}
+	if dev == nil {
+		logger.Warn("No development found for command, ignoring")
+		return nil
+	}
+
+	switch request.Action {
+	case models.ActionApprovePlan, models.ActionRejectPlan:
+		approved := request.Action == models.ActionApprovePlan
+		feedback := strings.TrimSpace(request.Comment)
+		if !approved && feedback == "" {
+			if err := p.jiraClient.AddComment(request.JiraIssueKey, "Please add feedback after {{/reject}} so the plan can be revised."); err != nil {
+				logger.WithError(err).Warn("Failed to post JIRA comment")
+			}
+			return nil
+		}
+
+		reviewed, err := p.devRepo.ReviewPlan(ctx, dev.ID, approved, feedback, request.CommentAuthor)
+		if err != nil {
+			return err
+		}
+		if !reviewed {
+			logger.WithField("status", dev.Status).Warn("Development is not awaiting approval, ignoring command")
+			return nil
+		}
+
+		logger.Info("Plan reviewed from JIRA comment")
+		return nil
+	case models.ActionAnswerQuestion:
+		answer := strings.TrimSpace(request.Comment)
+		if answer == "" || len(dev.Questions) == 0 {
+			logger.Warn("Empty answer or no question asked, ignoring command")
+			return nil
+		}
+
+		answered, err := p.devRepo.AnswerQuestion(ctx, dev.ID, len(dev.Questions)-1, answer, request.CommentAuthor)
+		if err != nil {
+			return err
+		}
+		if !answered {
+			logger.WithField("status", dev.Status).Warn("Development is not awaiting input, ignoring command")
+			return nil
+		}
+
+		logger.Info("Question answered from JIRA comment")
+		return nil
+	default:
+		return fmt.Errorf("unsupported action: %s", request.Action)
+	}
+}
+
+// RunResumer periodically publishes parked developments whose plan was reviewed or question
+// answered, so they are resumed by the queue consumer, and publishes again the resumes that
+// stopped sending heartbeats. Developments parked for longer than parkedTTL are failed and
+// their kept workspace removed; a parkedTTL of 0 keeps them. It returns when ctx is cancelled.
+func (p *Pipeline) RunResumer(ctx context.Context, interval time.Duration, publish ResumePublisher, parkedTTL time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.publishReviewed(ctx, publish)
+			p.republishStale(ctx, publish)
+			p.expireParked(ctx, parkedTTL)
+		}
+	}
+}
+
+func (p *Pipeline) publishReviewed(ctx context.Context, publish ResumePublisher) {
+	for _, status := range resumableStatuses {
+		developments, err := p.devRepo.FindByStatus(ctx, status)
+		if err != nil {
+			p.logger.WithError(err).Error("Failed to find developments to resume")
+			continue
+		}
+
+		for i := range developments {
+			dev := &developments[i]
+
+			if dev.Request == nil {
+				p.devRepo.MarkFailed(ctx, dev.ID, "original request not stored, cannot resume")
+				continue
+			}
+
+			// Claim the development so it is published only once
+			token, claimed, err := p.devRepo.ClaimResume(ctx, dev.ID, status)
+			if err != nil || !claimed {
+				continue
+			}
+
+			p.publishResume(dev, token, publish)
+		}
+	}
+}
+
+// republishStale publishes the ready developments again whose resume stopped sending
+// heartbeats. The resume token changes, so a message still queued for them is ignored.
+func (p *Pipeline) republishStale(ctx context.Context, publish ResumePublisher) {
+	before := time.Now().Add(-resumeStaleAfter)
+	developments, err := p.devRepo.FindStaleResumes(ctx, before)
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to find stale resumes")
+		return
+	}
+
+	for i := range developments {
+		dev := &developments[i]
+
+		token, claimed, err := p.devRepo.ReclaimStaleResume(ctx, dev.ID, before)
+		if err != nil || !claimed {
+			continue
+		}
+
+		p.logger.WithField("development_id", dev.ID.Hex()).Warn("Resume stopped sending heartbeats, publishing it again")
+		p.publishResume(dev, token, publish)
+	}
+}
+
+// publishResume publishes a resume request for a claimed development. When publishing
+// fails, the development is published again once its claim is stale.
+func (p *Pipeline) publishResume(dev *models.Development, token string, publish ResumePublisher) {
+	logger := p.logger.WithFields(logrus.Fields{
+		"development_id": dev.ID.Hex(),
+		"jira_issue_key": dev.JiraIssueKey,
+	})
+
+	request := &models.DevelopmentRequest{
+		JiraIssueID:    dev.JiraIssueID,
+		JiraIssueKey:   dev.JiraIssueKey,
+		JiraProjectKey: dev.JiraProjectKey,
+		Action:         models.ActionResume,
+		DevelopmentID:  dev.ID.Hex(),
+		ResumeToken:    token,
+	}
+	if err := publish(request); err != nil {
+		logger.WithError(err).Error("Failed to publish development to resume")
+		return
+	}
+
+	logger.Info("Development published to resume")
+}
+
+// resume continues a development published by the resumer. Requests whose resume token is
+// outdated are ignored, so every publication is processed at most once.
+func (p *Pipeline) resume(ctx context.Context, request *models.DevelopmentRequest) error {
+	logger := p.logger.WithFields(logrus.Fields{
+		"development_id": request.DevelopmentID,
+		"jira_issue_key": request.JiraIssueKey,
+	})
+
+	id, err := primitive.ObjectIDFromHex(request.DevelopmentID)
+	if err != nil {
+		return fmt.Errorf("invalid development ID %q: %w", request.DevelopmentID, err)
+	}
+
+	dev, token, err := p.devRepo.StartResume(ctx, id, request.ResumeToken)
+	if err != nil {
+		return err
+	}
+	if dev == nil {
+		logger.Info("Development was resumed already, ignoring")
+		return nil
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go p.resumeHeartbeat(heartbeatCtx, dev.ID, token)
+
+	logger.Info("Resuming development")
+	return p.process(ctx, dev, dev.Request)
+}
+
+// resumeHeartbeat keeps the claim of a running resume fresh until ctx is cancelled or the
+// development leaves ready
+func (p *Pipeline) resumeHeartbeat(ctx context.Context, id primitive.ObjectID, token string) {
+	ticker := time.NewTicker(resumeHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			alive, err := p.devRepo.HeartbeatResume(ctx, id, token)
+			if err != nil {
+				p.logger.WithError(err).Warn("Failed to record resume heartbeat")
+				continue
+			}
+			if !alive {
+				return
+			}
+		}
+	}
+}
+
+// expireParked fails the developments that waited longer than ttl for a plan review or an
+// answer, and removes the workspaces kept for them
+func (p *Pipeline) expireParked(ctx context.Context, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	before := time.Now().Add(-ttl)
+	for _, status := range parkedStatuses {
+		developments, err := p.devRepo.FindParkedBefore(ctx, status, before)
+		if err != nil {
+			p.logger.WithError(err).Error("Failed to find expired developments")
+			continue
+		}
+
+		for i := range developments {
+			dev := &developments[i]
+
+			reason := fmt.Sprintf("no decision within %d hours while %s", int(ttl.Hours()), status)
+			expired, err := p.devRepo.ExpireParked(ctx, dev.ID, status, reason)
+			if err != nil || !expired {
+				continue
+			}
+
+			if dev.WorkspacePath != "" {
+				if err := p.gitService.Cleanup(&services.GitWorkspace{Path: dev.WorkspacePath}); err != nil {
+					p.logger.WithError(err).Warn("Failed to remove kept workspace")
+				}
+			}
+
+			if err := p.jiraClient.AddComment(dev.JiraIssueKey, fmt.Sprintf("Development stopped: %s. Move the issue again to start over.", reason)); err != nil {
+				p.logger.WithError(err).Warn("Failed to post JIRA comment")
+			}
+
+			p.logger.WithFields(logrus.Fields{
+				"development_id": dev.ID.Hex(),
+				"jira_issue_key": dev.JiraIssueKey,
+				"status":         status,
+			}).Info("Parked development expired")
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	// Defaults of the analysis cache
+	DefaultAnalysisCacheDir      = "/tmp/sdlc-analysis-cache"
+	DefaultAnalysisCacheMaxBytes = 256 * 1024 * 1024
+	DefaultAnalysisCacheTTL      = 7 * 24 * time.Hour
+
+	analysisCacheExt = ".json"
+)
+
+// CachedAnalysis is a cached repository analysis with the retrieval index built from its
+// repository map. Index is nil when retrieval was disabled for the repository.
+type CachedAnalysis struct {
+	RepositoryURL   string                     `json:"repository_url"`
+	CommitSHA       string                     `json:"commit_sha"`
+	AnalyzerVersion string                     `json:"analyzer_version"`
+	Analysis        *models.RepositoryAnalysis `json:"analysis"`
+	Index           *LexicalIndex              `json:"index,omitempty"`
+	CreatedAt       time.Time                  `json:"created_at"`
+}
+
+// AnalysisCache keeps repository analyses on disk, keyed by repository URL, commit SHA and
+// analyzer version, so that tickets running against the same base commit do not walk the
+// repository again. Entries expire after the TTL and the least recently used entries are
+// evicted once the cache grows beyond its size limit.
+type AnalysisCache struct {
+	dir      string
+	maxBytes int64
+	ttl      time.Duration
+	logger   *logrus.Logger
+}
+
+func NewAnalysisCache(dir string, maxBytes int64, ttl time.Duration, logger *logrus.Logger) *AnalysisCache {
+	if dir == "" {
+		dir = DefaultAnalysisCacheDir
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultAnalysisCacheMaxBytes
+	}
+	if ttl <= 0 {
+		ttl = DefaultAnalysisCacheTTL
+	}
+	return &AnalysisCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		logger:   logger,
+	}
+}
+
+// AnalysisCacheKey returns the cache key of a repository commit. Repository settings that
+// change the analysis (the convention globs, the sparse checkout paths) are part of the key.
+func AnalysisCacheKey(repoURL, commitSHA string, conventions *models.ConventionSettings, sparsePaths []string) string {
+	settings, _ := json.Marshal([]interface{}{conventions, sparsePaths})
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git"),
+		commitSHA,
+		AnalyzerVersion,
+		string(settings),
+	}, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+// Get returns the cached analysis of a key. The index of the entry reads its excerpts
+// from repoPath, the workspace the cached commit is checked out in. Expired entries and
+// entries of another analyzer version are removed.
+func (c *AnalysisCache) Get(key, repoPath string) (*CachedAnalysis, bool) {
+	path := c.entryPath(key)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.logger.WithError(err).Warn("Failed to read cached analysis")
+		}
+		return nil, false
+	}
+
+	entry := &CachedAnalysis{}
+	if err := json.Unmarshal(content, entry); err != nil || entry.Analysis == nil {
+		c.logger.WithField("key", key).Warn("Removing unreadable cached analysis")
+		os.Remove(path)
+		return nil, false
+	}
+	if entry.AnalyzerVersion != AnalyzerVersion || time.Since(entry.CreatedAt) > c.ttl {
+		os.Remove(path)
+		return nil, false
+	}
+
+	// The modification time orders the entries for eviction
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	if entry.Index != nil {
+		entry.Index.repoPath = repoPath
+	}
+	return entry, true
+}
+
+// Put stores an analysis under a key and evicts the least recently used entries beyond the
+// size limit
+func (c *AnalysisCache) Put(key string, entry *CachedAnalysis) error {
+	entry.AnalyzerVersion = AnalyzerVersion
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode analysis: %w", err)
+	}
+	if int64(len(content)) > c.maxBytes {
+		return fmt.Errorf("analysis of %d bytes exceeds the cache size limit", len(content))
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so that concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), c.entryPath(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	c.evict()
+	return nil
+}
+
+// evict removes the least recently used entries until the cache fits its size limit
+func (c *AnalysisCache) evict() {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	files := []os.FileInfo{}
+	total := int64(0)
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || filepath.Ext(dirEntry.Name()) != analysisCacheExt {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, info := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, info.Name())); err != nil {
+			continue
+		}
+		total -= info.Size()
+		c.logger.WithField("entry", info.Name()).Info("Evicted cached analysis")
+	}
+}
+
+func (c *AnalysisCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+analysisCacheExt)
+}
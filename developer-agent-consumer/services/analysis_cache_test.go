@@ -0,0 +1,116 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestAnalysisCache(t *testing.T) {
+	repoDir := writeTestFiles(t, map[string]string{
+		"go.mod":                    "module example.com/app\n\ngo 1.20\n",
+		"services/retry_service.go": "package services\n\n// PushWithRetry retries failed pushes\nfunc PushWithRetry() error { return nil }\n",
+		"handlers/handler.go":       "package handlers\n\ntype Handler struct{}\n",
+	})
+	defer os.RemoveAll(repoDir)
+	cacheDir := t.TempDir()
+
+	service := NewAnalyzerService(logrus.New())
+	analysis, err := service.AnalyzeRepository(repoDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+	index := BuildLexicalIndex(repoDir, analysis.RepoMap.Files)
+
+	cache := NewAnalysisCache(cacheDir, 0, 0, logrus.New())
+	key := AnalysisCacheKey("https://github.com/example/app.git", "abc123", nil, nil)
+	if _, ok := cache.Get(key, repoDir); ok {
+		t.Fatal("Did not expect a cached analysis before Put")
+	}
+	if err := cache.Put(key, &CachedAnalysis{CommitSHA: "abc123", Analysis: analysis, Index: index}); err != nil {
+		t.Fatalf("Failed to cache analysis: %v", err)
+	}
+
+	cached, ok := cache.Get(key, repoDir)
+	if !ok {
+		t.Fatal("Expected a cached analysis")
+	}
+	if cached.AnalyzerVersion != AnalyzerVersion || !reflect.DeepEqual(cached.Analysis.Languages, analysis.Languages) || cached.Analysis.RepoMap.Content != analysis.RepoMap.Content {
+		t.Errorf("Cached analysis differs from the analysis: %+v", cached.Analysis)
+	}
+	terms := []string{"retry", "push"}
+	if got, expected := cached.Index.Search(terms, 1), index.Search(terms, 1); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected the cached index to rank like the built one, got %+v, expected %+v", got, expected)
+	}
+
+	// The URL is normalized; the commit, analyzer version and convention settings are part of the key
+	if AnalysisCacheKey("https://github.com/example/app", "abc123", nil, nil) != key {
+		t.Error("Expected the .git suffix to be ignored in the key")
+	}
+	if AnalysisCacheKey("https://github.com/example/app.git", "def456", nil, nil) == key ||
+		AnalysisCacheKey("https://github.com/example/app.git", "abc123", &models.ConventionSettings{MaxBytes: 1024}, nil) == key {
+		t.Error("Expected another commit or other convention settings to change the key")
+	}
+	if AnalysisCacheKey("https://github.com/example/app.git", "abc123", nil, []string{"services"}) == key {
+		t.Error("Expected sparse checkout paths to change the key")
+	}
+
+	// Expired entries are removed
+	expired := NewAnalysisCache(cacheDir, 0, time.Nanosecond, logrus.New())
+	time.Sleep(time.Millisecond)
+	if _, ok := expired.Get(key, repoDir); ok {
+		t.Error("Did not expect an expired analysis")
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, key+".json")); !os.IsNotExist(err) {
+		t.Error("Expected the expired entry to be removed")
+	}
+}
+
+func TestAnalysisCache_Eviction(t *testing.T) {
+	cacheDir := t.TempDir()
+	analysis := &models.RepositoryAnalysis{ProjectType: "Go Application", Languages: []string{"Go"}}
+
+	// Measure one entry to size the cache for two of them
+	probe := NewAnalysisCache(t.TempDir(), 0, 0, logrus.New())
+	if err := probe.Put("probe", &CachedAnalysis{Analysis: analysis}); err != nil {
+		t.Fatalf("Failed to cache analysis: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(probe.dir, "probe.json"))
+	if err != nil {
+		t.Fatalf("Failed to stat entry: %v", err)
+	}
+
+	cache := NewAnalysisCache(cacheDir, info.Size()*2+info.Size()/2, 0, logrus.New())
+	past := time.Now().Add(-time.Hour)
+	for i, key := range []string{"first", "second"} {
+		if err := cache.Put(key, &CachedAnalysis{Analysis: analysis}); err != nil {
+			t.Fatalf("Failed to cache analysis: %v", err)
+		}
+		modTime := past.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(filepath.Join(cacheDir, key+".json"), modTime, modTime)
+	}
+
+	// Reading "first" makes "second" the least recently used entry
+	if _, ok := cache.Get("first", ""); !ok {
+		t.Fatal("Expected the first entry to be cached")
+	}
+	if err := cache.Put("third", &CachedAnalysis{Analysis: analysis}); err != nil {
+		t.Fatalf("Failed to cache analysis: %v", err)
+	}
+
+	for key, expected := range map[string]bool{"first": true, "second": false, "third": true} {
+		if _, ok := cache.Get(key, ""); ok != expected {
+			t.Errorf("Expected %s cached=%v", key, expected)
+		}
+	}
+
+	small := NewAnalysisCache(t.TempDir(), 10, 0, logrus.New())
+	if err := small.Put("large", &CachedAnalysis{Analysis: analysis}); err == nil {
+		t.Error("Expected an entry beyond the size limit to be rejected")
+	}
+}
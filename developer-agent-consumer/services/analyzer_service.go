@@ -1,6 +1,7 @@
 package services
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,6 +20,10 @@ func NewAnalyzerService(logger *logrus.Logger) *AnalyzerService {
 	}
 }
 
+// AnalyzerVersion identifies the output of the analyzer in the analysis cache. Bump it
+// whenever the analysis, the repository map or the retrieval index change.
+const AnalyzerVersion = "1"
+
 var (
 	// Entry point files
 	entryPointFiles = []string{
@@ -40,8 +45,8 @@ var (
 
 	// Configuration files
 	configFiles = []string{
-		"go.mod", "package.json", "requirements.txt", "Pipfile",
-		"pom.xml", "build.gradle", "Cargo.toml", "composer.json",
+		"go.mod", "package.json", "requirements.txt", "Pipfile", "pyproject.toml",
+		"pom.xml", "build.gradle", "build.gradle.kts", "Cargo.toml", "composer.json",
 		".env.example", "config.yaml", "config.yml", "config.json",
 		"Dockerfile", "docker-compose.yml",
 	}
@@ -62,7 +67,9 @@ var (
 	}
 )
 
-func (s *AnalyzerService) AnalyzeRepository(repoPath string) (*models.RepositoryAnalysis, error) {
+// AnalyzeRepository scans the repository structure and reads its convention files.
+// Convention settings are optional; nil uses the default globs and size budget.
+func (s *AnalyzerService) AnalyzeRepository(repoPath string, conventions *models.ConventionSettings) (*models.RepositoryAnalysis, error) {
 	s.logger.WithFields(logrus.Fields{
 		"path": repoPath,
 	}).Info("Analyzing repository structure")
@@ -74,8 +81,13 @@ func (s *AnalyzerService) AnalyzeRepository(repoPath string) (*models.Repository
 		Languages:          []string{},
 		Patterns:           make(map[string]string),
 		DependencyManagers: []string{},
+		Frameworks:         []string{},
+		TestFrameworks:     []string{},
 	}
 
+	// Language of each source file, for the per-module analysis
+	languageFiles := map[string]string{}
+
 	// Scan directory
 	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -118,6 +130,7 @@ func (s *AnalyzerService) AnalyzeRepository(repoPath string) (*models.Repository
 			// Detect languages
 			ext := filepath.Ext(info.Name())
 			if lang, ok := languageExtensions[ext]; ok {
+				languageFiles[filepath.ToSlash(relPath)] = lang
 				if !contains(analysis.Languages, lang) {
 					analysis.Languages = append(analysis.Languages, lang)
 				}
@@ -146,15 +159,45 @@ func (s *AnalyzerService) AnalyzeRepository(repoPath string) (*models.Repository
 	// Detect dependency managers
 	analysis.DependencyManagers = s.detectDependencyManagers(analysis)
 
+	// Parse manifests for language versions, dependencies and frameworks
+	analysis.Manifests = s.detectManifests(repoPath, analysis)
+	for _, manifest := range analysis.Manifests {
+		for _, framework := range manifest.Frameworks {
+			if !contains(analysis.Frameworks, framework) {
+				analysis.Frameworks = append(analysis.Frameworks, framework)
+			}
+		}
+		for _, framework := range manifest.TestFrameworks {
+			if !contains(analysis.TestFrameworks, framework) {
+				analysis.TestFrameworks = append(analysis.TestFrameworks, framework)
+			}
+		}
+	}
+
+	// Split monorepos into modules
+	s.detectModules(repoPath, analysis, languageFiles)
+
 	// Detect common patterns
 	s.detectPatterns(analysis)
 
+	// Detect build, lint and test commands
+	analysis.VerificationCommands = s.detectVerificationCommands(repoPath, analysis)
+
+	// Read contributing guides, agent instructions, lint configs and ADRs
+	analysis.Conventions = s.detectConventionFiles(repoPath, conventions)
+
+	// File tree with exported symbols, for large codebases
+	analysis.RepoMap = s.buildRepositoryMap(repoPath)
+
 	s.logger.WithFields(logrus.Fields{
-		"entry_points":   len(analysis.EntryPoints),
-		"key_dirs":       len(analysis.KeyDirectories),
-		"config_files":   len(analysis.ConfigFiles),
-		"languages":      analysis.Languages,
-		"project_type":   analysis.ProjectType,
+		"entry_points": len(analysis.EntryPoints),
+		"key_dirs":     len(analysis.KeyDirectories),
+		"config_files": len(analysis.ConfigFiles),
+		"languages":    analysis.Languages,
+		"project_type": analysis.ProjectType,
+		"frameworks":   analysis.Frameworks,
+		"modules":      len(analysis.Modules),
+		"conventions":  len(analysis.Conventions),
 	}).Info("Repository analysis complete")
 
 	return analysis, nil
@@ -169,7 +212,7 @@ func (s *AnalyzerService) detectProjectType(analysis *models.RepositoryAnalysis)
 		if strings.Contains(configFile, "package.json") {
 			return "Node.js Application"
 		}
-		if strings.Contains(configFile, "requirements.txt") || strings.Contains(configFile, "Pipfile") {
+		if strings.Contains(configFile, "requirements.txt") || strings.Contains(configFile, "Pipfile") || strings.Contains(configFile, "pyproject.toml") {
 			return "Python Application"
 		}
 		if strings.Contains(configFile, "pom.xml") || strings.Contains(configFile, "build.gradle") {
@@ -204,6 +247,9 @@ func (s *AnalyzerService) detectDependencyManagers(analysis *models.RepositoryAn
 		if strings.Contains(configFile, "Pipfile") {
 			managers = append(managers, "pipenv")
 		}
+		if strings.Contains(configFile, "pyproject.toml") {
+			managers = append(managers, "pyproject")
+		}
 		if strings.Contains(configFile, "pom.xml") {
 			managers = append(managers, "Maven")
 		}
@@ -239,6 +285,111 @@ func (s *AnalyzerService) detectPatterns(analysis *models.RepositoryAnalysis) {
 	}
 }
 
+// detectVerificationCommands derives setup, build, lint and test commands from
+// the manifests found in the repository. Commands run in the manifest directory.
+func (s *AnalyzerService) detectVerificationCommands(repoPath string, analysis *models.RepositoryAnalysis) []models.VerificationCommand {
+	commands := []models.VerificationCommand{}
+
+	for _, configFile := range analysis.ConfigFiles {
+		dir := filepath.Dir(configFile)
+
+		switch filepath.Base(configFile) {
+		case "go.mod":
+			commands = append(commands,
+				models.VerificationCommand{Stage: models.VerificationStageBuild, Command: "go build ./...", Dir: dir},
+				models.VerificationCommand{Stage: models.VerificationStageLint, Command: "go vet ./...", Dir: dir},
+				models.VerificationCommand{Stage: models.VerificationStageTest, Command: "go test ./...", Dir: dir},
+			)
+		case "package.json":
+			commands = append(commands, s.detectNodeCommands(repoPath, dir)...)
+		case "Cargo.toml":
+			commands = append(commands,
+				models.VerificationCommand{Stage: models.VerificationStageBuild, Command: "cargo build", Dir: dir},
+				models.VerificationCommand{Stage: models.VerificationStageTest, Command: "cargo test", Dir: dir},
+			)
+		case "pom.xml":
+			commands = append(commands,
+				models.VerificationCommand{Stage: models.VerificationStageBuild, Command: "mvn -B -q compile", Dir: dir},
+				models.VerificationCommand{Stage: models.VerificationStageTest, Command: "mvn -B -q test", Dir: dir},
+			)
+		case "build.gradle", "build.gradle.kts":
+			gradle := "gradle"
+			if fileExists(filepath.Join(repoPath, dir, "gradlew")) {
+				gradle = "./gradlew"
+			}
+			commands = append(commands,
+				models.VerificationCommand{Stage: models.VerificationStageBuild, Command: gradle + " assemble", Dir: dir},
+				models.VerificationCommand{Stage: models.VerificationStageTest, Command: gradle + " test", Dir: dir},
+			)
+		case "requirements.txt", "Pipfile":
+			if fileExists(filepath.Join(repoPath, dir, "tests")) || fileExists(filepath.Join(repoPath, dir, "pytest.ini")) {
+				commands = append(commands,
+					models.VerificationCommand{Stage: models.VerificationStageTest, Command: "python -m pytest", Dir: dir},
+				)
+			}
+		}
+	}
+
+	return commands
+}
+
+// detectNodeCommands reads package.json scripts and picks the package manager from the lock file
+func (s *AnalyzerService) detectNodeCommands(repoPath, dir string) []models.VerificationCommand {
+	content, err := os.ReadFile(filepath.Join(repoPath, dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+
+	var manifest struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		s.logger.WithError(err).WithField("dir", dir).Warn("Failed to parse package.json")
+		return nil
+	}
+
+	install, run := "npm install", "npm run"
+	switch {
+	case fileExists(filepath.Join(repoPath, dir, "pnpm-lock.yaml")):
+		install, run = "pnpm install --frozen-lockfile", "pnpm run"
+	case fileExists(filepath.Join(repoPath, dir, "yarn.lock")):
+		install, run = "yarn install --frozen-lockfile", "yarn run"
+	case fileExists(filepath.Join(repoPath, dir, "package-lock.json")):
+		install = "npm ci"
+	}
+
+	commands := []models.VerificationCommand{
+		{Stage: models.VerificationStageSetup, Command: install, Dir: dir},
+	}
+
+	stages := []struct {
+		script string
+		stage  string
+	}{
+		{"build", models.VerificationStageBuild},
+		{"lint", models.VerificationStageLint},
+		{"test", models.VerificationStageTest},
+	}
+	for _, st := range stages {
+		script, ok := manifest.Scripts[st.script]
+		if !ok || strings.Contains(script, "no test specified") {
+			continue
+		}
+		commands = append(commands, models.VerificationCommand{
+			Stage:   st.stage,
+			Command: run + " " + st.script,
+			Dir:     dir,
+		})
+	}
+
+	return commands
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
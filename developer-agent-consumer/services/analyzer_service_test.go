@@ -3,9 +3,11 @@ package services
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
 )
 
 func TestAnalyzeRepository_GoProject(t *testing.T) {
@@ -17,7 +19,7 @@ func TestAnalyzeRepository_GoProject(t *testing.T) {
 	logger.SetOutput(os.Stdout)
 
 	service := NewAnalyzerService(logger)
-	analysis, err := service.AnalyzeRepository(tempDir)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
 	if err != nil {
 		t.Fatalf("Failed to analyze repository: %v", err)
 	}
@@ -75,7 +77,7 @@ func TestAnalyzeRepository_NodeProject(t *testing.T) {
 	logger.SetOutput(os.Stdout)
 
 	service := NewAnalyzerService(logger)
-	analysis, err := service.AnalyzeRepository(tempDir)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
 	if err != nil {
 		t.Fatalf("Failed to analyze repository: %v", err)
 	}
@@ -112,7 +114,7 @@ func TestAnalyzeRepository_CleanArchitecture(t *testing.T) {
 	logger.SetOutput(os.Stdout)
 
 	service := NewAnalyzerService(logger)
-	analysis, err := service.AnalyzeRepository(tempDir)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
 	if err != nil {
 		t.Fatalf("Failed to analyze repository: %v", err)
 	}
@@ -142,6 +144,123 @@ func TestAnalyzeRepository_CleanArchitecture(t *testing.T) {
 	}
 }
 
+func TestAnalyzeRepository_VerificationCommands(t *testing.T) {
+	tempDir := createTestGoProject(t)
+	defer os.RemoveAll(tempDir)
+
+	// Add a frontend with npm scripts next to the Go module
+	webDir := filepath.Join(tempDir, "web")
+	os.MkdirAll(webDir, 0755)
+	os.WriteFile(filepath.Join(webDir, "package.json"), []byte(`{"scripts": {"build": "vite build", "test": "vitest run"}}`), 0644)
+	os.WriteFile(filepath.Join(webDir, "package-lock.json"), []byte("{}"), 0644)
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	service := NewAnalyzerService(logger)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+
+	expected := map[string]string{
+		"go build ./...": ".",
+		"go vet ./...":   ".",
+		"go test ./...":  ".",
+		"npm ci":         "web",
+		"npm run build":  "web",
+		"npm run test":   "web",
+	}
+
+	found := map[string]string{}
+	for _, command := range analysis.VerificationCommands {
+		found[command.Command] = command.Dir
+	}
+
+	for command, dir := range expected {
+		if found[command] != dir {
+			t.Errorf("Expected command %q in dir %q, got commands %v", command, dir, analysis.VerificationCommands)
+		}
+	}
+
+	if _, ok := found["npm run lint"]; ok {
+		t.Error("Did not expect a lint command without a lint script")
+	}
+}
+
+func TestAnalyzeRepository_Conventions(t *testing.T) {
+	tempDir := createTestGoProject(t)
+	defer os.RemoveAll(tempDir)
+
+	os.WriteFile(filepath.Join(tempDir, "CONTRIBUTING.md"), []byte("# Contributing\nUse conventional commits.\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "CLAUDE.md"), []byte("Run make lint before committing.\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, ".editorconfig"), []byte("root = true\n"), 0644)
+	os.MkdirAll(filepath.Join(tempDir, "docs", "adr"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "docs", "adr", "0001-use-mongodb.md"), []byte(strings.Repeat("Context line\n", 100)), 0644)
+	os.MkdirAll(filepath.Join(tempDir, "node_modules", "lib"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "node_modules", "lib", "CONTRIBUTING.md"), []byte("ignored\n"), 0644)
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	service := NewAnalyzerService(logger)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+
+	paths := []string{}
+	for _, convention := range analysis.Conventions {
+		paths = append(paths, convention.Path)
+	}
+	expected := []string{"CLAUDE.md", "CONTRIBUTING.md", ".editorconfig", "docs/adr/0001-use-mongodb.md"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected conventions %v, got %v", expected, paths)
+	}
+	if analysis.Conventions[1].Content != "# Contributing\nUse conventional commits.\n" {
+		t.Errorf("Unexpected CONTRIBUTING.md content %q", analysis.Conventions[1].Content)
+	}
+
+	// Custom globs replace the defaults and the file budget truncates at a line break
+	analysis, err = service.AnalyzeRepository(tempDir, &models.ConventionSettings{
+		Globs:        []string{"docs/**/*.md"},
+		MaxFileBytes: 30,
+	})
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+	if len(analysis.Conventions) != 1 || analysis.Conventions[0].Path != "docs/adr/0001-use-mongodb.md" {
+		t.Fatalf("Expected only the ADR, got %v", analysis.Conventions)
+	}
+	if !analysis.Conventions[0].Truncated || analysis.Conventions[0].Content != "Context line\nContext line\n" {
+		t.Errorf("Expected ADR truncated to two lines, got %q", analysis.Conventions[0].Content)
+	}
+}
+
+func TestMatchConventionGlob(t *testing.T) {
+	tests := []struct {
+		glob    string
+		path    string
+		matches bool
+	}{
+		{"CONTRIBUTING.md", "CONTRIBUTING.md", true},
+		{"CONTRIBUTING.md", "services/api/CONTRIBUTING.md", true},
+		{".eslintrc.*", "web/.eslintrc.json", true},
+		{"docs/adr/*.md", "docs/adr/0001.md", true},
+		{"docs/adr/*.md", "api/docs/adr/0001.md", false},
+		{"docs/adr/*.md", "docs/adr/old/0001.md", false},
+		{"**/docs/adr/*.md", "api/docs/adr/0001.md", true},
+		{"docs/**/*.md", "docs/a/b/c.md", true},
+		{"docs/**/*.md", "docs/c.md", true},
+	}
+
+	for _, test := range tests {
+		if got := matchConventionGlob(test.glob, test.path); got != test.matches {
+			t.Errorf("matchConventionGlob(%q, %q) = %v, expected %v", test.glob, test.path, got, test.matches)
+		}
+	}
+}
+
 func createTestGoProject(t *testing.T) string {
 	tempDir, err := os.MkdirTemp("", "test-go-project-*")
 	if err != nil {
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ArtifactService stores files produced while processing a development
+// (e.g. best-of-N candidate diffs) outside of the temporary workspaces
+type ArtifactService struct {
+	baseDir string
+	logger  *logrus.Logger
+}
+
+func NewArtifactService(baseDir string, logger *logrus.Logger) *ArtifactService {
+	if baseDir == "" {
+		baseDir = "/tmp/sdlc-artifacts"
+	}
+	return &ArtifactService{
+		baseDir: baseDir,
+		logger:  logger,
+	}
+}
+
+// Save writes an artifact to <baseDir>/<developmentID>/<name> and returns its path
+func (s *ArtifactService) Save(developmentID, name string, content []byte) (string, error) {
+	dir := filepath.Join(s.baseDir, developmentID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write artifact: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"development_id": developmentID,
+		"path":           path,
+	}).Info("Artifact saved")
+
+	return path, nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+// ErrUnresolvedConflicts is returned when the agent's commits cannot be brought up to date
+// with the base branch because conflicts remain
+var ErrUnresolvedConflicts = errors.New("unresolved conflicts with the base branch")
+
+// SyncResult is the outcome of bringing the issue branch up to date with the base branch
+type SyncResult struct {
+	Updated   bool     // The base branch had moved and was rebased onto or merged
+	Conflicts []string // Files the rebase or merge stopped on; resolve them and call ContinueSync
+}
+
+// SyncWithBase fetches the base branch and, when it moved since the workspace was cloned,
+// rebases the issue branch onto it or merges it in. Commits are rewritten or created with
+// the identity and signature of the options. When git stops on conflicts, the workspace is
+// left mid-rebase or mid-merge with the conflicted files returned.
+func (s *GitService) SyncWithBase(workspace *GitWorkspace, auth GitAuth, baseBranch, strategy string, options CommitOptions) (*SyncResult, error) {
+	env, cleanup, err := auth.env(workspace.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	refSpec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", baseBranch, baseBranch)
+	if err := runGit(workspace.Path, env, "fetch", "--quiet", "origin", refSpec); err != nil {
+		return nil, fmt.Errorf("failed to fetch base branch: %w", err)
+	}
+
+	base := "origin/" + baseBranch
+	if runGit(workspace.Path, nil, "merge-base", "--is-ancestor", base, "HEAD") == nil {
+		s.logger.WithField("base_branch", baseBranch).Info("Branch is up to date with the base branch")
+		return &SyncResult{}, nil
+	}
+
+	// A shallow clone may not reach the commit the branch forked from
+	if s.IsShallow(workspace) {
+		found, err := s.DeepenHistory(workspace, auth, func() bool {
+			return runGit(workspace.Path, nil, "merge-base", base, "HEAD") == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("no common history with the base branch in the shallow clone")
+		}
+	}
+
+	// Rebasing rewrites commits a previous run may have pushed, so the push has to overwrite
+	// them, unless the branch moved on the remote in the meantime
+	if strategy == models.BaseSyncRebase && workspace.LeaseHead == "" {
+		head, err := s.RemoteBranchHead(workspace, auth, workspace.BranchName)
+		if err != nil {
+			return nil, err
+		}
+		workspace.LeaseHead = head
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"base_branch": baseBranch,
+		"strategy":    strategy,
+	}).Info("Base branch moved, updating branch")
+
+	commitEnv, commitCleanup, err := options.env()
+	if err != nil {
+		return nil, err
+	}
+	defer commitCleanup()
+
+	var syncErr error
+	switch strategy {
+	case models.BaseSyncRebase:
+		syncErr = runGit(workspace.Path, append(commitEnv, "GIT_EDITOR=true"), "rebase", "--quiet", base)
+	case models.BaseSyncMerge:
+		syncErr = runGit(workspace.Path, commitEnv, "merge", "--quiet", "--no-edit", "--no-verify", base)
+	default:
+		return nil, fmt.Errorf("unsupported base sync strategy %q", strategy)
+	}
+	if syncErr == nil {
+		return &SyncResult{Updated: true}, nil
+	}
+
+	conflicts, err := s.ConflictedFiles(workspace)
+	if err != nil || len(conflicts) == 0 {
+		workspace.Syncing = strategy
+		s.AbortSync(workspace)
+		return nil, fmt.Errorf("failed to %s onto the base branch: %w", strategy, syncErr)
+	}
+
+	workspace.Syncing = strategy
+	s.logger.WithField("files", conflicts).Warn("Conflicts with the base branch")
+	return &SyncResult{Updated: true, Conflicts: conflicts}, nil
+}
+
+// ContinueSync stages the resolved files and continues the rebase or merge SyncWithBase
+// stopped on. It fails with ErrUnresolvedConflicts when conflict markers remain in the
+// files, and returns the files of the next conflict when a later commit of the rebase
+// conflicts as well.
+func (s *GitService) ContinueSync(workspace *GitWorkspace, options CommitOptions) ([]string, error) {
+	conflicts, err := s.ConflictedFiles(workspace)
+	if err != nil {
+		return nil, err
+	}
+	if unresolved := filesWithConflictMarkers(workspace.Path, conflicts); len(unresolved) > 0 {
+		return nil, fmt.Errorf("%w: conflict markers remain in %s", ErrUnresolvedConflicts, strings.Join(unresolved, ", "))
+	}
+
+	// Only the resolved files and edits to tracked files are staged, so files the agent
+	// or its tools created along the way are not committed
+	if err := runGit(workspace.Path, nil, "add", "--update"); err != nil {
+		return nil, fmt.Errorf("failed to add resolved files: %w", err)
+	}
+	if len(conflicts) > 0 {
+		args := append([]string{"--literal-pathspecs", "add", "--all", "--"}, conflicts...)
+		if err := runGit(workspace.Path, nil, args...); err != nil {
+			return nil, fmt.Errorf("failed to add resolved files: %w", err)
+		}
+	}
+
+	env, cleanup, err := options.env()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	switch workspace.Syncing {
+	case models.BaseSyncRebase:
+		err = runGit(workspace.Path, append(env, "GIT_EDITOR=true"), "rebase", "--continue")
+	case models.BaseSyncMerge:
+		err = runGit(workspace.Path, env, "commit", "--quiet", "--no-edit", "--no-verify")
+	default:
+		return nil, fmt.Errorf("no rebase or merge in progress")
+	}
+	if err != nil {
+		if conflicts, _ := s.ConflictedFiles(workspace); len(conflicts) > 0 {
+			s.logger.WithField("files", conflicts).Warn("Conflicts with the base branch")
+			return conflicts, nil
+		}
+		return nil, fmt.Errorf("failed to continue %s: %w", workspace.Syncing, err)
+	}
+
+	workspace.Syncing = ""
+	s.logger.Info("Resolved conflicts with the base branch")
+	return nil, nil
+}
+
+// AbortSync abandons the rebase or merge in progress and restores the branch as it was
+// before SyncWithBase
+func (s *GitService) AbortSync(workspace *GitWorkspace) error {
+	if workspace.Syncing == "" {
+		return nil
+	}
+	strategy := workspace.Syncing
+	workspace.Syncing = ""
+	if err := runGit(workspace.Path, nil, strategy, "--abort"); err != nil {
+		return fmt.Errorf("failed to abort %s: %w", strategy, err)
+	}
+	return nil
+}
+
+// ConflictedFiles lists the unmerged files of the workspace
+func (s *GitService) ConflictedFiles(workspace *GitWorkspace) ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = workspace.Path
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conflicted files: %w", err)
+	}
+	files := []string{}
+	for _, file := range strings.Split(string(output), "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// filesWithConflictMarkers returns the files still containing conflict markers. Deleted
+// files count as resolved.
+func filesWithConflictMarkers(repoPath string, files []string) []string {
+	unresolved := []string{}
+	for _, file := range files {
+		f, err := os.Open(filepath.Join(repoPath, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+				unresolved = append(unresolved, file)
+				break
+			}
+		}
+		f.Close()
+	}
+	return unresolved
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+// newSyncTest clones the test remote onto an issue branch with one commit changing the
+// README, then pushes a commit to main with the given README
+func newSyncTest(t *testing.T, baseReadme string) (*GitService, *GitWorkspace, string) {
+	t.Helper()
+	remote, work := newTestRemote(t)
+	service := NewGitService(nil, logrus.New())
+
+	workspace, err := service.CloneRepository("file://"+remote, GitAuth{}, "TEST-3", "main", nil)
+	if err != nil {
+		t.Fatalf("Failed to clone repository: %v", err)
+	}
+	t.Cleanup(func() { service.Cleanup(workspace) })
+	if err := service.CreateAndCheckoutBranch(workspace, "feature/TEST-3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace.Path, "README.md"), []byte("# App\n\nAgent\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.CommitChanges(workspace, "Update README", CommitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(work, baseReadme), []byte("# App\n\nHuman\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, work, "add", ".")
+	gitTest(t, work, "commit", "--quiet", "-m", "Change on main")
+	gitTest(t, work, "push", "--quiet", "origin", "HEAD:main")
+
+	return service, workspace, gitTest(t, work, "rev-parse", "HEAD")
+}
+
+func TestGitService_SyncWithBase(t *testing.T) {
+	service, workspace, baseHead := newSyncTest(t, "CHANGELOG.md")
+
+	result, err := service.SyncWithBase(workspace, GitAuth{}, "main", models.BaseSyncRebase, CommitOptions{})
+	if err != nil || !result.Updated || len(result.Conflicts) != 0 {
+		t.Fatalf("Expected a clean rebase, got %+v (%v)", result, err)
+	}
+	if parent := gitTest(t, workspace.Path, "rev-parse", "HEAD^"); parent != baseHead {
+		t.Errorf("Expected the commit to be rebased onto %s, got parent %s", baseHead, parent)
+	}
+
+	result, err = service.SyncWithBase(workspace, GitAuth{}, "main", models.BaseSyncRebase, CommitOptions{})
+	if err != nil || result.Updated {
+		t.Errorf("Expected the branch to be up to date, got %+v (%v)", result, err)
+	}
+	if err := service.PushBranch(workspace, GitAuth{}); err != nil {
+		t.Errorf("Failed to push the rebased branch: %v", err)
+	}
+}
+
+func TestGitService_SyncWithBase_Conflicts(t *testing.T) {
+	service, workspace, baseHead := newSyncTest(t, "README.md")
+
+	result, err := service.SyncWithBase(workspace, GitAuth{}, "main", models.BaseSyncRebase, CommitOptions{})
+	if err != nil || len(result.Conflicts) != 1 || result.Conflicts[0] != "README.md" {
+		t.Fatalf("Expected a conflict in README.md, got %+v (%v)", result, err)
+	}
+
+	if _, err := service.ContinueSync(workspace, CommitOptions{}); !errors.Is(err, ErrUnresolvedConflicts) {
+		t.Fatalf("Expected conflict markers to be rejected, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(workspace.Path, "README.md"), []byte("# App\n\nHuman\nAgent\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Left behind by a tool the agent ran while resolving
+	if err := os.WriteFile(filepath.Join(workspace.Path, "build.log"), []byte("log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conflicts, err := service.ContinueSync(workspace, CommitOptions{})
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("Expected the rebase to complete, got %v (%v)", conflicts, err)
+	}
+	if parent := gitTest(t, workspace.Path, "rev-parse", "HEAD^"); parent != baseHead {
+		t.Errorf("Expected the commit to be rebased onto %s, got parent %s", baseHead, parent)
+	}
+	if message := gitTest(t, workspace.Path, "log", "-1", "--format=%s"); message != "Update README" {
+		t.Errorf("Expected the commit message to be kept, got %q", message)
+	}
+	if files := gitTest(t, workspace.Path, "show", "--name-only", "--format=", "HEAD"); files != "README.md" {
+		t.Errorf("Expected only the resolved file to be committed, got %q", files)
+	}
+}
+
+func TestGitService_SyncWithBase_AbortMerge(t *testing.T) {
+	service, workspace, _ := newSyncTest(t, "README.md")
+	head := gitTest(t, workspace.Path, "rev-parse", "HEAD")
+
+	result, err := service.SyncWithBase(workspace, GitAuth{}, "main", models.BaseSyncMerge, CommitOptions{})
+	if err != nil || len(result.Conflicts) != 1 {
+		t.Fatalf("Expected a merge conflict, got %+v (%v)", result, err)
+	}
+	if err := service.AbortSync(workspace); err != nil {
+		t.Fatalf("Failed to abort merge: %v", err)
+	}
+	if restored := gitTest(t, workspace.Path, "rev-parse", "HEAD"); restored != head || workspace.Syncing != "" {
+		t.Errorf("Expected the branch to be restored to %s, got %s", head, restored)
+	}
+	if status := gitTest(t, workspace.Path, "status", "--porcelain"); status != "" {
+		t.Errorf("Expected a clean workspace, got %q", status)
+	}
+}
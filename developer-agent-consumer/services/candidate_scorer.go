@@ -0,0 +1,104 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+// Score weights for best-of-N candidate ranking. Tests dominate, then build
+// and lint; large diffs are penalized so focused changes win ties.
+const (
+	scoreWeightTests     = 60.0
+	scoreWeightBuild     = 30.0
+	scoreWeightLint      = 10.0
+	scoreDiffLinesPerPt  = 50.0
+	scoreMaxDiffPenalty  = 20.0
+	scoreFailedCandidate = -1.0
+)
+
+// ScoreCandidate scores a candidate from its verification result and diff size.
+// Candidates that errored or produced no changes get a negative score.
+func ScoreCandidate(candidate *models.Candidate) float64 {
+	if candidate.Error != "" || candidate.FilesChanged == 0 {
+		return scoreFailedCandidate
+	}
+
+	score := 0.0
+	if candidate.Verification == nil {
+		// Verification disabled: every stage counts as passed
+		score = scoreWeightTests + scoreWeightBuild + scoreWeightLint
+	} else {
+		score += scoreWeightTests * stagePassRatio(candidate.Verification, models.VerificationStageTest)
+		score += scoreWeightBuild * stagePassRatio(candidate.Verification, models.VerificationStageBuild)
+		score += scoreWeightLint * stagePassRatio(candidate.Verification, models.VerificationStageLint)
+	}
+
+	penalty := float64(candidate.DiffLines) / scoreDiffLinesPerPt
+	if penalty > scoreMaxDiffPenalty {
+		penalty = scoreMaxDiffPenalty
+	}
+
+	return score - penalty
+}
+
+// RankCandidates scores the candidates, assigns ranks (1 = best) and marks the winner
+// as selected. Returns the index into the slice of the selected candidate, or -1 when
+// no candidate is usable.
+func RankCandidates(candidates []models.Candidate) int {
+	order := make([]int, len(candidates))
+	for i := range candidates {
+		candidates[i].Score = ScoreCandidate(&candidates[i])
+		candidates[i].Selected = false
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return candidates[order[a]].Score > candidates[order[b]].Score
+	})
+
+	for rank, i := range order {
+		candidates[i].Rank = rank + 1
+	}
+
+	if len(order) == 0 || candidates[order[0]].Score < 0 {
+		return -1
+	}
+
+	candidates[order[0]].Selected = true
+	return order[0]
+}
+
+// DiffLineCount counts added and removed lines in a unified diff
+func DiffLineCount(diff string) int {
+	count := 0
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			count++
+		}
+	}
+	return count
+}
+
+// stagePassRatio returns the fraction of passed commands of a stage; stages without
+// commands count as passed
+func stagePassRatio(result *models.VerificationResult, stage string) float64 {
+	total, passed := 0, 0
+	for _, step := range result.Steps {
+		if step.Stage != stage {
+			continue
+		}
+		total++
+		if step.Passed {
+			passed++
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(passed) / float64(total)
+}
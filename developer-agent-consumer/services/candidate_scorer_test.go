@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func verificationWith(steps ...models.VerificationStep) *models.VerificationResult {
+	passed := true
+	for _, step := range steps {
+		if !step.Passed {
+			passed = false
+		}
+	}
+	return &models.VerificationResult{Passed: passed, Steps: steps}
+}
+
+func TestRankCandidates_PrefersPassingTests(t *testing.T) {
+	candidates := []models.Candidate{
+		{
+			Index:        1,
+			FilesChanged: 2,
+			DiffLines:    10,
+			Verification: verificationWith(
+				models.VerificationStep{Stage: models.VerificationStageBuild, Passed: true},
+				models.VerificationStep{Stage: models.VerificationStageTest, Passed: false},
+			),
+		},
+		{
+			Index:        2,
+			FilesChanged: 3,
+			DiffLines:    200,
+			Verification: verificationWith(
+				models.VerificationStep{Stage: models.VerificationStageBuild, Passed: true},
+				models.VerificationStep{Stage: models.VerificationStageTest, Passed: true},
+			),
+		},
+	}
+
+	selected := RankCandidates(candidates)
+
+	if selected != 1 {
+		t.Fatalf("Expected candidate 2 to be selected, got index %d", selected)
+	}
+	if !candidates[1].Selected || candidates[1].Rank != 1 {
+		t.Errorf("Unexpected winner: %+v", candidates[1])
+	}
+	if candidates[0].Selected || candidates[0].Rank != 2 {
+		t.Errorf("Unexpected runner-up: %+v", candidates[0])
+	}
+}
+
+func TestRankCandidates_SmallerDiffBreaksTie(t *testing.T) {
+	candidates := []models.Candidate{
+		{Index: 1, FilesChanged: 4, DiffLines: 600},
+		{Index: 2, FilesChanged: 1, DiffLines: 40},
+	}
+
+	if selected := RankCandidates(candidates); selected != 1 {
+		t.Errorf("Expected smaller diff to win, got index %d", selected)
+	}
+}
+
+func TestRankCandidates_NoUsableCandidate(t *testing.T) {
+	candidates := []models.Candidate{
+		{Index: 1, Error: "Claude CLI timed out"},
+		{Index: 2, FilesChanged: 0},
+	}
+
+	if selected := RankCandidates(candidates); selected != -1 {
+		t.Errorf("Expected no selection, got index %d", selected)
+	}
+	for _, candidate := range candidates {
+		if candidate.Selected {
+			t.Errorf("Candidate %d should not be selected", candidate.Index)
+		}
+	}
+}
+
+func TestDiffLineCount(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n package main\n-var a = 1\n+var a = 2\n+var b = 3\n"
+
+	if count := DiffLineCount(diff); count != 3 {
+		t.Errorf("Expected 3 changed lines, got %d", count)
+	}
+}
@@ -2,10 +2,14 @@ package services
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +17,8 @@ import (
 	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
 )
 
+const defaultAgentTimeout = 10 * time.Minute
+
 type ClaudeService struct {
 	claudePath string
 	logger     *logrus.Logger
@@ -29,7 +35,8 @@ func NewClaudeService(claudePath string, logger *logrus.Logger) *ClaudeService {
 	}
 }
 
-// BuildPrompt creates the prompt that will be sent to Claude CLI
+// BuildPrompt creates the default prompt that will be sent to Claude CLI,
+// used when the project has no prompt template
 func (s *ClaudeService) BuildPrompt(
 	request *models.DevelopmentRequest,
 	project *models.Project,
@@ -54,6 +61,71 @@ func (s *ClaudeService) BuildPrompt(
 	if len(analysis.KeyDirectories) > 0 {
 		prompt.WriteString(fmt.Sprintf("- Key Directories: %s\n", strings.Join(analysis.KeyDirectories, ", ")))
 	}
+	if len(analysis.Frameworks) > 0 {
+		prompt.WriteString(fmt.Sprintf("- Frameworks: %s\n", strings.Join(analysis.Frameworks, ", ")))
+	}
+	if len(analysis.TestFrameworks) > 0 {
+		prompt.WriteString(fmt.Sprintf("- Test Frameworks: %s\n", strings.Join(analysis.TestFrameworks, ", ")))
+	}
+	if len(analysis.Manifests) > 0 {
+		manifests := make([]string, 0, len(analysis.Manifests))
+		for _, manifest := range analysis.Manifests {
+			if manifest.LanguageVersion != "" {
+				manifests = append(manifests, fmt.Sprintf("%s (%s %s)", manifest.Path, manifest.Ecosystem, manifest.LanguageVersion))
+			} else {
+				manifests = append(manifests, manifest.Path)
+			}
+		}
+		prompt.WriteString(fmt.Sprintf("- Manifests: %s\n", strings.Join(manifests, ", ")))
+	}
+
+	if len(analysis.Modules) > 0 {
+		prompt.WriteString("\n## Modules\n")
+		prompt.WriteString(fmt.Sprintf("The repository is a monorepo with %d modules:\n", len(analysis.Modules)))
+		for _, module := range analysis.Modules {
+			line := fmt.Sprintf("- `%s` (%s): %s", module.Path, module.Name, module.ProjectType)
+			if len(module.Frameworks) > 0 {
+				line += ", " + strings.Join(module.Frameworks, ", ")
+			}
+			prompt.WriteString(line + "\n")
+		}
+		if len(analysis.TargetModules) > 0 {
+			prompt.WriteString(fmt.Sprintf("\nThe issue most likely concerns: %s. Keep your changes within these modules unless the task requires otherwise.\n",
+				strings.Join(analysis.TargetModules, ", ")))
+		}
+	}
+
+	if len(analysis.RelevantFiles) > 0 {
+		prompt.WriteString("\n## Relevant Files\n")
+		prompt.WriteString("These files match the issue best and are a good place to start:\n")
+		for _, file := range analysis.RelevantFiles {
+			line := fmt.Sprintf("- `%s`", file.Path)
+			if len(file.Symbols) > 0 {
+				line += ": " + strings.Join(file.Symbols, "; ")
+			}
+			prompt.WriteString(line + "\n")
+			if file.Excerpt != "" {
+				fence := codeFence(file.Excerpt)
+				prompt.WriteString(fmt.Sprintf("  Lines %d-%d:\n", file.StartLine, file.StartLine+strings.Count(file.Excerpt, "\n")))
+				prompt.WriteString(fence + "\n" + file.Excerpt + "\n" + fence + "\n")
+			}
+		}
+	}
+
+	if len(analysis.Conventions) > 0 {
+		prompt.WriteString("\n## Repository Conventions\n")
+		prompt.WriteString("The repository documents the following conventions. Follow them in your changes.\n")
+		for _, convention := range analysis.Conventions {
+			fence := codeFence(convention.Content)
+			prompt.WriteString(fmt.Sprintf("\n### %s\n", convention.Path))
+			prompt.WriteString(fence + "\n")
+			prompt.WriteString(strings.TrimRight(convention.Content, "\n"))
+			prompt.WriteString("\n" + fence + "\n")
+			if convention.Truncated {
+				prompt.WriteString("(truncated, read the file for the full content)\n")
+			}
+		}
+	}
 
 	prompt.WriteString("\n## Instructions\n")
 	prompt.WriteString("Please implement the changes described above in the repository.\n")
@@ -66,23 +138,333 @@ func (s *ClaudeService) BuildPrompt(
 	return prompt.String()
 }
 
+// codeFence returns a backtick fence longer than any backtick run in the content,
+// so Markdown documents with their own code blocks can be quoted verbatim
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// GenerateCode runs Claude CLI with the given prompt in the repository
 func (s *ClaudeService) GenerateCode(
 	request *models.DevelopmentRequest,
-	project *models.Project,
-	analysis *models.RepositoryAnalysis,
+	prompt string,
 	repoPath string,
+	profile *models.AgentProfile,
 ) (*models.ClaudeCodeResponse, error) {
-	// Build the prompt
-	prompt := s.BuildPrompt(request, project, analysis)
+	return s.runCLI(request.JiraIssueKey, repoPath, prompt, profile)
+}
+
+// BuildPlanPrompt turns the task prompt into a prompt asking Claude for an implementation plan only.
+// When a previous plan was rejected, it is included together with the reviewer feedback.
+func (s *ClaudeService) BuildPlanPrompt(
+	request *models.DevelopmentRequest,
+	taskPrompt string,
+	previous *models.Plan,
+) string {
+	var prompt strings.Builder
+
+	prompt.WriteString(strings.Replace(
+		taskPrompt,
+		fmt.Sprintf("# Development Task: %s", request.JiraIssueKey),
+		fmt.Sprintf("# Implementation Plan: %s", request.JiraIssueKey),
+		1,
+	))
+
+	if previous != nil {
+		prompt.WriteString("\n## Previous Plan (rejected)\n")
+		prompt.WriteString(previous.Content)
+		prompt.WriteString("\n\n## Reviewer Feedback\n")
+		prompt.WriteString(previous.Feedback)
+		prompt.WriteString("\n")
+	}
+
+	prompt.WriteString("\n## Planning Instructions\n")
+	prompt.WriteString("Do NOT modify any files. Explore the repository and reply with an implementation plan only.\n")
+	prompt.WriteString("The plan must contain:\n")
+	prompt.WriteString("1. Files to touch: every file to create, modify or delete, with a short reason\n")
+	prompt.WriteString("2. Approach: the changes you intend to make, step by step\n")
+	prompt.WriteString("3. Risks: anything that could break, open questions and assumptions\n")
+	if previous != nil {
+		prompt.WriteString("Address the reviewer feedback in the new plan.\n")
+	}
+
+	return prompt.String()
+}
+
+// GeneratePlan runs Claude CLI in plan permission mode and returns the plan text
+func (s *ClaudeService) GeneratePlan(
+	request *models.DevelopmentRequest,
+	prompt string,
+	repoPath string,
+	profile *models.AgentProfile,
+) (string, error) {
+	planProfile := models.AgentProfile{}
+	if profile != nil {
+		planProfile = *profile
+	}
+	planProfile.PermissionMode = "plan"
 
+	response, err := s.runCLI(request.JiraIssueKey, repoPath, prompt, &planProfile)
+	if err != nil {
+		return "", err
+	}
+
+	plan := strings.TrimSpace(response.Output)
+	if plan == "" {
+		return "", fmt.Errorf("Claude CLI returned an empty plan")
+	}
+
+	return plan, nil
+}
+
+// readinessJudgmentPattern finds the JSON verdict in the agent output
+var readinessJudgmentPattern = regexp.MustCompile(`(?s)\{[^{}]*"score"[^{}]*\}`)
+
+// ReadinessJudgment is the agent's verdict on whether a ticket is ready for implementation
+type ReadinessJudgment struct {
+	Score   int      `json:"score"`   // 0-100
+	Missing []string `json:"missing"` // Information the ticket lacks
+}
+
+// JudgeReadiness asks Claude, in plan permission mode, whether the ticket has enough
+// information to be implemented in this repository
+func (s *ClaudeService) JudgeReadiness(
+	request *models.DevelopmentRequest,
+	repoPath string,
+	profile *models.AgentProfile,
+) (*ReadinessJudgment, error) {
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("# Ticket Readiness Review: %s\n\n", request.JiraIssueKey))
+	prompt.WriteString(fmt.Sprintf("## Summary\n%s\n\n", request.Summary))
+	prompt.WriteString(fmt.Sprintf("## Description\n%s\n\n", request.Description))
+	prompt.WriteString("## Instructions\n")
+	prompt.WriteString("Do NOT modify any files. Judge whether this ticket contains enough information to be implemented in this repository without guessing.\n")
+	prompt.WriteString("Reply with a single JSON object and nothing else: {\"score\": <0-100>, \"missing\": [\"<missing information>\", ...]}\n")
+
+	judgeProfile := models.AgentProfile{}
+	if profile != nil {
+		judgeProfile = *profile
+	}
+	judgeProfile.PermissionMode = "plan"
+
+	response, err := s.runCLI(request.JiraIssueKey, repoPath, prompt.String(), &judgeProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseReadinessJudgment(response.Output)
+}
+
+func parseReadinessJudgment(output string) (*ReadinessJudgment, error) {
+	matches := readinessJudgmentPattern.FindAllString(output, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no readiness verdict in Claude output")
+	}
+
+	var judgment ReadinessJudgment
+	if err := json.Unmarshal([]byte(matches[len(matches)-1]), &judgment); err != nil {
+		return nil, fmt.Errorf("failed to parse readiness verdict: %w", err)
+	}
+	if judgment.Score < 0 || judgment.Score > 100 {
+		return nil, fmt.Errorf("readiness score %d out of range", judgment.Score)
+	}
+
+	return &judgment, nil
+}
+
+// QuestionFile is where the agent writes a clarifying question instead of implementing an ambiguous task
+const QuestionFile = ".sdlc-question.json"
+
+// WithClarifications appends the answered clarifying questions to a development prompt. When
+// allowQuestion is set, it also explains how the agent can ask a question instead of guessing.
+func WithClarifications(prompt string, questions []models.Question, allowQuestion bool) string {
+	var result strings.Builder
+	result.WriteString(prompt)
+
+	answered := false
+	for _, question := range questions {
+		if question.Answer == "" {
+			continue
+		}
+		if !answered {
+			result.WriteString("\n## Clarifications\n")
+			answered = true
+		}
+		result.WriteString(fmt.Sprintf("\nQ: %s\nA: %s\n", question.Question, question.Answer))
+	}
+
+	if allowQuestion {
+		result.WriteString("\n## Clarifying Questions\n")
+		result.WriteString("If the task is too ambiguous to implement correctly, do not guess and do not change any code. ")
+		result.WriteString(fmt.Sprintf("Instead write a JSON file named `%s` in the repository root with the fields ", QuestionFile))
+		result.WriteString("`question` (a single, specific question for the reporter) and `context` (what you found and why it matters), then stop.\n")
+	}
+
+	return result.String()
+}
+
+// TakeQuestion reads and removes the question file written by the agent.
+// Returns nil when the agent did not ask a question.
+func (s *ClaudeService) TakeQuestion(repoPath string) (*models.AgentQuestion, error) {
+	path := filepath.Join(repoPath, QuestionFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read question file: %w", err)
+	}
+	os.Remove(path)
+
+	var question models.AgentQuestion
+	if err := json.Unmarshal(data, &question); err != nil {
+		return nil, fmt.Errorf("failed to parse question file: %w", err)
+	}
+	if strings.TrimSpace(question.Question) == "" {
+		return nil, fmt.Errorf("question file does not contain a question")
+	}
+
+	return &question, nil
+}
+
+// WithApprovedPlan appends an approved implementation plan to a development prompt
+func WithApprovedPlan(prompt string, plan *models.Plan) string {
+	if plan == nil || plan.Status != models.PlanStatusApproved {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n## Approved Implementation Plan\nFollow this plan, which was reviewed and approved:\n\n%s\n", prompt, plan.Content)
+}
+
+// maxFixPromptDiff limits how much of the current diff goes into a fix prompt
+const maxFixPromptDiff = 20 * 1024
+
+// BuildFixPrompt creates the prompt used to ask Claude to repair failed verification
+func (s *ClaudeService) BuildFixPrompt(
+	request *models.DevelopmentRequest,
+	verification *models.VerificationResult,
+	diff string,
+) string {
+	var prompt strings.Builder
+
+	prompt.WriteString(fmt.Sprintf("# Fix Verification Failures: %s\n\n", request.JiraIssueKey))
+	prompt.WriteString("The changes made for the task below do not pass the repository's build, lint or test commands.\n\n")
+	prompt.WriteString(fmt.Sprintf("## Task Summary\n%s\n\n", request.Summary))
+	prompt.WriteString(fmt.Sprintf("## Task Description\n%s\n\n", request.Description))
+
+	prompt.WriteString("## Failed Commands\n")
+	for _, step := range verification.Steps {
+		if step.Passed || step.Skipped {
+			continue
+		}
+		prompt.WriteString(fmt.Sprintf("\n### %s: `%s` (directory `%s`, exit code %d)\n", step.Stage, step.Command, step.Dir, step.ExitCode))
+		if step.TimedOut {
+			prompt.WriteString("The command timed out.\n")
+		}
+		prompt.WriteString(fmt.Sprintf("```\n%s\n```\n", step.Output))
+	}
+
+	if diff != "" {
+		prompt.WriteString("\n## Current Changes\n")
+		prompt.WriteString(fmt.Sprintf("```diff\n%s\n```\n", tailOutput(diff, maxFixPromptDiff)))
+	}
+
+	prompt.WriteString("\n## Instructions\n")
+	prompt.WriteString("Fix the code so that the failed commands pass.\n")
+	prompt.WriteString("Make sure to:\n")
+	prompt.WriteString("1. Keep the changes required by the task\n")
+	prompt.WriteString("2. Do not delete, skip or weaken existing tests to make them pass\n")
+	prompt.WriteString("3. Follow existing code patterns and conventions\n")
+
+	return prompt.String()
+}
+
+// FixCode re-invokes Claude with the verification failures and the current diff
+func (s *ClaudeService) FixCode(
+	request *models.DevelopmentRequest,
+	repoPath string,
+	verification *models.VerificationResult,
+	diff string,
+	profile *models.AgentProfile,
+) (*models.ClaudeCodeResponse, error) {
+	prompt := s.BuildFixPrompt(request, verification, diff)
+
+	return s.runCLI(request.JiraIssueKey, repoPath, prompt, profile)
+}
+
+// BuildConflictPrompt creates the prompt used to ask Claude to resolve conflicts with
+// changes pushed to the base branch while the task was worked on
+func (s *ClaudeService) BuildConflictPrompt(
+	request *models.DevelopmentRequest,
+	baseBranch string,
+	files []string,
+) string {
+	var prompt strings.Builder
+
+	prompt.WriteString(fmt.Sprintf("# Resolve Merge Conflicts: %s\n\n", request.JiraIssueKey))
+	prompt.WriteString(fmt.Sprintf("The changes made for the task below conflict with changes pushed to the base branch `%s` in the meantime. ", baseBranch))
+	prompt.WriteString("Git stopped with conflict markers in the files listed below.\n\n")
+	prompt.WriteString(fmt.Sprintf("## Task Summary\n%s\n\n", request.Summary))
+	prompt.WriteString(fmt.Sprintf("## Task Description\n%s\n\n", request.Description))
+
+	prompt.WriteString("## Conflicted Files\n")
+	for _, file := range files {
+		prompt.WriteString(fmt.Sprintf("- `%s`\n", file))
+	}
+
+	prompt.WriteString("\n## Instructions\n")
+	prompt.WriteString("Resolve the conflicts in these files.\n")
+	prompt.WriteString("Make sure to:\n")
+	prompt.WriteString("1. Keep the changes required by the task and the changes from the base branch\n")
+	prompt.WriteString("2. Remove every conflict marker (`<<<<<<<`, `=======`, `>>>>>>>`)\n")
+	prompt.WriteString("3. Adapt the task's changes to the new code of the base branch where they no longer fit\n")
+	prompt.WriteString("4. Do not run git commands: the resolved files are staged and the rebase or merge is continued for you\n")
+
+	return prompt.String()
+}
+
+// ResolveConflicts invokes Claude to resolve the conflicts git stopped on in the workspace
+func (s *ClaudeService) ResolveConflicts(
+	request *models.DevelopmentRequest,
+	repoPath string,
+	baseBranch string,
+	files []string,
+	profile *models.AgentProfile,
+) (*models.ClaudeCodeResponse, error) {
+	prompt := s.BuildConflictPrompt(request, baseBranch, files)
+
+	return s.runCLI(request.JiraIssueKey, repoPath, prompt, profile)
+}
+
+// runCLI executes Claude CLI in a detached screen session with the options of the given profile
+func (s *ClaudeService) runCLI(
+	jiraIssueKey string,
+	repoPath string,
+	prompt string,
+	profile *models.AgentProfile,
+) (*models.ClaudeCodeResponse, error) {
 	s.logger.WithFields(logrus.Fields{
-		"jira_issue_key": request.JiraIssueKey,
+		"jira_issue_key": jiraIssueKey,
 		"prompt_length":  len(prompt),
 		"repo_path":      repoPath,
+		"agent_profile":  profileName(profile),
 	}).Info("Calling Claude Code CLI with screen")
 
-	// Create unique session name and log file
-	sessionName := fmt.Sprintf("claude-%s", request.JiraIssueKey)
+	// Session name follows the workspace directory so parallel runs for the same issue don't collide
+	sessionName := fmt.Sprintf("claude-%s", filepath.Base(filepath.Dir(repoPath)))
 	logFile := filepath.Join(repoPath, ".claude-output.log")
 	doneFile := filepath.Join(repoPath, ".claude-done")
 
@@ -90,18 +472,30 @@ func (s *ClaudeService) GenerateCode(
 	os.Remove(logFile)
 	os.Remove(doneFile)
 
-	// Escape single quotes in prompt for bash
-	escapedPrompt := strings.ReplaceAll(prompt, "'", "'\\''")
+	// MCP configuration is written next to the repository so it never gets committed
+	mcpConfigPath := ""
+	if profile != nil && profile.MCPConfig != "" {
+		mcpConfigPath = filepath.Join(filepath.Dir(repoPath), "mcp-config.json")
+		if err := os.WriteFile(mcpConfigPath, []byte(profile.MCPConfig), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write MCP config: %w", err)
+		}
+		defer os.Remove(mcpConfigPath)
+	}
+
+	args := buildCLIArgs(repoPath, prompt, mcpConfigPath, profile)
+	quotedArgs := make([]string, len(args))
+	for i, arg := range args {
+		quotedArgs[i] = shellQuote(arg)
+	}
 
 	// Create bash command that runs Claude and signals completion
 	bashCmd := fmt.Sprintf(
-		"cd '%s' && '%s' --add-dir '%s' --permission-mode acceptEdits '%s' > '%s' 2>&1; echo $? > '%s'",
-		repoPath,
-		s.claudePath,
-		repoPath,
-		escapedPrompt,
-		logFile,
-		doneFile,
+		"cd %s && %s %s > %s 2>&1; echo $? > %s",
+		shellQuote(repoPath),
+		shellQuote(s.claudePath),
+		strings.Join(quotedArgs, " "),
+		shellQuote(logFile),
+		shellQuote(doneFile),
 	)
 
 	s.logger.WithFields(logrus.Fields{
@@ -111,14 +505,19 @@ func (s *ClaudeService) GenerateCode(
 
 	// Start screen session in detached mode with logging
 	cmd := exec.Command("screen", "-dmS", sessionName, "bash", "-c", bashCmd)
+	cmd.Env = append(os.Environ(), profileEnv(profile)...)
 	if err := cmd.Run(); err != nil {
 		return nil, fmt.Errorf("failed to start screen session: %w", err)
 	}
 
 	s.logger.Info("Screen session started, waiting for Claude to complete...")
 
-	// Wait for completion with timeout (10 minutes max)
-	timeout := time.After(10 * time.Minute)
+	// Wait for completion with timeout (profile timeout, 10 minutes by default)
+	timeoutDuration := defaultAgentTimeout
+	if profile != nil && profile.TimeoutSeconds > 0 {
+		timeoutDuration = time.Duration(profile.TimeoutSeconds) * time.Second
+	}
+	timeout := time.After(timeoutDuration)
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -127,7 +526,7 @@ func (s *ClaudeService) GenerateCode(
 		case <-timeout:
 			// Kill the screen session on timeout
 			exec.Command("screen", "-S", sessionName, "-X", "quit").Run()
-			return nil, fmt.Errorf("Claude CLI timed out after 10 minutes")
+			return nil, fmt.Errorf("Claude CLI timed out after %s", timeoutDuration)
 
 		case <-ticker.C:
 			// Check if done file exists
@@ -173,7 +572,7 @@ completed:
 	}
 
 	s.logger.WithFields(logrus.Fields{
-		"jira_issue_key": request.JiraIssueKey,
+		"jira_issue_key": jiraIssueKey,
 		"output_length":  len(outputStr),
 	}).Info("Claude CLI completed successfully via screen")
 
@@ -189,6 +588,7 @@ completed:
 		Message:            "Code generated successfully via Claude CLI in screen session",
 		FilesChanged:       filesChanged,
 		DevelopmentDetails: fmt.Sprintf("Generated code using Claude CLI.\n\nClaude Output:\n%s", outputStr),
+		Output:             outputStr,
 	}, nil
 }
 
@@ -210,3 +610,74 @@ func (s *ClaudeService) countChangedFiles(repoPath string) int {
 	}
 	return len(lines)
 }
+
+// buildCLIArgs translates an agent profile into Claude CLI arguments.
+// A nil profile yields the historical defaults (acceptEdits, repository dir only).
+func buildCLIArgs(repoPath, prompt, mcpConfigPath string, profile *models.AgentProfile) []string {
+	permissionMode := "acceptEdits"
+	addDirs := []string{repoPath}
+
+	var args []string
+	if profile != nil {
+		if profile.PermissionMode != "" {
+			permissionMode = profile.PermissionMode
+		}
+		addDirs = append(addDirs, profile.AddDirs...)
+
+		if profile.Model != "" {
+			args = append(args, "--model", profile.Model)
+		}
+		if profile.MaxTurns > 0 {
+			args = append(args, "--max-turns", strconv.Itoa(profile.MaxTurns))
+		}
+		if len(profile.AllowedTools) > 0 {
+			args = append(args, "--allowedTools", strings.Join(profile.AllowedTools, ","))
+		}
+		if len(profile.DisallowedTools) > 0 {
+			args = append(args, "--disallowedTools", strings.Join(profile.DisallowedTools, ","))
+		}
+	}
+
+	if mcpConfigPath != "" {
+		args = append(args, "--mcp-config", mcpConfigPath)
+	}
+
+	args = append(args, "--add-dir")
+	args = append(args, addDirs...)
+
+	// Keep a single-value flag right before the prompt so variadic flags don't consume it
+	args = append(args, "--permission-mode", permissionMode, prompt)
+
+	return args
+}
+
+// profileEnv returns the profile environment variables in KEY=VALUE form, sorted by key
+func profileEnv(profile *models.AgentProfile) []string {
+	if profile == nil || len(profile.Env) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(profile.Env))
+	for key := range profile.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", key, profile.Env[key]))
+	}
+	return env
+}
+
+func profileName(profile *models.AgentProfile) string {
+	if profile == nil {
+		return "default"
+	}
+	return profile.Name
+}
+
+// shellQuote wraps a value in single quotes for bash
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "'\\''") + "'"
+}
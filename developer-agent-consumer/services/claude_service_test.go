@@ -0,0 +1,206 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestBuildCLIArgs_DefaultProfile(t *testing.T) {
+	args := buildCLIArgs("/tmp/repo", "do it", "", nil)
+
+	expected := []string{"--add-dir", "/tmp/repo", "--permission-mode", "acceptEdits", "do it"}
+	if strings.Join(args, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected args %v, got %v", expected, args)
+	}
+}
+
+func TestBuildCLIArgs_WithProfile(t *testing.T) {
+	profile := &models.AgentProfile{
+		Model:           "claude-sonnet",
+		MaxTurns:        25,
+		PermissionMode:  "plan",
+		AllowedTools:    []string{"Read", "Bash(go test:*)"},
+		DisallowedTools: []string{"WebFetch"},
+		AddDirs:         []string{"/shared/docs"},
+	}
+
+	args := buildCLIArgs("/tmp/repo", "do it", "/tmp/mcp.json", profile)
+	joined := strings.Join(args, "|")
+
+	expectedParts := []string{
+		"--model|claude-sonnet",
+		"--max-turns|25",
+		"--allowedTools|Read,Bash(go test:*)",
+		"--disallowedTools|WebFetch",
+		"--mcp-config|/tmp/mcp.json",
+		"--add-dir|/tmp/repo|/shared/docs",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(joined, part) {
+			t.Errorf("Expected args to contain %q, got %v", part, args)
+		}
+	}
+
+	// Prompt must come last, right after the permission mode
+	if !strings.HasSuffix(joined, "--permission-mode|plan|do it") {
+		t.Errorf("Expected args to end with permission mode and prompt, got %v", args)
+	}
+}
+
+func TestProfileEnv(t *testing.T) {
+	profile := &models.AgentProfile{
+		Env: map[string]string{"B_VAR": "2", "A_VAR": "1"},
+	}
+
+	env := profileEnv(profile)
+	if len(env) != 2 || env[0] != "A_VAR=1" || env[1] != "B_VAR=2" {
+		t.Errorf("Unexpected env: %v", env)
+	}
+
+	if profileEnv(nil) != nil {
+		t.Error("Expected nil env for nil profile")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote("it's"); got != `'it'\''s'` {
+		t.Errorf("Unexpected quoting: %s", got)
+	}
+}
+
+func TestBuildFixPrompt(t *testing.T) {
+	service := NewClaudeService("claude", logrus.New())
+	request := &models.DevelopmentRequest{JiraIssueKey: "PROJ-1", Summary: "Add endpoint"}
+	verification := &models.VerificationResult{
+		Steps: []models.VerificationStep{
+			{Stage: "build", Command: "go build ./...", Dir: ".", Passed: true},
+			{Stage: "test", Command: "go test ./...", Dir: ".", ExitCode: 1, Output: "--- FAIL: TestHandler"},
+		},
+	}
+
+	prompt := service.BuildFixPrompt(request, verification, "+func Handler() {}")
+
+	for _, part := range []string{"PROJ-1", "go test ./...", "--- FAIL: TestHandler", "+func Handler() {}"} {
+		if !strings.Contains(prompt, part) {
+			t.Errorf("Expected fix prompt to contain %q", part)
+		}
+	}
+	if strings.Contains(prompt, "`go build ./...`") {
+		t.Error("Did not expect passed commands in fix prompt")
+	}
+}
+
+func TestBuildConflictPrompt(t *testing.T) {
+	service := NewClaudeService("claude", logrus.New())
+	request := &models.DevelopmentRequest{JiraIssueKey: "PROJ-1", Summary: "Add endpoint"}
+
+	prompt := service.BuildConflictPrompt(request, "develop", []string{"handlers/user.go", "go.mod"})
+
+	for _, part := range []string{"# Resolve Merge Conflicts: PROJ-1", "base branch `develop`", "- `handlers/user.go`\n- `go.mod`\n", "Do not run git commands"} {
+		if !strings.Contains(prompt, part) {
+			t.Errorf("Expected conflict prompt to contain %q", part)
+		}
+	}
+}
+
+func TestBuildPlanPrompt_IncludesRejectedPlanFeedback(t *testing.T) {
+	service := NewClaudeService("claude", logrus.New())
+	request := &models.DevelopmentRequest{JiraIssueKey: "PROJ-2", Summary: "Add caching"}
+	project := &models.Project{}
+	analysis := &models.RepositoryAnalysis{ProjectType: "go"}
+	previous := &models.Plan{Revision: 1, Content: "Use an in-memory map", Status: models.PlanStatusRejected, Feedback: "Use Redis instead"}
+
+	prompt := service.BuildPlanPrompt(request, service.BuildPrompt(request, project, analysis), previous)
+
+	for _, part := range []string{"# Implementation Plan: PROJ-2", "Do NOT modify any files", "Use an in-memory map", "Use Redis instead"} {
+		if !strings.Contains(prompt, part) {
+			t.Errorf("Expected plan prompt to contain %q", part)
+		}
+	}
+}
+
+func TestBuildPrompt_IncludesConventions(t *testing.T) {
+	service := NewClaudeService("claude", logrus.New())
+	request := &models.DevelopmentRequest{JiraIssueKey: "PROJ-3", Summary: "Add endpoint"}
+	analysis := &models.RepositoryAnalysis{
+		ProjectType: "go",
+		Conventions: []models.ConventionFile{
+			{Path: "CONTRIBUTING.md", Content: "Run tests with:\n```\nmake test\n```\n", Truncated: true},
+		},
+	}
+
+	prompt := service.BuildPrompt(request, &models.Project{}, analysis)
+
+	for _, part := range []string{"## Repository Conventions", "### CONTRIBUTING.md\n````\nRun tests with:", "make test\n```\n````\n", "(truncated"} {
+		if !strings.Contains(prompt, part) {
+			t.Errorf("Expected prompt to contain %q, got:\n%s", part, prompt)
+		}
+	}
+}
+
+func TestWithApprovedPlan(t *testing.T) {
+	pending := &models.Plan{Content: "Touch handlers.go", Status: models.PlanStatusPending}
+	if got := WithApprovedPlan("prompt", pending); got != "prompt" {
+		t.Errorf("Expected pending plan to be ignored, got %q", got)
+	}
+
+	approved := &models.Plan{Content: "Touch handlers.go", Status: models.PlanStatusApproved}
+	if got := WithApprovedPlan("prompt", approved); !strings.Contains(got, "Approved Implementation Plan") || !strings.Contains(got, "Touch handlers.go") {
+		t.Errorf("Expected approved plan in prompt, got %q", got)
+	}
+}
+
+func TestWithClarifications(t *testing.T) {
+	questions := []models.Question{
+		{Question: "Which currency?", Answer: "EUR only"},
+		{Question: "Unanswered?"},
+	}
+
+	prompt := WithClarifications("prompt", questions, false)
+	if !strings.Contains(prompt, "Q: Which currency?\nA: EUR only") {
+		t.Errorf("Expected answered question in prompt, got %q", prompt)
+	}
+	if strings.Contains(prompt, "Unanswered?") || strings.Contains(prompt, QuestionFile) {
+		t.Errorf("Did not expect unanswered question or question instructions, got %q", prompt)
+	}
+
+	if prompt := WithClarifications("prompt", nil, true); !strings.Contains(prompt, QuestionFile) {
+		t.Errorf("Expected question instructions, got %q", prompt)
+	}
+}
+
+func TestTakeQuestion(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-question-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	service := NewClaudeService("claude", logrus.New())
+
+	question, err := service.TakeQuestion(tempDir)
+	if err != nil || question != nil {
+		t.Fatalf("Expected no question, got %+v, %v", question, err)
+	}
+
+	path := filepath.Join(tempDir, QuestionFile)
+	if err := os.WriteFile(path, []byte(`{"question": "Which currency?", "context": "Prices are stored as integers"}`), 0644); err != nil {
+		t.Fatalf("Failed to write question file: %v", err)
+	}
+
+	question, err = service.TakeQuestion(tempDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if question == nil || question.Question != "Which currency?" || question.Context != "Prices are stored as integers" {
+		t.Errorf("Unexpected question: %+v", question)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected question file to be removed")
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+// otherCommitGroup collects the files matching no group when no group is without globs
+const otherCommitGroup = "other"
+
+// DefaultCommitGroups are committed in an order where every commit builds on its own:
+// schema changes first, then the implementation, the tests exercising it and the docs
+var DefaultCommitGroups = []models.CommitGroup{
+	{Name: "migrations", Globs: []string{"**/migrations/**", "**/migrate/**", "*.sql"}},
+	{Name: "implementation"},
+	{Name: "tests", Globs: []string{
+		"*_test.go", "*.test.*", "*.spec.*", "test_*.py", "*_test.py", "*Test.java", "*Tests.java",
+		"**/test/**", "**/tests/**", "**/__tests__/**", "**/testdata/**",
+	}},
+	{Name: "docs", Globs: []string{"*.md", "*.rst", "*.adoc", "**/docs/**", "**/doc/**"}},
+}
+
+// FileGroup is a group of changed files with the files that belong to it
+type FileGroup struct {
+	Name  string
+	Files []string
+}
+
+// GroupFiles assigns changed files (slash-separated paths relative to the repository root)
+// to the commit groups, or the default groups when none are given. Groups without files
+// are left out.
+func GroupFiles(files []string, groups []models.CommitGroup) []FileGroup {
+	if len(groups) == 0 {
+		groups = DefaultCommitGroups
+	}
+
+	fallback := -1
+	for i, group := range groups {
+		if len(group.Globs) == 0 {
+			fallback = i
+			break
+		}
+	}
+
+	assigned := make([][]string, len(groups))
+	other := []string{}
+	for _, file := range files {
+		index := fallback
+		for i, group := range groups {
+			if matchesAnyGlob(group.Globs, file) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			other = append(other, file)
+			continue
+		}
+		assigned[index] = append(assigned[index], file)
+	}
+
+	result := []FileGroup{}
+	for i, group := range groups {
+		if len(assigned[i]) > 0 {
+			result = append(result, FileGroup{Name: group.Name, Files: assigned[i]})
+		}
+	}
+	if len(other) > 0 {
+		result = append(result, FileGroup{Name: otherCommitGroup, Files: other})
+	}
+	return result
+}
+
+func matchesAnyGlob(globs []string, file string) bool {
+	for _, glob := range globs {
+		if matchConventionGlob(glob, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupChanges assigns changed files to the commit groups like GroupFiles, by their new
+// path. The old path of a renamed file goes into the same group, so the rename is
+// committed as a whole.
+func GroupChanges(changes []FileChange, groups []models.CommitGroup) []FileGroup {
+	files := make([]string, 0, len(changes))
+	oldPaths := map[string]string{}
+	for _, change := range changes {
+		files = append(files, change.Path)
+		if change.OldPath != "" {
+			oldPaths[change.Path] = change.OldPath
+		}
+	}
+
+	result := GroupFiles(files, groups)
+	for i := range result {
+		for _, file := range result[i].Files {
+			if oldPath, ok := oldPaths[file]; ok {
+				result[i].Files = append(result[i].Files, oldPath)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestGroupFiles(t *testing.T) {
+	files := []string{
+		"README.md",
+		"handlers/user.go",
+		"handlers/user_test.go",
+		"db/migrations/002_add_users.sql",
+		"web/src/__tests__/App.tsx",
+		"web/src/App.tsx",
+		"docs/api/users.yaml",
+	}
+
+	groups := GroupFiles(files, nil)
+	expected := []FileGroup{
+		{Name: "migrations", Files: []string{"db/migrations/002_add_users.sql"}},
+		{Name: "implementation", Files: []string{"handlers/user.go", "web/src/App.tsx"}},
+		{Name: "tests", Files: []string{"handlers/user_test.go", "web/src/__tests__/App.tsx"}},
+		{Name: "docs", Files: []string{"README.md", "docs/api/users.yaml"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Unexpected default groups:\n%+v\nexpected:\n%+v", groups, expected)
+	}
+
+	groups = GroupFiles(files, []models.CommitGroup{
+		{Name: "frontend", Globs: []string{"web/**"}},
+		{Name: "backend", Globs: []string{"handlers/**", "db/**"}},
+	})
+	expected = []FileGroup{
+		{Name: "frontend", Files: []string{"web/src/__tests__/App.tsx", "web/src/App.tsx"}},
+		{Name: "backend", Files: []string{"handlers/user.go", "handlers/user_test.go", "db/migrations/002_add_users.sql"}},
+		{Name: "other", Files: []string{"README.md", "docs/api/users.yaml"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Unexpected custom groups:\n%+v\nexpected:\n%+v", groups, expected)
+	}
+}
+
+func TestGitService_CommitFiles(t *testing.T) {
+	_, work := newTestRemote(t)
+	workspace := &GitWorkspace{Path: work}
+	service := NewGitService(nil, logrus.New())
+	base := gitTest(t, work, "rev-parse", "HEAD")
+
+	for _, file := range []string{"user.go", "user_test.go"} {
+		if err := os.WriteFile(filepath.Join(work, file), []byte("package user\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(work, "README.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.CommitFiles(workspace, []string{"user.go", "README.md"}, "Add user", CommitOptions{}); err != nil {
+		t.Fatalf("Failed to commit files: %v", err)
+	}
+	if files := gitTest(t, work, "show", "--name-status", "--format=", "HEAD"); files != "D\tREADME.md\nA\tuser.go" {
+		t.Errorf("Expected only the given files in the commit, got %q", files)
+	}
+	if status := gitTest(t, work, "status", "--porcelain"); status != "?? user_test.go" {
+		t.Errorf("Expected the other files to stay uncommitted, got %q", status)
+	}
+	first := gitTest(t, work, "rev-parse", "HEAD")
+
+	if err := service.CommitChanges(workspace, "Add user tests", CommitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, cleanup, err := service.AddWorktree(workspace, first)
+	if err != nil {
+		t.Fatalf("Failed to add worktree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "user_test.go")); !os.IsNotExist(err) {
+		t.Errorf("Expected the worktree to contain the first commit only")
+	}
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the worktree to be removed")
+	}
+
+	if err := service.SquashCommits(workspace, base, "Add user", CommitOptions{}); err != nil {
+		t.Fatalf("Failed to squash commits: %v", err)
+	}
+	if parent := gitTest(t, work, "rev-parse", "HEAD^"); parent != base {
+		t.Errorf("Expected a single commit on top of %s, got parent %s", base, parent)
+	}
+	if files := gitTest(t, work, "show", "--name-only", "--format=", "HEAD"); files != "README.md\nuser.go\nuser_test.go" {
+		t.Errorf("Expected the squashed commit to contain all files, got %q", files)
+	}
+}
+
+func TestGitService_StatusChanges(t *testing.T) {
+	_, work := newTestRemote(t)
+	workspace := &GitWorkspace{Path: work}
+	service := NewGitService(nil, logrus.New())
+
+	if err := os.WriteFile(filepath.Join(work, "café.go"), []byte("package app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(work, "with space_test.go"), []byte("package app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, work, "mv", "README.md", "docs.md")
+
+	changes, err := service.StatusChanges(workspace)
+	if err != nil {
+		t.Fatalf("Failed to get changes: %v", err)
+	}
+	expected := []FileChange{{Path: "docs.md", OldPath: "README.md"}, {Path: "café.go"}, {Path: "with space_test.go"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Unexpected changes:\n%+v\nexpected:\n%+v", changes, expected)
+	}
+
+	groups := GroupChanges(changes, []models.CommitGroup{
+		{Name: "implementation"},
+		{Name: "tests", Globs: []string{"*_test.go"}},
+	})
+	expectedGroups := []FileGroup{
+		{Name: "implementation", Files: []string{"docs.md", "café.go", "README.md"}},
+		{Name: "tests", Files: []string{"with space_test.go"}},
+	}
+	if !reflect.DeepEqual(groups, expectedGroups) {
+		t.Fatalf("Unexpected groups:\n%+v\nexpected:\n%+v", groups, expectedGroups)
+	}
+
+	// A rename only detected in the working tree, as for intent-to-add files
+	gitTest(t, work, "reset", "-q")
+	gitTest(t, work, "add", "-N", "docs.md")
+	if err := os.Remove(filepath.Join(work, "README.md")); err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	changes, err = service.StatusChanges(workspace)
+	if err != nil {
+		t.Fatalf("Failed to get changes: %v", err)
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Unexpected changes for a working tree rename:\n%+v\nexpected:\n%+v", changes, expected)
+	}
+
+	if err := service.CommitFiles(workspace, groups[0].Files, "Add app", CommitOptions{}); err != nil {
+		t.Fatalf("Failed to commit non-ASCII file: %v", err)
+	}
+	if files := gitTest(t, work, "-c", "core.quotePath=false", "show", "--name-status", "--format=", "-M", "HEAD"); files != "A\tcafé.go\nR100\tREADME.md\tdocs.md" {
+		t.Errorf("Expected the rename and the new file in the commit, got %q", files)
+	}
+	if status := gitTest(t, work, "status", "--porcelain"); status != `?? "with space_test.go"` {
+		t.Errorf("Expected the tests to stay uncommitted, got %q", status)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	// Identity of the agent's commits when the project does not configure one
+	DefaultCommitAuthorName  = "SDLC AI Agent"
+	DefaultCommitAuthorEmail = "sdlc-agent@example.com"
+)
+
+// CommitOptions are the identity, signing key and co-authors of the agent's commits.
+// The author is also the committer, whose email hosts match against the signing key.
+type CommitOptions struct {
+	AuthorName        string
+	AuthorEmail       string
+	SigningFormat     string // gpg or ssh, empty for unsigned commits
+	SigningKey        string // Armored GPG or OpenSSH private key
+	SigningPassphrase string
+	CoAuthors         []string // "Name <email>" credited with Co-authored-by trailers
+}
+
+// NewCommitOptions resolves the commit settings of a project: the signing key and its
+// passphrase are read from the secret store and the assignee is mapped to a co-author
+func NewCommitOptions(settings *models.CommitSettings, assignee *models.Assignee, secrets *SecretStore) (CommitOptions, error) {
+	options := CommitOptions{
+		AuthorName:  DefaultCommitAuthorName,
+		AuthorEmail: DefaultCommitAuthorEmail,
+	}
+	if settings == nil {
+		return options, nil
+	}
+
+	if settings.AuthorName != "" {
+		options.AuthorName = settings.AuthorName
+	}
+	if settings.AuthorEmail != "" {
+		options.AuthorEmail = settings.AuthorEmail
+	}
+
+	if signing := settings.Signing; signing != nil {
+		key, err := secrets.Get(signing.KeySecret)
+		if err != nil {
+			return CommitOptions{}, fmt.Errorf("failed to load commit signing key: %w", err)
+		}
+		options.SigningFormat = signing.Format
+		options.SigningKey = key
+
+		if signing.PassphraseSecret != "" {
+			passphrase, err := secrets.Get(signing.PassphraseSecret)
+			if err != nil {
+				return CommitOptions{}, fmt.Errorf("failed to load commit signing passphrase: %w", err)
+			}
+			options.SigningPassphrase = strings.TrimRight(passphrase, "\r\n")
+		}
+	}
+
+	if coAuthor := mapAssignee(settings.UserMapping, assignee); coAuthor != "" {
+		options.CoAuthors = append(options.CoAuthors, coAuthor)
+	}
+
+	return options, nil
+}
+
+// mapAssignee returns the git identity the user mapping gives the assignee, matching its
+// account ID, username or email address case-insensitively
+func mapAssignee(mapping []models.UserMapping, assignee *models.Assignee) string {
+	if assignee == nil {
+		return ""
+	}
+
+	for _, user := range mapping {
+		for _, id := range []string{assignee.AccountID, assignee.Name, assignee.Email} {
+			if id != "" && strings.EqualFold(user.JiraUser, id) {
+				return fmt.Sprintf("%s <%s>", user.Name, user.Email)
+			}
+		}
+	}
+	return ""
+}
+
+// message appends the Co-authored-by trailers to a commit message
+func (o CommitOptions) message(message string) string {
+	if len(o.CoAuthors) == 0 {
+		return message
+	}
+
+	trailers := make([]string, 0, len(o.CoAuthors))
+	for _, coAuthor := range o.CoAuthors {
+		trailers = append(trailers, "Co-authored-by: "+coAuthor)
+	}
+	return message + "\n\n" + strings.Join(trailers, "\n")
+}
+
+// env returns the environment committing with the configured identity and, when there is
+// a signing key, signing the commit. The key is written to a temporary directory that the
+// returned function removes; it never reaches the repository config.
+func (o CommitOptions) env() ([]string, func(), error) {
+	name, email := o.AuthorName, o.AuthorEmail
+	if name == "" {
+		name = DefaultCommitAuthorName
+	}
+	if email == "" {
+		email = DefaultCommitAuthorEmail
+	}
+	env := append(os.Environ(),
+		"GIT_AUTHOR_NAME="+name,
+		"GIT_AUTHOR_EMAIL="+email,
+		"GIT_COMMITTER_NAME="+name,
+		"GIT_COMMITTER_EMAIL="+email,
+	)
+
+	var config []string
+	var cleanup func()
+	var err error
+	switch o.SigningFormat {
+	case "":
+		return env, func() {}, nil
+	case "ssh":
+		config, cleanup, err = sshSigningConfig(o.SigningKey, o.SigningPassphrase)
+	case "gpg":
+		config, cleanup, err = gpgSigningConfig(o.SigningKey, o.SigningPassphrase)
+	default:
+		return nil, nil, fmt.Errorf("unsupported commit signing format %q", o.SigningFormat)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config = append(config, "commit.gpgSign", "true")
+	env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(config)/2))
+	for i := 0; i < len(config); i += 2 {
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i/2, config[i]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i/2, config[i+1]),
+		)
+	}
+	return env, cleanup, nil
+}
+
+// sshSigningConfig returns the git config signing commits with an SSH key. The key is
+// written without passphrase so that ssh-keygen never prompts for it.
+func sshSigningConfig(privateKey, passphrase string) ([]string, func(), error) {
+	keyPEM, err := decryptSSHKey(privateKey, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "sdlc-signing-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create signing key directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	keyFile := filepath.Join(dir, "signing_key")
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	return []string{"gpg.format", "ssh", "user.signingKey", keyFile}, cleanup, nil
+}
+
+// gpgSigningConfig imports a GPG key into a temporary keyring and returns the git config
+// signing commits with it. git runs gpg through a wrapper passing the keyring and the
+// passphrase, since there is no agent to ask for it.
+func gpgSigningConfig(privateKey, passphrase string) ([]string, func(), error) {
+	dir, err := os.MkdirTemp("", "sdlc-gpg-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create gpg home: %w", err)
+	}
+	cleanup := func() {
+		exec.Command("gpgconf", "--homedir", dir, "--kill", "gpg-agent").Run()
+		os.RemoveAll(dir)
+	}
+
+	keyFile := filepath.Join(dir, "signing_key.asc")
+	passphraseFile := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(keyFile, []byte(privateKey), 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+	if err := os.WriteFile(passphraseFile, []byte(passphrase), 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write signing key passphrase: %w", err)
+	}
+
+	gpgArgs := []string{"--homedir", dir, "--batch", "--pinentry-mode", "loopback", "--passphrase-file", passphraseFile}
+	if output, err := exec.Command("gpg", append(gpgArgs, "--import", keyFile)...).CombinedOutput(); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to import gpg signing key: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	os.Remove(keyFile)
+
+	output, err := exec.Command("gpg", "--homedir", dir, "--batch", "--with-colons", "--list-secret-keys").Output()
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to list gpg signing key: %w", err)
+	}
+	fingerprint := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Split(line, ":"); fields[0] == "fpr" && len(fields) > 9 {
+			fingerprint = fields[9]
+			break
+		}
+	}
+	if fingerprint == "" {
+		cleanup()
+		return nil, nil, fmt.Errorf("gpg signing key secret holds no private key")
+	}
+
+	program := filepath.Join(dir, "gpg.sh")
+	script := "#!/bin/sh\nexec gpg '" + strings.Join(gpgArgs, "' '") + "' \"$@\"\n"
+	if err := os.WriteFile(program, []byte(script), 0700); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write gpg wrapper: %w", err)
+	}
+
+	return []string{"gpg.format", "openpgp", "gpg.program", program, "user.signingKey", fingerprint}, cleanup, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestNewCommitOptions(t *testing.T) {
+	secrets := NewSecretStore(t.TempDir())
+
+	options, err := NewCommitOptions(nil, &models.Assignee{Email: "jane@example.com"}, secrets)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if options.AuthorName != DefaultCommitAuthorName || options.AuthorEmail != DefaultCommitAuthorEmail || options.SigningFormat != "" || len(options.CoAuthors) != 0 {
+		t.Errorf("Expected the default identity without signature, got %+v", options)
+	}
+
+	settings := &models.CommitSettings{
+		UserMapping: []models.UserMapping{
+			{JiraUser: "5b10a2844c20165700ede21g", Name: "John Roe", Email: "john@company.com"},
+			{JiraUser: "jane@example.com", Name: "Jane Doe", Email: "jane@company.com"},
+		},
+	}
+	options, _ = NewCommitOptions(settings, &models.Assignee{Email: "Jane@Example.com", DisplayName: "Jane"}, secrets)
+	if len(options.CoAuthors) != 1 || options.CoAuthors[0] != "Jane Doe <jane@company.com>" {
+		t.Errorf("Expected the assignee to be mapped by email, got %v", options.CoAuthors)
+	}
+	options, _ = NewCommitOptions(settings, &models.Assignee{Email: "unknown@example.com"}, secrets)
+	if len(options.CoAuthors) != 0 {
+		t.Errorf("Did not expect unmapped assignees as co-authors, got %v", options.CoAuthors)
+	}
+
+	settings.Signing = &models.CommitSigningSettings{Format: "ssh", KeySecret: "missing"}
+	if _, err := NewCommitOptions(settings, nil, secrets); err == nil {
+		t.Error("Expected a missing signing key to fail")
+	}
+	settings.Signing.KeySecret = "../etc/passwd"
+	if _, err := NewCommitOptions(settings, nil, secrets); err == nil {
+		t.Error("Expected secret names with path separators to be rejected")
+	}
+}
+
+func TestCommitChanges_SSHSigned(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secretsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(secretsDir, "signing_key"), pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secretsDir, "signing_passphrase"), []byte("passphrase\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options, err := NewCommitOptions(&models.CommitSettings{
+		AuthorName:  "Release Bot",
+		AuthorEmail: "bot@company.com",
+		Signing:     &models.CommitSigningSettings{Format: "ssh", KeySecret: "signing_key", PassphraseSecret: "signing_passphrase"},
+		UserMapping: []models.UserMapping{{JiraUser: "jane@example.com", Name: "Jane Doe", Email: "jane@company.com"}},
+	}, &models.Assignee{Email: "jane@example.com"}, NewSecretStore(secretsDir))
+	if err != nil {
+		t.Fatalf("Failed to resolve commit options: %v", err)
+	}
+
+	_, work := newTestRemote(t)
+	if err := os.WriteFile(filepath.Join(work, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	service := NewGitService(nil, logrus.New())
+	if err := service.CommitChanges(&GitWorkspace{Path: work}, "[TEST-1] Add main", options); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	if identity := gitTest(t, work, "log", "-1", "--format=%an <%ae>|%cn <%ce>"); identity != "Release Bot <bot@company.com>|Release Bot <bot@company.com>" {
+		t.Errorf("Expected the configured identity, got %q", identity)
+	}
+	if message := gitTest(t, work, "log", "-1", "--format=%B"); !strings.HasSuffix(message, "\n\nCo-authored-by: Jane Doe <jane@company.com>") {
+		t.Errorf("Expected a Co-authored-by trailer, got %q", message)
+	}
+
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowedSigners := filepath.Join(t.TempDir(), "allowed_signers")
+	if err := os.WriteFile(allowedSigners, []byte("bot@company.com "+string(ssh.MarshalAuthorizedKey(sshPublicKey))), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, work, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners, "verify-commit", "HEAD")
+}
+
+func TestCommitChanges_GPGSigned(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+
+	// Generate a passphrase protected key in a separate keyring
+	home, err := os.MkdirTemp("", "gpg-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run()
+		os.RemoveAll(home)
+	})
+	gpg := func(args ...string) []byte {
+		args = append([]string{"--homedir", home, "--batch", "--pinentry-mode", "loopback", "--passphrase", "secret"}, args...)
+		output, err := exec.Command("gpg", args...).Output()
+		if err != nil {
+			t.Fatalf("gpg %s failed: %v", strings.Join(args, " "), err)
+		}
+		return output
+	}
+	gpg("--quick-gen-key", "Release Bot <bot@company.com>", "ed25519", "sign", "never")
+	secretsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(secretsDir, "gpg_key"), gpg("--armor", "--export-secret-keys", "bot@company.com"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secretsDir, "gpg_passphrase"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options, err := NewCommitOptions(&models.CommitSettings{
+		AuthorName:  "Release Bot",
+		AuthorEmail: "bot@company.com",
+		Signing:     &models.CommitSigningSettings{Format: "gpg", KeySecret: "gpg_key", PassphraseSecret: "gpg_passphrase"},
+	}, nil, NewSecretStore(secretsDir))
+	if err != nil {
+		t.Fatalf("Failed to resolve commit options: %v", err)
+	}
+
+	_, work := newTestRemote(t)
+	if err := os.WriteFile(filepath.Join(work, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	service := NewGitService(nil, logrus.New())
+	if err := service.CommitChanges(&GitWorkspace{Path: work}, "[TEST-1] Add main", options); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	verify := exec.Command("git", "verify-commit", "HEAD")
+	verify.Dir = work
+	verify.Env = append(os.Environ(), "GNUPGHOME="+home)
+	if output, err := verify.CombinedOutput(); err != nil {
+		t.Errorf("Expected a valid signature: %v: %s", err, output)
+	}
+}
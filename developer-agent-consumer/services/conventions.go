@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	// Default size budget of the convention files included in the agent context
+	defaultConventionMaxBytes     = 32 * 1024
+	defaultConventionMaxFileBytes = 8 * 1024
+
+	// Remaining budget below which no further convention file is included
+	minConventionBytes = 256
+)
+
+// defaultConventionGlobs are the convention documents included in the agent context, in
+// priority order: when the budget runs out, files matched by later globs are dropped first.
+var defaultConventionGlobs = []string{
+	// Agent instructions and contributing guides
+	"CLAUDE.md", "AGENTS.md", ".cursorrules", ".github/copilot-instructions.md",
+	"CONTRIBUTING.md", ".github/CONTRIBUTING.md", "docs/CONTRIBUTING.md",
+	// Formatting and lint configuration
+	".editorconfig",
+	".golangci.yml", ".golangci.yaml", ".golangci.toml",
+	".eslintrc", ".eslintrc.*", "eslint.config.*",
+	".prettierrc", ".prettierrc.*",
+	"ruff.toml", ".flake8", ".pylintrc", ".rubocop.yml",
+	"rustfmt.toml", ".rustfmt.toml", "checkstyle.xml",
+	// Architecture decision records
+	"docs/adr/*.md", "doc/adr/*.md", "docs/decisions/*.md", "adr/*.md",
+}
+
+// conventionMatch is a file matched by a convention glob
+type conventionMatch struct {
+	path     string
+	priority int // Index of the first glob that matched
+}
+
+// detectConventionFiles reads the convention documents matching the repository's globs
+// (or the defaults) and keeps their content within the size budget
+func (s *AnalyzerService) detectConventionFiles(repoPath string, settings *models.ConventionSettings) []models.ConventionFile {
+	globs := defaultConventionGlobs
+	maxBytes := defaultConventionMaxBytes
+	maxFileBytes := defaultConventionMaxFileBytes
+	if settings != nil {
+		if len(settings.Globs) > 0 {
+			globs = settings.Globs
+		}
+		if settings.MaxBytes > 0 {
+			maxBytes = settings.MaxBytes
+		}
+		if settings.MaxFileBytes > 0 {
+			maxFileBytes = settings.MaxFileBytes
+		}
+	}
+
+	var matches []conventionMatch
+	err := filepath.Walk(repoPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Convention files live in dot-directories such as .github, so only
+		// the git metadata and dependency directories are skipped
+		if info.IsDir() {
+			switch info.Name() {
+			case ".git", "node_modules", "vendor", "venv", "__pycache__":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(repoPath, filePath)
+		relPath = filepath.ToSlash(relPath)
+		for i, glob := range globs {
+			if matchConventionGlob(glob, relPath) {
+				matches = append(matches, conventionMatch{path: relPath, priority: i})
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to scan repository for convention files")
+	}
+
+	// Higher priority globs first, then files closer to the repository root
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].priority != matches[j].priority {
+			return matches[i].priority < matches[j].priority
+		}
+		di, dj := strings.Count(matches[i].path, "/"), strings.Count(matches[j].path, "/")
+		if di != dj {
+			return di < dj
+		}
+		return matches[i].path < matches[j].path
+	})
+
+	conventions := []models.ConventionFile{}
+	remaining := maxBytes
+	for i, match := range matches {
+		if remaining < minConventionBytes {
+			s.logger.WithFields(logrus.Fields{
+				"skipped": len(matches) - i,
+				"budget":  maxBytes,
+			}).Info("Convention file budget exhausted")
+			break
+		}
+
+		limit := maxFileBytes
+		if limit > remaining {
+			limit = remaining
+		}
+
+		content, truncated, err := readConventionFile(filepath.Join(repoPath, filepath.FromSlash(match.path)), limit)
+		if err != nil {
+			s.logger.WithError(err).WithField("file", match.path).Warn("Failed to read convention file")
+			continue
+		}
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		conventions = append(conventions, models.ConventionFile{
+			Path:      match.path,
+			Content:   content,
+			Truncated: truncated,
+		})
+		remaining -= len(content)
+	}
+
+	return conventions
+}
+
+// readConventionFile reads up to limit bytes of a text file. Content over the limit is
+// cut at the last line break that fits. Binary files are returned empty.
+func readConventionFile(filePath string, limit int) (string, bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(limit)+1))
+	if err != nil {
+		return "", false, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", false, nil
+	}
+
+	truncated := len(data) > limit
+	if truncated {
+		data = data[:limit]
+		if i := bytes.LastIndexByte(data, '\n'); i > 0 {
+			data = data[:i+1]
+		}
+		for len(data) > 0 && !utf8.Valid(data) {
+			data = data[:len(data)-1]
+		}
+	}
+
+	return string(data), truncated, nil
+}
+
+// matchConventionGlob reports whether a slash-separated path relative to the repository
+// root matches a glob. A glob without a slash matches the file name in any directory,
+// "**" matches any number of directories.
+func matchConventionGlob(glob, relPath string) bool {
+	if !strings.Contains(glob, "/") {
+		matched, _ := path.Match(glob, path.Base(relPath))
+		return matched
+	}
+	return matchGlobSegments(strings.Split(glob, "/"), strings.Split(relPath, "/"))
+}
+
+// matchGlobSegments matches path segments against glob segments
+func matchGlobSegments(glob, segments []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchGlobSegments(glob[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(glob[0], segments[0]); !matched {
+			return false
+		}
+		glob, segments = glob[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
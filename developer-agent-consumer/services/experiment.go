@@ -0,0 +1,37 @@
+package services
+
+import (
+	"hash/fnv"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+// AssignVariant picks the experiment variant of an issue by weight. The assignment is
+// derived from a hash of the experiment and issue key, so re-runs and resumed
+// developments of the same issue always get the same variant.
+func AssignVariant(experiment *models.Experiment, issueKey string) *models.ExperimentVariant {
+	total := 0
+	for _, variant := range experiment.Variants {
+		if variant.Weight > 0 {
+			total += variant.Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(experiment.ID + "/" + issueKey))
+	bucket := int(hash.Sum32() % uint32(total))
+
+	for i, variant := range experiment.Variants {
+		if variant.Weight <= 0 {
+			continue
+		}
+		if bucket < variant.Weight {
+			return &experiment.Variants[i]
+		}
+		bucket -= variant.Weight
+	}
+	return nil
+}
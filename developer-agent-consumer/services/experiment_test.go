@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestAssignVariant_IsStablePerIssue(t *testing.T) {
+	experiment := &models.Experiment{
+		ID: "exp-1",
+		Variants: []models.ExperimentVariant{
+			{Name: "control", Weight: 50},
+			{Name: "strict", Weight: 50},
+		},
+	}
+
+	first := AssignVariant(experiment, "PROJ-7")
+	for i := 0; i < 5; i++ {
+		if got := AssignVariant(experiment, "PROJ-7"); got.Name != first.Name {
+			t.Fatalf("Expected stable assignment %q, got %q", first.Name, got.Name)
+		}
+	}
+}
+
+func TestAssignVariant_FollowsWeights(t *testing.T) {
+	experiment := &models.Experiment{
+		ID: "exp-2",
+		Variants: []models.ExperimentVariant{
+			{Name: "control", Weight: 80},
+			{Name: "candidate", Weight: 20},
+			{Name: "disabled", Weight: 0},
+		},
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		counts[AssignVariant(experiment, fmt.Sprintf("PROJ-%d", i)).Name]++
+	}
+
+	if counts["disabled"] != 0 {
+		t.Errorf("Expected no assignments to a zero-weight variant, got %d", counts["disabled"])
+	}
+	if counts["candidate"] < 300 || counts["candidate"] > 500 {
+		t.Errorf("Expected about 400 assignments to candidate, got %d", counts["candidate"])
+	}
+}
+
+func TestAssignVariant_NoWeights(t *testing.T) {
+	experiment := &models.Experiment{ID: "exp-3", Variants: []models.ExperimentVariant{{Name: "a"}}}
+	if AssignVariant(experiment, "PROJ-1") != nil {
+		t.Error("Expected no variant without weights")
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// Forks are created asynchronously; EnsureFork waits for them to be ready
+	forkPollInterval = 2 * time.Second
+	forkPollAttempts = 30
+)
+
+// EnsureFork returns the clone URL of the token owner's fork of a repository, or of the
+// fork in the given organization (GitHub) or group (GitLab), creating the fork when it
+// does not exist yet. The URL is an SSH URL when the repository URL is one.
+func (s *PRService) EnsureFork(repoURL, accessToken, namespace string) (string, error) {
+	repoInfo, err := s.parseRepoURL(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"platform":  repoInfo.Platform,
+		"owner":     repoInfo.Owner,
+		"repo":      repoInfo.Repo,
+		"namespace": namespace,
+	}).Info("Ensuring fork exists")
+
+	switch repoInfo.Platform {
+	case "github":
+		return s.ensureGitHubFork(repoInfo, accessToken, namespace, IsSSHURL(repoURL))
+	case "gitlab":
+		return s.ensureGitLabFork(repoInfo, accessToken, namespace, IsSSHURL(repoURL))
+	}
+	return "", fmt.Errorf("unsupported platform: %s", repoInfo.Platform)
+}
+
+// ensureGitHubFork creates the fork, which returns the existing fork when there is one,
+// and waits until its branches are available
+func (s *PRService) ensureGitHubFork(repoInfo *RepoInfo, accessToken, namespace string, ssh bool) (string, error) {
+	payload := map[string]interface{}{}
+	if namespace != "" {
+		payload["organization"] = namespace
+	}
+
+	var fork struct {
+		FullName string `json:"full_name"`
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+	}
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/forks", repoInfo.BaseURL, repoInfo.Owner, repoInfo.Repo)
+	if err := s.postJSON(apiURL, "token "+accessToken, payload, &fork); err != nil {
+		return "", fmt.Errorf("failed to create fork: %w", err)
+	}
+
+	branchesURL := fmt.Sprintf("%s/repos/%s/branches?per_page=1", repoInfo.BaseURL, fork.FullName)
+	err := s.waitForFork(fork.FullName, func() (bool, error) {
+		var branches []struct {
+			Name string `json:"name"`
+		}
+		if err := s.getJSON(branchesURL, "token "+accessToken, &branches); err != nil {
+			return false, err
+		}
+		return len(branches) > 0, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if ssh {
+		return fork.SSHURL, nil
+	}
+	return fork.CloneURL, nil
+}
+
+type gitLabFork struct {
+	ID           int    `json:"id"`
+	SSHURL       string `json:"ssh_url_to_repo"`
+	HTTPURL      string `json:"http_url_to_repo"`
+	ImportStatus string `json:"import_status"`
+	Namespace    struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+}
+
+// ensureGitLabFork reuses a fork owned by the token user, in the group when one is given,
+// or forks the project and waits for the import to finish
+func (s *PRService) ensureGitLabFork(repoInfo *RepoInfo, accessToken, namespace string, ssh bool) (string, error) {
+	projectID, err := s.gitLabProjectID(repoInfo, accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	var forks []gitLabFork
+	forksURL := fmt.Sprintf("%s/projects/%d/forks?owned=true", repoInfo.BaseURL, projectID)
+	if err := s.getJSON(forksURL, "Bearer "+accessToken, &forks); err != nil {
+		return "", fmt.Errorf("failed to look up existing forks: %w", err)
+	}
+
+	var fork *gitLabFork
+	for i := range forks {
+		if namespace == "" || forks[i].Namespace.FullPath == namespace {
+			fork = &forks[i]
+			break
+		}
+	}
+
+	if fork == nil {
+		payload := map[string]interface{}{}
+		if namespace != "" {
+			payload["namespace_path"] = namespace
+		}
+		fork = &gitLabFork{}
+		if err := s.postJSON(fmt.Sprintf("%s/projects/%d/fork", repoInfo.BaseURL, projectID), "Bearer "+accessToken, payload, fork); err != nil {
+			return "", fmt.Errorf("failed to create fork: %w", err)
+		}
+
+		forkURL := fmt.Sprintf("%s/projects/%d", repoInfo.BaseURL, fork.ID)
+		err := s.waitForFork(fork.HTTPURL, func() (bool, error) {
+			if err := s.getJSON(forkURL, "Bearer "+accessToken, fork); err != nil {
+				return false, err
+			}
+			if fork.ImportStatus == "failed" {
+				return false, fmt.Errorf("fork import failed")
+			}
+			return fork.ImportStatus == "finished" || fork.ImportStatus == "none", nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if ssh {
+		return fork.SSHURL, nil
+	}
+	return fork.HTTPURL, nil
+}
+
+// waitForFork polls until ready reports the fork can be pushed to
+func (s *PRService) waitForFork(name string, ready func() (bool, error)) error {
+	for attempt := 1; attempt <= forkPollAttempts; attempt++ {
+		done, err := ready()
+		if err != nil {
+			return fmt.Errorf("failed to check fork %s: %w", name, err)
+		}
+		if done {
+			s.logger.WithField("fork", name).Info("Fork is ready")
+			return nil
+		}
+		time.Sleep(forkPollInterval)
+	}
+	return fmt.Errorf("fork %s was not ready after %d attempts", name, forkPollAttempts)
+}
+
+// gitLabProjectID looks up the numeric ID of a GitLab project
+func (s *PRService) gitLabProjectID(repoInfo *RepoInfo, accessToken string) (int, error) {
+	var project struct {
+		ID int `json:"id"`
+	}
+	projectPath := url.PathEscape(fmt.Sprintf("%s/%s", repoInfo.Owner, repoInfo.Repo))
+	if err := s.getJSON(fmt.Sprintf("%s/projects/%s", repoInfo.BaseURL, projectPath), "Bearer "+accessToken, &project); err != nil {
+		return 0, fmt.Errorf("failed to get project: %w", err)
+	}
+	return project.ID, nil
+}
+
+// postJSON posts a JSON payload and decodes the response of a created (or, for GitHub
+// forks, accepted) resource
+func (s *PRService) postJSON(apiURL, authorization string, payload, target interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestGitService_UseFork(t *testing.T) {
+	remote, _ := newTestRemote(t)
+	fork := filepath.Join(t.TempDir(), "fork.git")
+	gitTest(t, filepath.Dir(fork), "clone", "--quiet", "--bare", remote, fork)
+	service := NewGitService(nil, logrus.New())
+
+	workspace, err := service.CloneRepository("file://"+remote, GitAuth{}, "TEST-4", "main", nil)
+	if err != nil {
+		t.Fatalf("Failed to clone repository: %v", err)
+	}
+	defer service.Cleanup(workspace)
+	if err := service.UseFork(workspace, "file://"+fork); err != nil {
+		t.Fatalf("Failed to add fork remote: %v", err)
+	}
+	if _, err := service.CheckoutIssueBranch(workspace, GitAuth{}, "feature/TEST-4", models.RerunPolicyAppend); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace.Path, "README.md"), []byte("# App\n\nAgent\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.CommitChanges(workspace, "Update README", CommitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.PushBranch(workspace, GitAuth{}); err != nil {
+		t.Fatalf("Failed to push to fork: %v", err)
+	}
+
+	if head := gitTest(t, fork, "rev-parse", "feature/TEST-4"); head != gitTest(t, workspace.Path, "rev-parse", "HEAD") {
+		t.Errorf("Expected the branch to be pushed to the fork, got %s", head)
+	}
+	if refs := gitTest(t, remote, "branch", "--list", "feature/*"); refs != "" {
+		t.Errorf("Did not expect the branch on origin, got %q", refs)
+	}
+
+	reopened, err := service.OpenWorkspace(workspace.Path)
+	if err != nil || reopened.ForkURL != "file://"+fork {
+		t.Errorf("Expected the reopened workspace to keep the fork, got %+v (%v)", reopened, err)
+	}
+	if head, err := service.RemoteBranchHead(reopened, GitAuth{}, "feature/TEST-4"); err != nil || head == "" {
+		t.Errorf("Expected the branch head on the fork, got %q (%v)", head, err)
+	}
+}
+
+func TestEnsureFork_GitHub(t *testing.T) {
+	forkPollInterval = 0
+	branchChecks := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/company/backend/forks":
+			var payload map[string]string
+			json.NewDecoder(r.Body).Decode(&payload)
+			if payload["organization"] != "agents" {
+				t.Errorf("Expected the fork in the organization, got %v", payload)
+			}
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"full_name": "agents/backend", "clone_url": "https://github.com/agents/backend.git", "ssh_url": "git@github.com:agents/backend.git"}`))
+		case r.Method == "GET" && r.URL.Path == "/repos/agents/backend/branches":
+			branchChecks++
+			if branchChecks == 1 {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"name": "main"}]`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := NewPRService(logrus.New())
+	repoInfo := &RepoInfo{Platform: "github", Owner: "company", Repo: "backend", BaseURL: server.URL}
+	forkURL, err := service.ensureGitHubFork(repoInfo, "token", "agents", true)
+	if err != nil {
+		t.Fatalf("Failed to ensure fork: %v", err)
+	}
+	if forkURL != "git@github.com:agents/backend.git" || branchChecks != 2 {
+		t.Errorf("Expected the SSH URL once the fork is ready, got %s after %d checks", forkURL, branchChecks)
+	}
+}
+
+func TestEnsureFork_GitLabReusesOwnedFork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/projects/group/repo":
+			w.Write([]byte(`{"id": 42}`))
+		case r.Method == "GET" && r.URL.Path == "/projects/42/forks":
+			w.Write([]byte(`[
+				{"id": 50, "http_url_to_repo": "https://gitlab.internal/someone/repo.git", "namespace": {"full_path": "someone"}},
+				{"id": 51, "http_url_to_repo": "https://gitlab.internal/agents/repo.git", "namespace": {"full_path": "agents"}}
+			]`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := NewPRService(logrus.New())
+	repoInfo := &RepoInfo{Platform: "gitlab", Owner: "group", Repo: "repo", BaseURL: server.URL}
+	forkURL, err := service.ensureGitLabFork(repoInfo, "token", "agents", false)
+	if err != nil {
+		t.Fatalf("Failed to ensure fork: %v", err)
+	}
+	if forkURL != "https://gitlab.internal/agents/repo.git" {
+		t.Errorf("Expected the fork in the group, got %s", forkURL)
+	}
+}
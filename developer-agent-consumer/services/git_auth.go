@@ -0,0 +1,148 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// scpURLPattern matches the scp-like SSH form user@host:path of repository URLs
+var scpURLPattern = regexp.MustCompile(`^([A-Za-z0-9._-]+@)?([A-Za-z0-9.-]+):([^/].*)$`)
+
+// GitAuth holds the credentials of a repository: the access token is used for HTTPS URLs
+// and the deploy key for SSH URLs
+type GitAuth struct {
+	AccessToken string
+	SSHKey      *models.SSHKeyCredential
+}
+
+// NewGitAuth returns the git credentials of a repository
+func NewGitAuth(repository *models.Repository) GitAuth {
+	return GitAuth{
+		AccessToken: repository.GitAccessToken,
+		SSHKey:      repository.SSHKey,
+	}
+}
+
+// IsSSHURL reports whether a repository URL uses SSH, either as ssh://host/path or in the
+// scp-like form git@host:org/repo.git
+func IsSSHURL(repoURL string) bool {
+	return strings.HasPrefix(repoURL, "ssh://") || strings.HasPrefix(repoURL, "git+ssh://") ||
+		(!strings.Contains(repoURL, "://") && scpURLPattern.MatchString(repoURL))
+}
+
+// SplitSSHURL returns the host and the path of an SSH repository URL
+func SplitSSHURL(repoURL string) (string, string, bool) {
+	if !strings.Contains(repoURL, "://") {
+		match := scpURLPattern.FindStringSubmatch(repoURL)
+		if match == nil {
+			return "", "", false
+		}
+		return match[2], strings.Trim(match[3], "/"), true
+	}
+
+	rest := repoURL[strings.Index(repoURL, "://")+3:]
+	if i := strings.Index(rest, "@"); i >= 0 && i < strings.Index(rest+"/", "/") {
+		rest = rest[i+1:]
+	}
+	host, path, _ := strings.Cut(rest, "/")
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	return host, strings.Trim(path, "/"), host != ""
+}
+
+// env returns the environment passing the credentials for repoURL to git, and a function
+// removing the key files written for it. The transport is chosen by the URL scheme: the
+// access token goes in an HTTP header, the deploy key to ssh through GIT_SSH_COMMAND.
+// Neither reaches the command line or the repository config.
+func (a GitAuth) env(repoURL string) ([]string, func(), error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	noCleanup := func() {}
+
+	if !IsSSHURL(repoURL) {
+		if a.AccessToken == "" {
+			return env, noCleanup, nil
+		}
+		// The header is scoped to the repository's host: submodules and LFS objects on the
+		// same host are fetched with it, while it never reaches other hosts
+		key := "http.extraHeader"
+		if parsed, err := url.Parse(repoURL); err == nil && parsed.Host != "" {
+			key = fmt.Sprintf("http.%s://%s/.extraHeader", parsed.Scheme, parsed.Host)
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte("git:" + a.AccessToken))
+		return append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0="+key,
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		), noCleanup, nil
+	}
+
+	if a.SSHKey == nil || a.SSHKey.PrivateKey == "" {
+		// Fall back to the keys and known hosts of the consumer's user
+		return append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes"), noCleanup, nil
+	}
+
+	// Without pinned host keys the deploy key could be offered to, and the code pushed to,
+	// whichever server answers for the host
+	if strings.TrimSpace(a.SSHKey.KnownHosts) == "" {
+		host, _, _ := SplitSSHURL(repoURL)
+		return nil, nil, fmt.Errorf("ssh_key of %s has no known_hosts: add the host keys of %s (e.g. from ssh-keyscan, verified out of band)", repoURL, host)
+	}
+
+	keyPEM, err := decryptSSHKey(a.SSHKey.PrivateKey, a.SSHKey.Passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "sdlc-ssh-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create ssh key directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	keyFile := filepath.Join(dir, "id")
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write ssh key: %w", err)
+	}
+
+	// Hosts are pinned to the configured known_hosts only
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	if err := os.WriteFile(knownHostsFile, []byte(a.SSHKey.KnownHosts+"\n"), 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write known hosts: %w", err)
+	}
+
+	command := fmt.Sprintf("ssh -i '%s' -o IdentitiesOnly=yes -o BatchMode=yes -o StrictHostKeyChecking=yes -o UserKnownHostsFile='%s'", keyFile, knownHostsFile)
+	return append(env, "GIT_SSH_COMMAND="+command), cleanup, nil
+}
+
+// decryptSSHKey returns the private key in PEM form without passphrase, so that ssh
+// never prompts for it
+func decryptSSHKey(privateKey, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		if _, err := ssh.ParseRawPrivateKey([]byte(privateKey)); err != nil {
+			return nil, fmt.Errorf("invalid ssh private key: %w", err)
+		}
+		return []byte(strings.TrimSpace(privateKey) + "\n"), nil
+	}
+
+	key, err := ssh.ParseRawPrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt ssh private key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ssh private key: %w", err)
+	}
+	return pem.EncodeToMemory(block), nil
+}
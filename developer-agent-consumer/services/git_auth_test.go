@@ -0,0 +1,146 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSplitSSHURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		ssh  bool
+		host string
+		path string
+	}{
+		{"git@github.com:company/backend.git", true, "github.com", "company/backend.git"},
+		{"deploy@git.internal:platform/api", true, "git.internal", "platform/api"},
+		{"ssh://git@gitlab.internal:2222/group/repo.git", true, "gitlab.internal", "group/repo.git"},
+		{"ssh://gitlab.internal/group/repo", true, "gitlab.internal", "group/repo"},
+		{"https://github.com/company/backend", false, "", ""},
+		{"file:///tmp/remote.git", false, "", ""},
+		{"/tmp/remote.git", false, "", ""},
+	}
+
+	for _, test := range tests {
+		if got := IsSSHURL(test.url); got != test.ssh {
+			t.Errorf("IsSSHURL(%q) = %v, expected %v", test.url, got, test.ssh)
+		}
+		if !test.ssh {
+			continue
+		}
+		host, path, ok := SplitSSHURL(test.url)
+		if !ok || host != test.host || path != test.path {
+			t.Errorf("SplitSSHURL(%q) = %q, %q, %v", test.url, host, path, ok)
+		}
+	}
+}
+
+func TestGitAuthEnv(t *testing.T) {
+	auth := GitAuth{AccessToken: "secret"}
+
+	env, cleanup, err := auth.env("https://github.com/company/backend")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cleanup()
+	joined := strings.Join(env, "\n")
+	if !strings.Contains(joined, "GIT_CONFIG_VALUE_0=Authorization: Basic Z2l0OnNlY3JldA==") || strings.Contains(joined, "GIT_SSH_COMMAND") {
+		t.Error("Expected the token to be passed as an HTTP header for HTTPS URLs")
+	}
+	if !strings.Contains(joined, "GIT_CONFIG_KEY_0=http.https://github.com/.extraHeader") {
+		t.Error("Expected the header to be scoped to the repository host")
+	}
+
+	env, cleanup, _ = auth.env("git@github.com:company/backend.git")
+	cleanup()
+	if strings.Contains(strings.Join(env, "\n"), "Authorization") {
+		t.Error("Did not expect the token to be sent over SSH")
+	}
+}
+
+func TestGitAuthEnv_DeployKey(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := GitAuth{SSHKey: &models.SSHKeyCredential{
+		PrivateKey: string(pem.EncodeToMemory(block)),
+		Passphrase: "passphrase",
+		KnownHosts: "git.internal ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl",
+	}}
+	env, cleanup, err := auth.env("git@git.internal:platform/api.git")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	command := ""
+	for _, variable := range env {
+		if strings.HasPrefix(variable, "GIT_SSH_COMMAND=") {
+			command = strings.TrimPrefix(variable, "GIT_SSH_COMMAND=")
+		}
+	}
+	if !strings.Contains(command, "-o StrictHostKeyChecking=yes") || !strings.Contains(command, "-o IdentitiesOnly=yes") {
+		t.Errorf("Expected the host key to be pinned, got %q", command)
+	}
+
+	keyFile := strings.Split(command, "'")[1]
+	content, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatalf("Expected the key file to be written: %v", err)
+	}
+	if _, err := ssh.ParseRawPrivateKey(content); err != nil {
+		t.Errorf("Expected the key file to hold the decrypted key: %v", err)
+	}
+	if info, _ := os.Stat(keyFile); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the key file to be private, got %v", info.Mode().Perm())
+	}
+
+	cleanup()
+	if _, err := os.Stat(keyFile); !os.IsNotExist(err) {
+		t.Error("Expected the key file to be removed")
+	}
+
+	auth.SSHKey.Passphrase = "wrong"
+	if _, _, err := auth.env("git@git.internal:platform/api.git"); err == nil {
+		t.Error("Expected a wrong passphrase to fail")
+	}
+
+	auth.SSHKey.Passphrase = "passphrase"
+	auth.SSHKey.KnownHosts = ""
+	if _, _, err := auth.env("git@git.internal:platform/api.git"); err == nil || !strings.Contains(err.Error(), "known_hosts") {
+		t.Errorf("Expected a deploy key without known hosts to fail, got %v", err)
+	}
+}
+
+func TestParseRepoURL_SSH(t *testing.T) {
+	service := NewPRService(logrus.New())
+	tests := map[string]RepoInfo{
+		"git@github.com:company/backend.git":            {Platform: "github", Owner: "company", Repo: "backend", BaseURL: "https://api.github.com"},
+		"ssh://git@gitlab.internal:2222/group/repo.git": {Platform: "gitlab", Owner: "group", Repo: "repo", BaseURL: "https://gitlab.internal/api/v4"},
+		"https://github.com/company/backend.git":        {Platform: "github", Owner: "company", Repo: "backend", BaseURL: "https://api.github.com"},
+	}
+
+	for repoURL, expected := range tests {
+		info, err := service.parseRepoURL(repoURL)
+		if err != nil {
+			t.Errorf("parseRepoURL(%q) failed: %v", repoURL, err)
+			continue
+		}
+		if *info != expected {
+			t.Errorf("parseRepoURL(%q) = %+v, expected %+v", repoURL, *info, expected)
+		}
+	}
+}
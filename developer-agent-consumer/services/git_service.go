@@ -3,158 +3,422 @@ package services
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"time"
+	"regexp"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/config"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
 )
 
+const (
+	// Commits fetched by the first round of DeepenHistory, doubled every round
+	deepenCommits = 50
+	deepenRounds  = 4
+
+	// forkRemote is the remote issue branches are pushed to in the fork workflow
+	forkRemote = "fork"
+)
+
+var commitReferencePattern = regexp.MustCompile(`\b[0-9a-f]{7,40}\b`)
+
 type GitService struct {
-	logger *logrus.Logger
+	mirrors *MirrorCache
+	logger  *logrus.Logger
 }
 
-func NewGitService(logger *logrus.Logger) *GitService {
+// NewGitService creates the git service. Repositories are cloned through the mirror
+// cache when one is given, and directly from the remote otherwise.
+func NewGitService(mirrors *MirrorCache, logger *logrus.Logger) *GitService {
 	return &GitService{
-		logger: logger,
+		mirrors: mirrors,
+		logger:  logger,
 	}
 }
 
 type GitWorkspace struct {
 	Path       string
+	URL        string // URL of the origin remote
 	Repository *git.Repository
 	BranchName string
+	LeaseHead  string // Remote branch head a push may overwrite, set when replacing a previous run
+	Syncing    string // rebase or merge while SyncWithBase waits for conflicts to be resolved
+	ForkURL    string // Fork the issue branch is pushed to instead of origin, see UseFork
+	LFS        bool   // Git LFS filters are set up; LFS objects are pushed with the branch
 }
 
-func (s *GitService) CloneRepository(repoURL, accessToken, jiraIssueKey string) (*GitWorkspace, error) {
-	// Create temporary directory
-	tempDir := filepath.Join("/tmp", fmt.Sprintf("sdlc-%s", jiraIssueKey))
-	repoPath := filepath.Join(tempDir, "repo")
-
-	// Clean up if directory already exists
-	if _, err := os.Stat(tempDir); err == nil {
-		s.logger.Warnf("Directory %s already exists, removing", tempDir)
-		os.RemoveAll(tempDir)
+// pushRemote returns the name and URL of the remote the issue branch lives on
+func (w *GitWorkspace) pushRemote() (string, string) {
+	if w.ForkURL != "" {
+		return forkRemote, w.ForkURL
 	}
+	return "origin", w.URL
+}
 
-	// Create directory
-	if err := os.MkdirAll(repoPath, 0755); err != nil {
+// CloneRepository clones the repository into a new workspace /tmp/sdlc-<KEY>-<random>/repo.
+// Every call gets its own directory, so overlapping runs for the same issue do not share
+// a workspace. Clone settings are optional: shallow, single-branch and partial clones are
+// made from the remote, other clones from the mirror cache; sparse paths apply to both.
+func (s *GitService) CloneRepository(repoURL string, auth GitAuth, jiraIssueKey, baseBranch string, settings *models.CloneSettings) (*GitWorkspace, error) {
+	// Create temporary directory
+	tempDir, err := os.MkdirTemp("/tmp", fmt.Sprintf("sdlc-%s-", jiraIssueKey))
+	if err != nil {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
+	repoPath := filepath.Join(tempDir, "repo")
 
 	s.logger.WithFields(logrus.Fields{
 		"repository_url": repoURL,
 		"local_path":     repoPath,
 	}).Info("Cloning repository")
 
-	// Clone repository with authentication
-	repo, err := git.PlainClone(repoPath, false, &git.CloneOptions{
-		URL: repoURL,
-		Auth: &http.BasicAuth{
-			Username: "git", // Can be anything for PAT
-			Password: accessToken,
-		},
-		Progress: os.Stdout,
-	})
+	sparsePaths := []string{}
+	if settings != nil {
+		sparsePaths = settings.SparsePaths
+	}
+
+	if s.mirrors != nil && !partialClone(settings) {
+		err := s.mirrors.CloneFrom(repoURL, auth, repoPath, baseBranch, sparsePaths)
+		if err == nil {
+			return s.openClone(tempDir, repoPath, repoURL, auth, settings)
+		}
+		s.logger.WithError(err).Warn("Failed to clone from mirror, cloning from the remote")
+		os.RemoveAll(repoPath)
+	}
+
+	env, cleanup, err := auth.env(repoURL)
 	if err != nil {
+		os.RemoveAll(tempDir)
+		return nil, err
+	}
+	defer cleanup()
+	env = skipLFSSmudge(env)
+
+	if err := runGit("", env, cloneArgs(repoURL, repoPath, baseBranch, settings)...); err != nil {
 		os.RemoveAll(tempDir)
 		return nil, fmt.Errorf("failed to clone repository: %w", err)
 	}
+	if len(sparsePaths) > 0 {
+		// Checking out the sparse paths fetches their blobs in partial clones
+		if err := runGit(repoPath, env, append([]string{"sparse-checkout", "set", "--cone"}, sparsePaths...)...); err != nil {
+			os.RemoveAll(tempDir)
+			return nil, fmt.Errorf("failed to set sparse checkout: %w", err)
+		}
+	}
+
+	return s.openClone(tempDir, repoPath, repoURL, auth, settings)
+}
+
+// skipLFSSmudge keeps a git-lfs installed on the host from downloading LFS objects during
+// checkouts: clones from the mirror have no LFS endpoint, and LFS objects are only fetched
+// when the repository asks for them (see completeCheckout)
+func skipLFSSmudge(env []string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+	return append(env, "GIT_LFS_SKIP_SMUDGE=1")
+}
+
+// partialClone reports whether the clone settings call for a shallow, single-branch or
+// partial clone, which the mirror cache does not serve
+func partialClone(settings *models.CloneSettings) bool {
+	return settings != nil && (settings.Depth > 0 || settings.SingleBranch || settings.Filter != "")
+}
+
+// cloneArgs returns the arguments of git clone for the clone settings. The base branch is
+// checked out, so that issue branches start from it and not from the remote's default branch.
+func cloneArgs(repoURL, repoPath, baseBranch string, settings *models.CloneSettings) []string {
+	args := []string{"clone", "--quiet"}
+	if baseBranch != "" {
+		args = append(args, "--branch", baseBranch)
+	}
+	if settings != nil {
+		if settings.Depth > 0 {
+			args = append(args, fmt.Sprintf("--depth=%d", settings.Depth))
+			if !settings.SingleBranch {
+				// --depth implies --single-branch
+				args = append(args, "--no-single-branch")
+			}
+		}
+		if settings.SingleBranch {
+			args = append(args, "--single-branch")
+		}
+		if settings.Filter != "" {
+			args = append(args, "--filter="+settings.Filter)
+		}
+		if len(settings.SparsePaths) > 0 {
+			args = append(args, "--sparse")
+		}
+	}
+	return append(args, repoURL, repoPath)
+}
+
+// openClone opens a cloned repository and completes its checkout
+func (s *GitService) openClone(tempDir, repoPath, repoURL string, auth GitAuth, settings *models.CloneSettings) (*GitWorkspace, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	workspace := &GitWorkspace{
+		Path:       repoPath,
+		URL:        repoURL,
+		Repository: repo,
+	}
+	if err := s.completeCheckout(workspace, auth, settings); err != nil {
+		os.RemoveAll(tempDir)
+		return nil, err
+	}
 
 	s.logger.Info("Repository cloned successfully")
+	return workspace, nil
+}
+
+// completeCheckout initializes the submodules of a clone recursively and fetches its LFS
+// objects, when the clone settings ask for them. Both use the repository's credentials,
+// so submodules on the same host need no credentials of their own.
+func (s *GitService) completeCheckout(workspace *GitWorkspace, auth GitAuth, settings *models.CloneSettings) error {
+	if settings == nil || (!settings.Submodules && !settings.LFS) {
+		return nil
+	}
+
+	env, cleanup, err := auth.env(workspace.URL)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if settings.Submodules {
+		s.logger.Info("Initializing submodules")
+		if err := runGit(workspace.Path, skipLFSSmudge(env), "submodule", "update", "--init", "--recursive", "--quiet"); err != nil {
+			return fmt.Errorf("failed to initialize submodules: %w", err)
+		}
+	}
 
-	return &GitWorkspace{
+	if settings.LFS {
+		s.logger.Info("Fetching LFS objects")
+		// Filters only, without the pre-push hook: PushBranch pushes the LFS objects
+		if err := runGit(workspace.Path, env, "lfs", "install", "--local", "--skip-repo"); err != nil {
+			return fmt.Errorf("failed to set up Git LFS: %w", err)
+		}
+		if err := runGit(workspace.Path, env, "lfs", "pull"); err != nil {
+			return fmt.Errorf("failed to fetch LFS objects: %w", err)
+		}
+		if settings.Submodules {
+			if err := runGit(workspace.Path, env, "submodule", "foreach", "--recursive", "--quiet", "git lfs install --local --skip-repo && git lfs pull"); err != nil {
+				return fmt.Errorf("failed to fetch LFS objects of submodules: %w", err)
+			}
+		}
+		workspace.LFS = true
+	}
+	return nil
+}
+
+// OpenWorkspace opens a workspace kept from an earlier run. BranchName is set to the
+// checked out branch so the branch is not created again.
+func (s *GitService) OpenWorkspace(repoPath string) (*GitWorkspace, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	workspace := &GitWorkspace{
 		Path:       repoPath,
 		Repository: repo,
-	}, nil
+	}
+	if head.Name().IsBranch() {
+		workspace.BranchName = head.Name().Short()
+	}
+	if origin, err := repo.Remote("origin"); err == nil && len(origin.Config().URLs) > 0 {
+		workspace.URL = origin.Config().URLs[0]
+	}
+	if fork, err := repo.Remote(forkRemote); err == nil && len(fork.Config().URLs) > 0 {
+		workspace.ForkURL = fork.Config().URLs[0]
+	}
+	if config, err := repo.Config(); err == nil {
+		workspace.LFS = config.Raw.Section("filter").Subsection("lfs").Option("clean") != ""
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"local_path":  repoPath,
+		"branch_name": workspace.BranchName,
+	}).Info("Reopened existing workspace")
+
+	return workspace, nil
 }
 
-func (s *GitService) CreateAndCheckoutBranch(workspace *GitWorkspace, jiraIssueKey string) error {
-	branchName := fmt.Sprintf("feature/%s", jiraIssueKey)
+func (s *GitService) CreateAndCheckoutBranch(workspace *GitWorkspace, branchName string) error {
 	workspace.BranchName = branchName
 
 	s.logger.WithFields(logrus.Fields{
 		"branch_name": branchName,
 	}).Info("Creating and checking out branch")
 
-	// Get HEAD reference
-	headRef, err := workspace.Repository.Head()
-	if err != nil {
-		return fmt.Errorf("failed to get HEAD: %w", err)
+	// The git CLI is used for the branch, commit and push: unlike go-git it supports
+	// sparse checkouts and pushing from shallow and partial clones
+	if err := runGit(workspace.Path, nil, "checkout", "--quiet", "-B", branchName); err != nil {
+		return fmt.Errorf("failed to checkout branch: %w", err)
 	}
 
-	// Create new branch reference
-	refName := plumbing.NewBranchReferenceName(branchName)
-	ref := plumbing.NewHashReference(refName, headRef.Hash())
-	err = workspace.Repository.Storer.SetReference(ref)
-	if err != nil {
-		return fmt.Errorf("failed to create branch: %w", err)
+	s.logger.Infof("Checked out branch: %s", branchName)
+	return nil
+}
+
+// UseFork adds the fork as a remote of the workspace. Issue branches are then looked up on
+// and pushed to the fork, while the base branch is still fetched from origin.
+func (s *GitService) UseFork(workspace *GitWorkspace, forkURL string) error {
+	// A reopened workspace already has the remote
+	runGit(workspace.Path, nil, "remote", "remove", forkRemote)
+	if err := runGit(workspace.Path, nil, "remote", "add", forkRemote, forkURL); err != nil {
+		return fmt.Errorf("failed to add fork remote: %w", err)
 	}
+	workspace.ForkURL = forkURL
+
+	s.logger.WithField("fork_url", forkURL).Info("Pushing issue branch to fork")
+	return nil
+}
 
-	// Checkout the branch
-	worktree, err := workspace.Repository.Worktree()
+// RemoteBranchHead returns the commit a branch points to on the remote (the fork, if the
+// workspace uses one), or an empty string when the branch does not exist
+func (s *GitService) RemoteBranchHead(workspace *GitWorkspace, auth GitAuth, branchName string) (string, error) {
+	remote, remoteURL := workspace.pushRemote()
+	env, cleanup, err := auth.env(remoteURL)
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return "", err
 	}
+	defer cleanup()
 
-	err = worktree.Checkout(&git.CheckoutOptions{
-		Branch: refName,
-	})
+	cmd := exec.Command("git", "ls-remote", remote, "refs/heads/"+branchName)
+	cmd.Dir = workspace.Path
+	cmd.Env = env
+	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("failed to checkout branch: %w", err)
+		return "", fmt.Errorf("failed to list remote branch: %w", err)
 	}
 
-	s.logger.Infof("Checked out branch: %s", branchName)
-	return nil
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], nil
 }
 
-func (s *GitService) CommitChanges(workspace *GitWorkspace, jiraIssueKey, summary string) error {
-	worktree, err := workspace.Repository.Worktree()
+// CheckoutIssueBranch checks out the branch of an issue. When the branch already
+// exists on the remote from a previous run, the append policy continues from it, while the
+// replace policy starts again from the base branch and the push only overwrites the remote
+// branch if it still points to the previous head (force-with-lease). Returns the head of
+// the existing remote branch, empty when there is none.
+func (s *GitService) CheckoutIssueBranch(workspace *GitWorkspace, auth GitAuth, branchName, policy string) (string, error) {
+	head, err := s.RemoteBranchHead(workspace, auth, branchName)
+	if err != nil {
+		return "", err
+	}
+
+	if head == "" || policy == models.RerunPolicyReplace {
+		if err := s.CreateAndCheckoutBranch(workspace, branchName); err != nil {
+			return "", err
+		}
+		workspace.LeaseHead = head
+		if head != "" {
+			s.logger.WithField("previous_head", head).Info("Replacing the branch of a previous run")
+		}
+		return head, nil
+	}
+
+	remote, remoteURL := workspace.pushRemote()
+	env, cleanup, err := auth.env(remoteURL)
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return "", err
 	}
+	defer cleanup()
 
+	refSpec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", branchName, remote, branchName)
+	if err := runGit(workspace.Path, env, "fetch", "--quiet", remote, refSpec); err != nil {
+		return "", fmt.Errorf("failed to fetch branch: %w", err)
+	}
+	if err := runGit(workspace.Path, nil, "checkout", "--quiet", "-B", branchName, remote+"/"+branchName); err != nil {
+		return "", fmt.Errorf("failed to checkout branch: %w", err)
+	}
+	workspace.BranchName = branchName
+
+	s.logger.WithField("previous_head", head).Info("Continuing the branch of a previous run")
+	return head, nil
+}
+
+// CommitChanges commits all changes in the workspace with the message, and the identity,
+// signature and co-author trailers of the options
+func (s *GitService) CommitChanges(workspace *GitWorkspace, message string, options CommitOptions) error {
 	s.logger.Info("Adding changes to git")
 
 	// Add all changes
-	err = worktree.AddWithOptions(&git.AddOptions{
-		All: true,
-	})
-	if err != nil {
+	if err := runGit(workspace.Path, nil, "add", "--all"); err != nil {
 		return fmt.Errorf("failed to add changes: %w", err)
 	}
 
-	// Check if there are changes to commit
-	status, err := worktree.Status()
-	if err != nil {
-		return fmt.Errorf("failed to get status: %w", err)
+	return s.commitStaged(workspace, message, options)
+}
+
+// CommitFiles commits the changes of the given files (slash-separated paths relative to
+// the repository root, deleted files included) and leaves other changes uncommitted
+func (s *GitService) CommitFiles(workspace *GitWorkspace, files []string, message string, options CommitOptions) error {
+	s.logger.WithField("files", len(files)).Info("Adding files to git")
+
+	// Changes the agent staged itself must not end up in this commit
+	if err := runGit(workspace.Path, nil, "reset", "--quiet"); err != nil {
+		return fmt.Errorf("failed to reset index: %w", err)
 	}
+	args := append([]string{"--literal-pathspecs", "add", "--all", "--"}, files...)
+	if err := runGit(workspace.Path, nil, args...); err != nil {
+		return fmt.Errorf("failed to add files: %w", err)
+	}
+
+	return s.commitStaged(workspace, message, options)
+}
+
+// SquashCommits replaces the commits on top of base with a single commit
+func (s *GitService) SquashCommits(workspace *GitWorkspace, base, message string, options CommitOptions) error {
+	s.logger.WithField("base", base).Info("Squashing commits")
 
-	if status.IsClean() {
+	if err := runGit(workspace.Path, nil, "reset", "--quiet", "--soft", base); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", base, err)
+	}
+
+	return s.commitStaged(workspace, message, options)
+}
+
+// commitStaged commits the staged changes, if there are any
+func (s *GitService) commitStaged(workspace *GitWorkspace, message string, options CommitOptions) error {
+	// Check if there are changes to commit
+	if err := runGit(workspace.Path, nil, "diff", "--cached", "--quiet"); err == nil {
 		s.logger.Warn("No changes to commit")
 		return nil
 	}
 
 	// Create commit message
-	commitMessage := fmt.Sprintf("[%s] %s\n\nAutomated commit by SDLC AI Agent", jiraIssueKey, summary)
+	commitMessage := options.message(message)
 
 	s.logger.WithFields(logrus.Fields{
 		"message": commitMessage,
+		"author":  options.AuthorEmail,
+		"signed":  options.SigningFormat != "",
 	}).Info("Creating commit")
 
 	// Commit changes
-	_, err = worktree.Commit(commitMessage, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "SDLC AI Agent",
-			Email: "sdlc-agent@example.com",
-			When:  time.Now(),
-		},
-	})
+	env, cleanup, err := options.env()
 	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := runGit(workspace.Path, env, "commit", "--quiet", "--no-verify", "-m", commitMessage); err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
 
@@ -162,23 +426,33 @@ func (s *GitService) CommitChanges(workspace *GitWorkspace, jiraIssueKey, summar
 	return nil
 }
 
-func (s *GitService) PushBranch(workspace *GitWorkspace, accessToken string) error {
+func (s *GitService) PushBranch(workspace *GitWorkspace, auth GitAuth) error {
 	s.logger.WithFields(logrus.Fields{
 		"branch": workspace.BranchName,
 	}).Info("Pushing branch to remote")
 
 	// Push to remote
-	err := workspace.Repository.Push(&git.PushOptions{
-		RemoteName: "origin",
-		RefSpecs: []config.RefSpec{
-			config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", workspace.BranchName, workspace.BranchName)),
-		},
-		Auth: &http.BasicAuth{
-			Username: "git",
-			Password: accessToken,
-		},
-	})
+	remote, remoteURL := workspace.pushRemote()
+	env, cleanup, err := auth.env(remoteURL)
 	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if workspace.LFS {
+		// Upload the LFS objects of new commits before the commits referencing them
+		if err := runGit(workspace.Path, env, "lfs", "push", remote, workspace.BranchName); err != nil {
+			return fmt.Errorf("failed to push LFS objects: %w", err)
+		}
+	}
+
+	refSpec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", workspace.BranchName, workspace.BranchName)
+	args := []string{"push", "--quiet"}
+	if workspace.LeaseHead != "" {
+		// Overwrite the previous run, unless the branch moved since it was checked
+		args = append(args, fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", workspace.BranchName, workspace.LeaseHead))
+	}
+	if err := runGit(workspace.Path, env, append(args, remote, refSpec)...); err != nil {
 		return fmt.Errorf("failed to push branch: %w", err)
 	}
 
@@ -186,12 +460,287 @@ func (s *GitService) PushBranch(workspace *GitWorkspace, accessToken string) err
 	return nil
 }
 
+// CommitReferences returns the commit SHAs (7 to 40 hex characters, at least one digit)
+// mentioned in a text
+func CommitReferences(text string) []string {
+	commits := []string{}
+	for _, match := range commitReferencePattern.FindAllString(text, -1) {
+		if strings.ContainsAny(match, "0123456789") && strings.ContainsAny(match, "abcdef") && !contains(commits, match) {
+			commits = append(commits, match)
+		}
+	}
+	return commits
+}
+
+// IsShallow reports whether the workspace is a shallow clone
+func (s *GitService) IsShallow(workspace *GitWorkspace) bool {
+	cmd := exec.Command("git", "rev-parse", "--is-shallow-repository")
+	cmd.Dir = workspace.Path
+	output, err := cmd.Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// HasCommit reports whether a commit (full or abbreviated SHA) is in the local history of
+// the workspace. The history is listed rather than the object looked up, because looking
+// up a missing object in a partial clone fetches it from the remote.
+func (s *GitService) HasCommit(workspace *GitWorkspace, sha string) bool {
+	cmd := exec.Command("git", "rev-list", "--all")
+	cmd.Dir = workspace.Path
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	for _, commit := range strings.Fields(string(output)) {
+		if strings.HasPrefix(commit, strings.ToLower(sha)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeepenHistory fetches more history into a shallow workspace until satisfied reports
+// that the history needed is present. Every round doubles the number of commits fetched;
+// after deepenRounds the workspace is left as it is and false is returned.
+func (s *GitService) DeepenHistory(workspace *GitWorkspace, auth GitAuth, satisfied func() bool) (bool, error) {
+	if satisfied() {
+		return true, nil
+	}
+
+	env, cleanup, err := auth.env(workspace.URL)
+	if err != nil {
+		return false, err
+	}
+	defer cleanup()
+
+	deepen := deepenCommits
+	for round := 0; round < deepenRounds && s.IsShallow(workspace); round++ {
+		s.logger.WithField("deepen", deepen).Info("Deepening shallow clone")
+		if err := runGit(workspace.Path, env, "fetch", "--quiet", fmt.Sprintf("--deepen=%d", deepen), "origin"); err != nil {
+			return false, fmt.Errorf("failed to deepen clone: %w", err)
+		}
+		if satisfied() {
+			return true, nil
+		}
+		deepen *= 2
+	}
+	return false, nil
+}
+
+// WorkingDiff returns the uncommitted changes in the workspace, including new files.
+// The index is left untouched so CommitChanges stays the only place that stages files.
+func (s *GitService) WorkingDiff(workspace *GitWorkspace) (string, error) {
+	diffCmd := exec.Command("git", "diff", "HEAD")
+	diffCmd.Dir = workspace.Path
+	output, err := diffCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff: %w", err)
+	}
+
+	var diff strings.Builder
+	diff.Write(output)
+
+	untrackedCmd := exec.Command("git", "ls-files", "--others", "--exclude-standard")
+	untrackedCmd.Dir = workspace.Path
+	untracked, err := untrackedCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	for _, file := range strings.Split(strings.TrimSpace(string(untracked)), "\n") {
+		if file == "" {
+			continue
+		}
+		// git diff --no-index exits with 1 when files differ, so ignore the error
+		fileCmd := exec.Command("git", "diff", "--no-index", "--", "/dev/null", file)
+		fileCmd.Dir = workspace.Path
+		fileDiff, _ := fileCmd.Output()
+		diff.Write(fileDiff)
+	}
+
+	return diff.String(), nil
+}
+
+// AddWorktree checks a commit out into a temporary directory next to the workspace, so it
+// can be built on its own. The returned function removes the worktree.
+func (s *GitService) AddWorktree(workspace *GitWorkspace, commit string) (string, func(), error) {
+	dir, err := os.MkdirTemp(filepath.Dir(workspace.Path), "worktree-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+
+	if err := runGit(workspace.Path, nil, "worktree", "add", "--quiet", "--detach", dir, commit); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("failed to add worktree: %w", err)
+	}
+
+	return dir, func() {
+		if err := runGit(workspace.Path, nil, "worktree", "remove", "--force", dir); err != nil {
+			s.logger.WithError(err).Warn("Failed to remove worktree")
+			os.RemoveAll(dir)
+		}
+	}, nil
+}
+
+// WorkingTreeSnapshot records the files of a workspace in a separate index, so changes
+// made afterwards (by verification commands, for example) can be undone with
+// RestoreWorkingTree. Ignored files are not recorded.
+type WorkingTreeSnapshot struct {
+	workspace *GitWorkspace
+	indexFile string
+}
+
+// SnapshotWorkingTree records the current files of the workspace, uncommitted changes
+// included. The workspace's index is left untouched.
+func (s *GitService) SnapshotWorkingTree(workspace *GitWorkspace) (*WorkingTreeSnapshot, error) {
+	index, err := os.CreateTemp(filepath.Dir(workspace.Path), "snapshot-index-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot index: %w", err)
+	}
+	index.Close()
+	// git refuses an empty file as an index
+	os.Remove(index.Name())
+
+	snapshot := &WorkingTreeSnapshot{workspace: workspace, indexFile: index.Name()}
+	env := snapshot.env()
+	if err := runGit(workspace.Path, env, "read-tree", "HEAD"); err != nil {
+		snapshot.discard()
+		return nil, fmt.Errorf("failed to snapshot working tree: %w", err)
+	}
+	if err := runGit(workspace.Path, env, "add", "--all"); err != nil {
+		snapshot.discard()
+		return nil, fmt.Errorf("failed to snapshot working tree: %w", err)
+	}
+	return snapshot, nil
+}
+
+// RestoreWorkingTree undoes the changes made to the workspace since the snapshot: changed
+// and deleted files are restored and new files removed, unless they are ignored. Returns the
+// restored and removed paths. The snapshot cannot be used again.
+func (s *GitService) RestoreWorkingTree(snapshot *WorkingTreeSnapshot) ([]string, error) {
+	defer snapshot.discard()
+	workspace := snapshot.workspace
+	env := snapshot.env()
+
+	newFiles, err := gitPaths(workspace.Path, env, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list new files: %w", err)
+	}
+	for _, file := range newFiles {
+		if err := os.Remove(filepath.Join(workspace.Path, filepath.FromSlash(file))); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+
+	changedFiles, err := gitPaths(workspace.Path, env, "diff", "-z", "--name-only")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	if len(changedFiles) > 0 {
+		args := append([]string{"checkout-index", "--force", "--"}, changedFiles...)
+		if err := runGit(workspace.Path, env, args...); err != nil {
+			return nil, fmt.Errorf("failed to restore changed files: %w", err)
+		}
+	}
+
+	return append(newFiles, changedFiles...), nil
+}
+
+func (snapshot *WorkingTreeSnapshot) env() []string {
+	return append(os.Environ(), "GIT_INDEX_FILE="+snapshot.indexFile)
+}
+
+func (snapshot *WorkingTreeSnapshot) discard() {
+	os.Remove(snapshot.indexFile)
+}
+
+// gitPaths runs a git command listing NUL-separated paths
+func gitPaths(dir string, env []string, args ...string) ([]string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// HeadCommit returns the SHA of the commit checked out in the workspace
+func (s *GitService) HeadCommit(workspace *GitWorkspace) (string, error) {
+	head, err := workspace.Repository.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	return head.Hash().String(), nil
+}
+
+// FileChange is a file changed in the workspace. OldPath is set for renames and copies.
+type FileChange struct {
+	Path    string
+	OldPath string
+}
+
+// StatusChanges lists the files changed, added, deleted or renamed in the workspace since
+// the last commit, as slash-separated paths relative to the repository root
+func (s *GitService) StatusChanges(workspace *GitWorkspace) ([]FileChange, error) {
+	// -z leaves paths unquoted, so names with spaces or non-ASCII characters come through as is
+	cmd := exec.Command("git", "status", "--porcelain", "-z", "--untracked-files=all")
+	cmd.Dir = workspace.Path
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+
+	changes := []FileChange{}
+	entries := strings.Split(string(output), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		change := FileChange{Path: entry[3:]}
+		// Renames and copies, staged (X) or in the working tree (Y, e.g. for intent-to-add
+		// files), are followed by the entry of their source path
+		x, y := entry[0], entry[1]
+		if (x == 'R' || x == 'C' || y == 'R' || y == 'C') && i+1 < len(entries) {
+			i++
+			change.OldPath = entries[i]
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// ChangedFiles lists the files changed, added or deleted in the workspace since the last
+// commit, as slash-separated paths relative to the repository root. Renamed files are
+// listed under their new path.
+func (s *GitService) ChangedFiles(workspace *GitWorkspace) ([]string, error) {
+	changes, err := s.StatusChanges(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, change.Path)
+	}
+	return files, nil
+}
+
 func (s *GitService) Cleanup(workspace *GitWorkspace) error {
 	if workspace == nil || workspace.Path == "" {
 		return nil
 	}
 
-	// Get parent directory (/tmp/sdlc-{jira_issue_key})
+	// Get parent directory (/tmp/sdlc-{jira_issue_key}-{random})
 	tempDir := filepath.Dir(workspace.Path)
 
 	s.logger.WithFields(logrus.Fields{
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestGitService_ShallowSparseClone(t *testing.T) {
+	remote, work := newTestRemote(t)
+	gitTest(t, remote, "config", "uploadpack.allowFilter", "true")
+	firstCommit := gitTest(t, work, "rev-parse", "HEAD")
+
+	// Three more commits touching two directories
+	for _, file := range []string{"services/a.go", "web/app.ts", "services/b.go"} {
+		path := filepath.Join(work, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("// "+file+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitTest(t, work, "add", ".")
+		gitTest(t, work, "commit", "--quiet", "-m", "Add "+file)
+	}
+	gitTest(t, work, "push", "--quiet", "origin", "HEAD:main")
+
+	service := NewGitService(nil, logrus.New())
+	workspace, err := service.CloneRepository("file://"+remote, GitAuth{}, "TEST-1", "main", &models.CloneSettings{
+		Depth:        1,
+		SingleBranch: true,
+		Filter:       "blob:none",
+		SparsePaths:  []string{"services"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to clone repository: %v", err)
+	}
+	defer service.Cleanup(workspace)
+
+	if !service.IsShallow(workspace) {
+		t.Error("Expected a shallow clone")
+	}
+	if _, err := os.Stat(filepath.Join(workspace.Path, "services", "b.go")); err != nil {
+		t.Error("Expected the sparse path to be checked out")
+	}
+	if _, err := os.Stat(filepath.Join(workspace.Path, "web")); !os.IsNotExist(err) {
+		t.Error("Did not expect paths outside the sparse checkout")
+	}
+
+	// The first commit is three commits behind the shallow boundary
+	if service.HasCommit(workspace, firstCommit) {
+		t.Fatal("Did not expect the first commit in a clone of depth 1")
+	}
+	found, err := service.DeepenHistory(workspace, GitAuth{}, func() bool {
+		return service.HasCommit(workspace, firstCommit)
+	})
+	if err != nil || !found {
+		t.Fatalf("Expected deepening to fetch the first commit, got %v, %v", found, err)
+	}
+
+	// Branch, commit and push work in the sparse, partial clone
+	if err := service.CreateAndCheckoutBranch(workspace, "feature/TEST-1"); err != nil {
+		t.Fatalf("Failed to create branch: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace.Path, "services", "c.go"), []byte("package services\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.CommitChanges(workspace, "Add c", CommitOptions{}); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	files := gitTest(t, workspace.Path, "show", "--name-status", "--format=", "HEAD")
+	if files != "A\tservices/c.go" {
+		t.Errorf("Expected only the new file in the commit, got %q", files)
+	}
+	if err := service.PushBranch(workspace, GitAuth{}); err != nil {
+		t.Fatalf("Failed to push: %v", err)
+	}
+	if gitTest(t, remote, "rev-parse", "feature/TEST-1") != gitTest(t, workspace.Path, "rev-parse", "HEAD") {
+		t.Error("Expected the branch to be pushed")
+	}
+}
+
+func TestCommitReferences(t *testing.T) {
+	text := "Regression since 3f2a9c1, see also 3f2a9c1 and 0123456789abcdef0123456789abcdef01234567. Not: 12345678, deadbeef, facade."
+	expected := []string{"3f2a9c1", "0123456789abcdef0123456789abcdef01234567"}
+	if got := CommitReferences(text); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestGitService_CheckoutIssueBranch(t *testing.T) {
+	remote, work := newTestRemote(t)
+	base := gitTest(t, work, "rev-parse", "HEAD")
+	service := NewGitService(nil, logrus.New())
+
+	commitAndPush := func(workspace *GitWorkspace, file string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(workspace.Path, file), []byte(file+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.CommitChanges(workspace, "Add "+file, CommitOptions{}); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+		if err := service.PushBranch(workspace, GitAuth{}); err != nil {
+			t.Fatalf("Failed to push: %v", err)
+		}
+		return gitTest(t, workspace.Path, "rev-parse", "HEAD")
+	}
+	clone := func() *GitWorkspace {
+		t.Helper()
+		workspace, err := service.CloneRepository("file://"+remote, GitAuth{}, "TEST-2", "main", nil)
+		if err != nil {
+			t.Fatalf("Failed to clone repository: %v", err)
+		}
+		t.Cleanup(func() { service.Cleanup(workspace) })
+		return workspace
+	}
+
+	// First run: no remote branch yet
+	first := clone()
+	if head, err := service.CheckoutIssueBranch(first, GitAuth{}, "feature/TEST-2", models.RerunPolicyAppend); err != nil || head != "" {
+		t.Fatalf("Expected no previous branch, got %q, %v", head, err)
+	}
+	firstHead := commitAndPush(first, "first.txt")
+
+	// Append: the second run continues the branch
+	second := clone()
+	head, err := service.CheckoutIssueBranch(second, GitAuth{}, "feature/TEST-2", models.RerunPolicyAppend)
+	if err != nil || head != firstHead {
+		t.Fatalf("Expected the previous head %s, got %q, %v", firstHead, head, err)
+	}
+	secondHead := commitAndPush(second, "second.txt")
+	if parent := gitTest(t, remote, "rev-parse", "feature/TEST-2^"); parent != firstHead {
+		t.Errorf("Expected the commit on top of the previous run, parent is %s", parent)
+	}
+
+	// Replace: the third run starts again from the base branch and overwrites the branch
+	third := clone()
+	if head, err := service.CheckoutIssueBranch(third, GitAuth{}, "feature/TEST-2", models.RerunPolicyReplace); err != nil || head != secondHead {
+		t.Fatalf("Expected the previous head %s, got %q, %v", secondHead, head, err)
+	}
+	commitAndPush(third, "third.txt")
+	if parent := gitTest(t, remote, "rev-parse", "feature/TEST-2^"); parent != base {
+		t.Errorf("Expected the branch to be replaced by a commit on the base branch, parent is %s", parent)
+	}
+
+	// The lease protects commits pushed after the branch was checked out
+	fourth := clone()
+	if _, err := service.CheckoutIssueBranch(fourth, GitAuth{}, "feature/TEST-2", models.RerunPolicyReplace); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, work, "fetch", "--quiet", "origin")
+	gitTest(t, work, "checkout", "--quiet", "-B", "feature/TEST-2", "origin/feature/TEST-2")
+	gitTest(t, work, "commit", "--quiet", "--allow-empty", "-m", "Reviewer fix")
+	gitTest(t, work, "push", "--quiet", "origin", "feature/TEST-2")
+	if err := os.WriteFile(filepath.Join(fourth.Path, "fourth.txt"), []byte("fourth\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.CommitChanges(fourth, "Add fourth", CommitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.PushBranch(fourth, GitAuth{}); err == nil {
+		t.Error("Expected the push to be rejected after the branch moved")
+	}
+}
+
+func TestGitService_CloneBaseBranch(t *testing.T) {
+	remote, work := newTestRemote(t)
+
+	// The base branch is not the remote's default branch, which moves on separately
+	gitTest(t, work, "checkout", "--quiet", "-b", "develop")
+	gitTest(t, work, "commit", "--quiet", "--allow-empty", "-m", "Develop commit")
+	gitTest(t, work, "push", "--quiet", "origin", "develop")
+	developHead := gitTest(t, work, "rev-parse", "HEAD")
+	gitTest(t, work, "checkout", "--quiet", "main")
+	gitTest(t, work, "commit", "--quiet", "--allow-empty", "-m", "Main commit")
+	gitTest(t, work, "push", "--quiet", "origin", "main")
+
+	gitServices := map[string]*GitService{
+		"remote": NewGitService(nil, logrus.New()),
+		"mirror": NewGitService(NewMirrorCache(t.TempDir(), 0, logrus.New()), logrus.New()),
+	}
+	for name, service := range gitServices {
+		t.Run(name, func(t *testing.T) {
+			workspace, err := service.CloneRepository("file://"+remote, GitAuth{}, "TEST-3", "develop", nil)
+			if err != nil {
+				t.Fatalf("Failed to clone repository: %v", err)
+			}
+			defer service.Cleanup(workspace)
+
+			if err := service.CreateAndCheckoutBranch(workspace, "feature/TEST-3"); err != nil {
+				t.Fatalf("Failed to create branch: %v", err)
+			}
+			if head := gitTest(t, workspace.Path, "rev-parse", "HEAD"); head != developHead {
+				t.Errorf("Expected the issue branch to start from the base branch %s, got %s", developHead, head)
+			}
+		})
+	}
+}
+
+// allowFileSubmodules lets git clone submodules from local paths, which it refuses by default
+func allowFileSubmodules(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+}
+
+func TestGitService_CloneSubmodules(t *testing.T) {
+	allowFileSubmodules(t)
+	remote, work := newTestRemote(t)
+	library, libraryWork := newTestRemote(t)
+	if err := os.WriteFile(filepath.Join(libraryWork, "lib.go"), []byte("package lib\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, libraryWork, "add", ".")
+	gitTest(t, libraryWork, "commit", "--quiet", "-m", "Add library")
+	gitTest(t, libraryWork, "push", "--quiet", "origin", "HEAD:main")
+
+	gitTest(t, work, "submodule", "add", "--quiet", "file://"+library, "vendor/lib")
+	gitTest(t, work, "commit", "--quiet", "-m", "Add submodule")
+	gitTest(t, work, "push", "--quiet", "origin", "HEAD:main")
+
+	service := NewGitService(NewMirrorCache(t.TempDir(), 0, logrus.New()), logrus.New())
+	for _, submodules := range []bool{false, true} {
+		workspace, err := service.CloneRepository("file://"+remote, GitAuth{}, "TEST-5", "main", &models.CloneSettings{Submodules: submodules})
+		if err != nil {
+			t.Fatalf("Failed to clone repository: %v", err)
+		}
+		defer service.Cleanup(workspace)
+
+		_, err = os.Stat(filepath.Join(workspace.Path, "vendor", "lib", "lib.go"))
+		if submodules && err != nil {
+			t.Errorf("Expected the submodule to be checked out: %v", err)
+		}
+		if !submodules && err == nil {
+			t.Error("Did not expect the submodule without the setting")
+		}
+	}
+}
+
+func TestGitService_CloneLFS(t *testing.T) {
+	if err := exec.Command("git", "lfs", "version").Run(); err != nil {
+		t.Skip("git-lfs is not installed")
+	}
+	remote, work := newTestRemote(t)
+	gitTest(t, work, "lfs", "install", "--local")
+	gitTest(t, work, "lfs", "track", "*.bin")
+	if err := os.WriteFile(filepath.Join(work, "asset.bin"), []byte("binary asset\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, work, "add", ".")
+	gitTest(t, work, "commit", "--quiet", "-m", "Add asset")
+	gitTest(t, work, "push", "--quiet", "origin", "HEAD:main")
+
+	service := NewGitService(nil, logrus.New())
+	workspace, err := service.CloneRepository("file://"+remote, GitAuth{}, "TEST-6", "main", &models.CloneSettings{LFS: true})
+	if err != nil {
+		t.Fatalf("Failed to clone repository: %v", err)
+	}
+	defer service.Cleanup(workspace)
+	if content, _ := os.ReadFile(filepath.Join(workspace.Path, "asset.bin")); string(content) != "binary asset\n" {
+		t.Errorf("Expected the LFS object instead of the pointer, got %q", content)
+	}
+
+	if err := service.CreateAndCheckoutBranch(workspace, "feature/TEST-6"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace.Path, "new.bin"), []byte("new asset\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.CommitChanges(workspace, "Add new asset", CommitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.PushBranch(workspace, GitAuth{}); err != nil {
+		t.Fatalf("Failed to push LFS objects: %v", err)
+	}
+	if pointer := gitTest(t, remote, "show", "feature/TEST-6:new.bin"); !strings.HasPrefix(pointer, "version https://git-lfs") {
+		t.Errorf("Expected a pointer file to be committed, got %q", pointer)
+	}
+}
+
+func TestGitService_RestoreWorkingTree(t *testing.T) {
+	remote, _ := newTestRemote(t)
+	service := NewGitService(nil, logrus.New())
+	workspace, err := service.CloneRepository("file://"+remote, GitAuth{}, "TEST-5", "main", nil)
+	if err != nil {
+		t.Fatalf("Failed to clone repository: %v", err)
+	}
+	t.Cleanup(func() { service.Cleanup(workspace) })
+
+	write := func(file, content string) {
+		t.Helper()
+		path := filepath.Join(workspace.Path, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The agent's changes
+	write(".gitignore", "cache/\n")
+	write("main.go", "package main\n")
+	write("README.md", "# App\n\nAgent\n")
+	gitTest(t, workspace.Path, "add", "README.md")
+
+	snapshot, err := service.SnapshotWorkingTree(workspace)
+	if err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+
+	// Changes made by verification commands
+	write("README.md", "# App\n\nBuild\n")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("package-lock.json", "{}\n")
+	write("dist/app.js", "app\n")
+	write("cache/data", "data\n")
+
+	restored, err := service.RestoreWorkingTree(snapshot)
+	if err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if len(restored) != 4 {
+		t.Errorf("Expected 4 restored files, got %v", restored)
+	}
+
+	for file, expected := range map[string]string{
+		"README.md":  "# App\n\nAgent\n",
+		"main.go":    "package main\n",
+		"cache/data": "data\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(workspace.Path, file))
+		if err != nil || string(content) != expected {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", file, expected, content, err)
+		}
+	}
+	for _, file := range []string{"package-lock.json", "dist/app.js"} {
+		if _, err := os.Stat(filepath.Join(workspace.Path, file)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", file, err)
+		}
+	}
+	if staged := gitTest(t, workspace.Path, "diff", "--cached", "--name-only"); staged != "README.md" {
+		t.Errorf("Expected the index to be untouched, got %q", staged)
+	}
+	if _, err := os.Stat(snapshot.indexFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the snapshot index to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a pattern of a .gitignore file
+type ignoreRule struct {
+	base     string   // Directory of the .gitignore, "" for the repository root
+	segments []string // Pattern split on "/"
+	negate   bool     // "!pattern" re-includes a path
+	dirOnly  bool     // "pattern/" only matches directories
+	anchored bool     // Patterns containing a slash match relative to base only
+}
+
+// gitignore matches paths against the .gitignore files loaded so far. Later rules
+// win over earlier ones, like in git.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// load reads the .gitignore of a directory (slash-separated, "" for the root) if there is one
+func (g *gitignore) load(repoPath, dir string) {
+	file, err := os.Open(filepath.Join(repoPath, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		rule.segments = strings.Split(line, "/")
+		g.rules = append(g.rules, rule)
+	}
+}
+
+// ignored reports whether a slash-separated path relative to the repository root is ignored
+func (g *gitignore) ignored(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.matches(relPath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(relPath, r.base+"/") {
+			return false
+		}
+		relPath = relPath[len(r.base)+1:]
+	}
+
+	segments := strings.Split(relPath, "/")
+	if r.anchored {
+		return matchGlobSegments(r.segments, segments)
+	}
+	// Patterns without a slash match at any depth
+	return matchGlobSegments(append([]string{"**"}, r.segments...), segments)
+}
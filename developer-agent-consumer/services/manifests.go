@@ -0,0 +1,622 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+// frameworkRule maps a dependency name prefix to the framework it indicates
+type frameworkRule struct {
+	prefix    string
+	framework string
+}
+
+var (
+	// Application frameworks by ecosystem, matched against dependency names
+	frameworkRules = map[string][]frameworkRule{
+		models.EcosystemGo: {
+			{"github.com/gin-gonic/gin", "Gin"},
+			{"github.com/labstack/echo", "Echo"},
+			{"github.com/gofiber/fiber", "Fiber"},
+			{"github.com/go-chi/chi", "Chi"},
+			{"github.com/gorilla/mux", "Gorilla Mux"},
+			{"google.golang.org/grpc", "gRPC"},
+		},
+		models.EcosystemNode: {
+			{"react", "React"},
+			{"next", "Next.js"},
+			{"vue", "Vue"},
+			{"@angular/core", "Angular"},
+			{"svelte", "Svelte"},
+			{"vite", "Vite"},
+			{"express", "Express"},
+			{"@nestjs/core", "NestJS"},
+		},
+		models.EcosystemPython: {
+			{"django", "Django"},
+			{"flask", "Flask"},
+			{"fastapi", "FastAPI"},
+		},
+		models.EcosystemJava: {
+			{"org.springframework.boot:", "Spring Boot"},
+			{"org.springframework:", "Spring"},
+			{"io.quarkus:", "Quarkus"},
+		},
+		models.EcosystemRust: {
+			{"actix-web", "Actix Web"},
+			{"axum", "Axum"},
+			{"rocket", "Rocket"},
+		},
+	}
+
+	// Test frameworks by ecosystem, matched against dependency names
+	testFrameworkRules = map[string][]frameworkRule{
+		models.EcosystemGo: {
+			{"github.com/stretchr/testify", "testify"},
+			{"github.com/onsi/ginkgo", "Ginkgo"},
+		},
+		models.EcosystemNode: {
+			{"jest", "Jest"},
+			{"vitest", "Vitest"},
+			{"mocha", "Mocha"},
+			{"@playwright/test", "Playwright"},
+			{"cypress", "Cypress"},
+			{"@testing-library/", "Testing Library"},
+		},
+		models.EcosystemPython: {
+			{"pytest", "pytest"},
+			{"hypothesis", "Hypothesis"},
+		},
+		models.EcosystemJava: {
+			{"org.junit.jupiter:", "JUnit 5"},
+			{"junit:junit", "JUnit 4"},
+			{"org.testng:", "TestNG"},
+			{"org.mockito:", "Mockito"},
+		},
+		models.EcosystemRust: {
+			{"proptest", "proptest"},
+		},
+	}
+
+	// Test frameworks built into the toolchain
+	builtinTestFrameworks = map[string]string{
+		models.EcosystemGo:   "go test",
+		models.EcosystemRust: "cargo test",
+	}
+
+	// pythonRequirementPattern splits a PEP 508 requirement into name and version constraint
+	pythonRequirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*([^;]*)`)
+)
+
+// detectManifests parses the dependency manifests among the config files
+func (s *AnalyzerService) detectManifests(repoPath string, analysis *models.RepositoryAnalysis) []models.Manifest {
+	manifests := []models.Manifest{}
+
+	for _, configFile := range analysis.ConfigFiles {
+		path := filepath.Join(repoPath, configFile)
+
+		var manifest *models.Manifest
+		var err error
+		switch filepath.Base(configFile) {
+		case "go.mod":
+			manifest, err = parseGoMod(path)
+		case "package.json":
+			manifest, err = parsePackageJSON(path)
+		case "requirements.txt":
+			manifest, err = parseRequirementsTxt(path)
+		case "pyproject.toml":
+			manifest, err = parsePyprojectToml(path)
+		case "pom.xml":
+			manifest, err = parsePomXML(path)
+		case "Cargo.toml":
+			manifest, err = parseCargoToml(path)
+		default:
+			continue
+		}
+		if err != nil {
+			s.logger.WithError(err).WithField("file", configFile).Warn("Failed to parse manifest")
+			continue
+		}
+
+		manifest.Path = filepath.ToSlash(configFile)
+		manifest.Frameworks = matchFrameworks(manifest, frameworkRules[manifest.Ecosystem])
+		manifest.TestFrameworks = matchFrameworks(manifest, testFrameworkRules[manifest.Ecosystem])
+		if builtin, ok := builtinTestFrameworks[manifest.Ecosystem]; ok {
+			manifest.TestFrameworks = append([]string{builtin}, manifest.TestFrameworks...)
+		}
+		manifests = append(manifests, *manifest)
+	}
+
+	return manifests
+}
+
+// matchFrameworks returns the frameworks whose rule matches a dependency of the manifest,
+// in rule order
+func matchFrameworks(manifest *models.Manifest, rules []frameworkRule) []string {
+	frameworks := []string{}
+	for _, rule := range rules {
+		if contains(frameworks, rule.framework) {
+			continue
+		}
+		for _, dependency := range manifest.Dependencies {
+			if dependencyMatches(dependency.Name, rule.prefix) {
+				frameworks = append(frameworks, rule.framework)
+				break
+			}
+		}
+	}
+	return frameworks
+}
+
+// dependencyMatches matches a dependency name against a rule prefix. Prefixes ending in
+// a separator match any name below them; others match the name itself, a Go major
+// version suffix (/v4) or a sub-package.
+func dependencyMatches(name, prefix string) bool {
+	if strings.HasSuffix(prefix, ":") || strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(name, prefix)
+	}
+	return name == prefix || strings.HasPrefix(name, prefix+"/")
+}
+
+// parseGoMod reads the module path, go version and direct requirements of a go.mod
+func parseGoMod(path string) (*models.Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifest := &models.Manifest{Ecosystem: models.EcosystemGo}
+	block := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		indirect := strings.Contains(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+
+		if block != "" {
+			if line == ")" {
+				block = ""
+			} else if block == "require" && !indirect {
+				manifest.Dependencies = appendGoRequirement(manifest.Dependencies, strings.Fields(line))
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+		case fields[0] == "module" && len(fields) > 1:
+			manifest.Name = strings.Trim(fields[1], `"`)
+		case fields[0] == "go" && len(fields) > 1:
+			manifest.LanguageVersion = fields[1]
+		case fields[0] == "require" && !indirect:
+			manifest.Dependencies = appendGoRequirement(manifest.Dependencies, fields[1:])
+		}
+	}
+
+	return manifest, scanner.Err()
+}
+
+func appendGoRequirement(dependencies []models.Dependency, fields []string) []models.Dependency {
+	if len(fields) < 2 {
+		return dependencies
+	}
+	return append(dependencies, models.Dependency{Name: fields[0], Version: fields[1]})
+}
+
+// parsePackageJSON reads the name, node engine, dependencies and scripts of a package.json
+func parsePackageJSON(path string) (*models.Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Name            string            `json:"name"`
+		Engines         map[string]string `json:"engines"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+		Scripts         map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil, err
+	}
+
+	manifest := &models.Manifest{
+		Ecosystem:       models.EcosystemNode,
+		Name:            pkg.Name,
+		LanguageVersion: pkg.Engines["node"],
+		Scripts:         pkg.Scripts,
+	}
+	manifest.Dependencies = append(manifest.Dependencies, sortedDependencies(pkg.Dependencies, false)...)
+	manifest.Dependencies = append(manifest.Dependencies, sortedDependencies(pkg.DevDependencies, true)...)
+
+	return manifest, nil
+}
+
+// parseRequirementsTxt reads the requirements of a pip requirements file
+func parseRequirementsTxt(path string) (*models.Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifest := &models.Manifest{Ecosystem: models.EcosystemPython}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		// Skip comments, options (-r, -e, --index-url) and direct references
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		if dependency, ok := parsePythonRequirement(line); ok {
+			manifest.Dependencies = append(manifest.Dependencies, dependency)
+		}
+	}
+
+	return manifest, scanner.Err()
+}
+
+// parsePythonRequirement parses a PEP 508 requirement such as "Django>=4.2; python_version>'3.8'"
+func parsePythonRequirement(requirement string) (models.Dependency, bool) {
+	match := pythonRequirementPattern.FindStringSubmatch(strings.TrimSpace(requirement))
+	if match == nil {
+		return models.Dependency{}, false
+	}
+	return models.Dependency{
+		Name:    normalizePythonName(match[1]),
+		Version: strings.TrimSpace(match[3]),
+	}, true
+}
+
+// normalizePythonName normalizes a Python distribution name (PEP 503)
+func normalizePythonName(name string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+}
+
+// parsePyprojectToml reads PEP 621 ([project]) or Poetry ([tool.poetry]) metadata
+func parsePyprojectToml(path string) (*models.Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables := parseTOMLTables(string(content))
+
+	manifest := &models.Manifest{Ecosystem: models.EcosystemPython}
+	if project, ok := tables["project"]; ok {
+		manifest.Name = tomlString(project["name"])
+		manifest.LanguageVersion = tomlString(project["requires-python"])
+		for _, requirement := range tomlStringArray(project["dependencies"]) {
+			if dependency, ok := parsePythonRequirement(requirement); ok {
+				manifest.Dependencies = append(manifest.Dependencies, dependency)
+			}
+		}
+		for _, raw := range tables["project.optional-dependencies"] {
+			for _, requirement := range tomlStringArray(raw) {
+				if dependency, ok := parsePythonRequirement(requirement); ok {
+					dependency.Dev = true
+					manifest.Dependencies = append(manifest.Dependencies, dependency)
+				}
+			}
+		}
+		manifest.Scripts = tomlStringTable(tables["project.scripts"])
+	}
+
+	if poetry, ok := tables["tool.poetry"]; ok {
+		if manifest.Name == "" {
+			manifest.Name = tomlString(poetry["name"])
+		}
+		for _, name := range []string{"tool.poetry.dependencies", "tool.poetry.dev-dependencies", "tool.poetry.group.dev.dependencies", "tool.poetry.group.test.dependencies"} {
+			dev := name != "tool.poetry.dependencies"
+			for _, dependency := range tomlDependencies(tables[name], dev) {
+				if dependency.Name == "python" {
+					if manifest.LanguageVersion == "" {
+						manifest.LanguageVersion = dependency.Version
+					}
+					continue
+				}
+				dependency.Name = normalizePythonName(dependency.Name)
+				manifest.Dependencies = append(manifest.Dependencies, dependency)
+			}
+		}
+		if len(manifest.Scripts) == 0 {
+			manifest.Scripts = tomlStringTable(tables["tool.poetry.scripts"])
+		}
+	}
+
+	return manifest, nil
+}
+
+// parseCargoToml reads the package name, rust version and dependencies of a Cargo.toml
+func parseCargoToml(path string) (*models.Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables := parseTOMLTables(string(content))
+
+	manifest := &models.Manifest{
+		Ecosystem:       models.EcosystemRust,
+		Name:            tomlString(tables["package"]["name"]),
+		LanguageVersion: tomlString(tables["package"]["rust-version"]),
+	}
+	if manifest.LanguageVersion == "" {
+		if edition := tomlString(tables["package"]["edition"]); edition != "" {
+			manifest.LanguageVersion = "edition " + edition
+		}
+	}
+	manifest.Dependencies = append(manifest.Dependencies, tomlDependencies(tables["dependencies"], false)...)
+	manifest.Dependencies = append(manifest.Dependencies, tomlDependencies(tables["dev-dependencies"], true)...)
+
+	return manifest, nil
+}
+
+// pomProject is the part of a Maven POM the analyzer reads
+type pomProject struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Parent     struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Dependencies []struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+		Scope      string `xml:"scope"`
+	} `xml:"dependencies>dependency"`
+}
+
+// parsePomXML reads the artifact, java version and dependencies of a Maven POM.
+// The parent POM counts as a dependency so that starter parents reveal the framework.
+func parsePomXML(path string) (*models.Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pom pomProject
+	if err := xml.Unmarshal(content, &pom); err != nil {
+		return nil, err
+	}
+
+	properties := map[string]string{}
+	for _, entry := range pom.Properties.Entries {
+		properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	resolve := func(value string) string {
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
+			if resolved, ok := properties[value[2:len(value)-1]]; ok {
+				return resolved
+			}
+		}
+		return value
+	}
+
+	manifest := &models.Manifest{
+		Ecosystem: models.EcosystemJava,
+		Name:      pom.ArtifactID,
+	}
+	if pom.GroupID != "" {
+		manifest.Name = pom.GroupID + ":" + pom.ArtifactID
+	}
+	for _, property := range []string{"java.version", "maven.compiler.release", "maven.compiler.source"} {
+		if version := properties[property]; version != "" {
+			manifest.LanguageVersion = resolve(version)
+			break
+		}
+	}
+
+	if pom.Parent.ArtifactID != "" {
+		manifest.Dependencies = append(manifest.Dependencies, models.Dependency{
+			Name:    pom.Parent.GroupID + ":" + pom.Parent.ArtifactID,
+			Version: resolve(pom.Parent.Version),
+		})
+	}
+	for _, dependency := range pom.Dependencies {
+		manifest.Dependencies = append(manifest.Dependencies, models.Dependency{
+			Name:    dependency.GroupID + ":" + dependency.ArtifactID,
+			Version: resolve(dependency.Version),
+			Dev:     dependency.Scope == "test",
+		})
+	}
+
+	return manifest, nil
+}
+
+// sortedDependencies turns a name to version map into dependencies sorted by name
+func sortedDependencies(versions map[string]string, dev bool) []models.Dependency {
+	dependencies := make([]models.Dependency, 0, len(versions))
+	for name, version := range versions {
+		dependencies = append(dependencies, models.Dependency{Name: name, Version: version, Dev: dev})
+	}
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].Name < dependencies[j].Name
+	})
+	return dependencies
+}
+
+// parseTOMLTables parses the subset of TOML used by pyproject.toml and Cargo.toml: tables of
+// key = value pairs whose values are strings, inline tables or (multi-line) arrays. Values
+// are kept raw and keyed by the dotted table name; top-level keys use the "" table.
+func parseTOMLTables(content string) map[string]map[string]string {
+	tables := map[string]map[string]string{"": {}}
+	table := ""
+	key, value := "", ""
+	depth := 0
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+
+		// Continuation of a multi-line array or inline table
+		if depth > 0 {
+			value += " " + line
+			depth += tomlBracketDepth(line)
+			if depth <= 0 {
+				tables[table][key] = value
+				depth = 0
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(line, "[] ")
+			if _, ok := tables[table]; !ok {
+				tables[table] = map[string]string{}
+			}
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(line[:eq]), `"'`)
+		value = strings.TrimSpace(line[eq+1:])
+		depth = tomlBracketDepth(value)
+		if depth <= 0 {
+			tables[table][key] = value
+			depth = 0
+		}
+	}
+
+	return tables
+}
+
+// stripTOMLComment removes a trailing comment that is not inside a string
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlBracketDepth returns the bracket nesting opened (positive) or closed by a value,
+// ignoring brackets inside strings
+func tomlBracketDepth(value string) int {
+	depth := 0
+	var quote rune
+	for _, r := range value {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && (r == '[' || r == '{'):
+			depth++
+		case quote == 0 && (r == ']' || r == '}'):
+			depth--
+		}
+	}
+	return depth
+}
+
+// tomlString unquotes a raw TOML string value
+func tomlString(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[len(raw)-1] == raw[0] {
+		return raw[1 : len(raw)-1]
+	}
+	return ""
+}
+
+// tomlStringArray returns the string items of a raw TOML array
+func tomlStringArray(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "[") {
+		return nil
+	}
+
+	items := []string{}
+	var quote rune
+	var item strings.Builder
+	for _, r := range raw {
+		switch {
+		case quote != 0 && r == quote:
+			items = append(items, item.String())
+			item.Reset()
+			quote = 0
+		case quote != 0:
+			item.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		}
+	}
+	return items
+}
+
+// tomlInlineValue returns a string field of a raw TOML inline table
+func tomlInlineValue(raw, field string) string {
+	raw = strings.Trim(strings.TrimSpace(raw), "{}")
+	for _, part := range strings.Split(raw, ",") {
+		eq := strings.Index(part, "=")
+		if eq >= 0 && strings.TrimSpace(part[:eq]) == field {
+			return tomlString(part[eq+1:])
+		}
+	}
+	return ""
+}
+
+// tomlStringTable returns the string values of a table
+func tomlStringTable(table map[string]string) map[string]string {
+	if len(table) == 0 {
+		return nil
+	}
+	values := map[string]string{}
+	for key, raw := range table {
+		values[key] = tomlString(raw)
+	}
+	return values
+}
+
+// tomlDependencies reads a Cargo or Poetry dependency table, where a dependency is either
+// a version string or an inline table with a version field
+func tomlDependencies(table map[string]string, dev bool) []models.Dependency {
+	versions := map[string]string{}
+	for name, raw := range table {
+		version := tomlString(raw)
+		if strings.HasPrefix(strings.TrimSpace(raw), "{") {
+			version = tomlInlineValue(raw, "version")
+		}
+		versions[name] = version
+	}
+	return sortedDependencies(versions, dev)
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestAnalyzeRepository_Manifests(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-manifests-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"go.mod": `module github.com/example/api
+
+go 1.21
+
+require (
+	github.com/gin-gonic/gin v1.9.1
+	github.com/stretchr/testify v1.8.4 // test helpers
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace github.com/example/lib => ../lib
+`,
+		"main.go": "package main\n",
+		"web/package.json": `{
+  "name": "web",
+  "engines": {"node": ">=18"},
+  "scripts": {"dev": "vite", "test": "vitest run"},
+  "dependencies": {"react": "^18.2.0", "react-dom": "^18.2.0"},
+  "devDependencies": {"vite": "^5.0.0", "vitest": "^1.0.0"}
+}`,
+		"ml/pyproject.toml": `[project]
+name = "ml"
+requires-python = ">=3.10"
+dependencies = [
+    "Django>=4.2",  # web
+    "requests[security]==2.31; python_version>'3.8'",
+]
+
+[project.optional-dependencies]
+test = ["pytest>=7"]
+
+[project.scripts]
+ml-train = "ml.train:main"
+`,
+		"svc/pom.xml": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>svc</artifactId>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>3.2.0</version>
+  </parent>
+  <properties>
+    <java.version>17</java.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>org.junit.jupiter</groupId>
+      <artifactId>junit-jupiter</artifactId>
+      <version>5.10.0</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>`,
+		"cli/Cargo.toml": `[package]
+name = "cli"
+edition = "2021"
+
+[dependencies]
+axum = "0.7"
+tokio = { version = "1", features = ["full"] }
+
+[dev-dependencies]
+proptest = "1.4"
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	service := NewAnalyzerService(logger)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+
+	manifests := map[string]models.Manifest{}
+	for _, manifest := range analysis.Manifests {
+		manifests[manifest.Path] = manifest
+	}
+	if len(manifests) != 5 {
+		t.Fatalf("Expected 5 manifests, got %v", analysis.Manifests)
+	}
+
+	goMod := manifests["go.mod"]
+	if goMod.Name != "github.com/example/api" || goMod.LanguageVersion != "1.21" {
+		t.Errorf("Unexpected go.mod manifest %+v", goMod)
+	}
+	if len(goMod.Dependencies) != 2 {
+		t.Errorf("Expected 2 direct Go dependencies, got %v", goMod.Dependencies)
+	}
+
+	node := manifests["web/package.json"]
+	if node.LanguageVersion != ">=18" || node.Scripts["test"] != "vitest run" {
+		t.Errorf("Unexpected package.json manifest %+v", node)
+	}
+
+	python := manifests["ml/pyproject.toml"]
+	if python.LanguageVersion != ">=3.10" || python.Scripts["ml-train"] != "ml.train:main" {
+		t.Errorf("Unexpected pyproject.toml manifest %+v", python)
+	}
+	if len(python.Dependencies) != 3 || python.Dependencies[0].Name != "django" || python.Dependencies[1].Version != "==2.31" || !python.Dependencies[2].Dev {
+		t.Errorf("Unexpected Python dependencies %+v", python.Dependencies)
+	}
+
+	java := manifests["svc/pom.xml"]
+	if java.Name != "com.example:svc" || java.LanguageVersion != "17" {
+		t.Errorf("Unexpected pom.xml manifest %+v", java)
+	}
+
+	rust := manifests["cli/Cargo.toml"]
+	if rust.LanguageVersion != "edition 2021" || len(rust.Dependencies) != 3 {
+		t.Errorf("Unexpected Cargo.toml manifest %+v", rust)
+	}
+
+	expectedFrameworks := []string{"Gin", "React", "Vite", "Django", "Spring Boot", "Axum"}
+	for _, framework := range expectedFrameworks {
+		if !contains(analysis.Frameworks, framework) {
+			t.Errorf("Expected framework %q, got %v", framework, analysis.Frameworks)
+		}
+	}
+	expectedTestFrameworks := []string{"go test", "testify", "Vitest", "pytest", "JUnit 5", "cargo test", "proptest"}
+	for _, framework := range expectedTestFrameworks {
+		if !contains(analysis.TestFrameworks, framework) {
+			t.Errorf("Expected test framework %q, got %v", framework, analysis.TestFrameworks)
+		}
+	}
+	if contains(analysis.Frameworks, "Spring") {
+		t.Error("Did not expect Spring without a org.springframework dependency")
+	}
+}
+
+func TestParsePythonRequirement(t *testing.T) {
+	tests := map[string]string{
+		"Django>=4.2":                   "django|>=4.2",
+		"zope.interface":                "zope-interface|",
+		"uvicorn[standard] ~= 0.24":     "uvicorn|~= 0.24",
+		"numpy==1.26; python_version>3": "numpy|==1.26",
+	}
+
+	for requirement, expected := range tests {
+		dependency, ok := parsePythonRequirement(requirement)
+		if !ok {
+			t.Errorf("Failed to parse %q", requirement)
+			continue
+		}
+		if got := strings.Join([]string{dependency.Name, dependency.Version}, "|"); got != expected {
+			t.Errorf("parsePythonRequirement(%q) = %q, expected %q", requirement, got, expected)
+		}
+	}
+}
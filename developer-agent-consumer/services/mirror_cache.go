@@ -0,0 +1,219 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Defaults of the mirror cache
+	DefaultMirrorCacheDir      = "/tmp/sdlc-mirrors"
+	DefaultMirrorCacheMaxBytes = 10 * 1024 * 1024 * 1024
+)
+
+// MirrorCache keeps a bare mirror of every repository on local disk. Mirrors are fetched
+// incrementally before each job and jobs clone from them locally (objects are hard-linked),
+// so a repository is downloaded in full only once. Fetches of the same repository are
+// serialized and the least recently used mirrors are evicted beyond the size limit.
+type MirrorCache struct {
+	dir      string
+	maxBytes int64
+	logger   *logrus.Logger
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func NewMirrorCache(dir string, maxBytes int64, logger *logrus.Logger) *MirrorCache {
+	if dir == "" {
+		dir = DefaultMirrorCacheDir
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMirrorCacheMaxBytes
+	}
+	return &MirrorCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		logger:   logger,
+		locks:    map[string]*sync.Mutex{},
+	}
+}
+
+// CloneFrom brings the mirror of a repository up to date and clones it into repoPath,
+// checking out the base branch, and only the sparse paths when there are any. The origin remote of the clone
+// points to repoURL, so branches are pushed to the repository and not to the mirror.
+func (c *MirrorCache) CloneFrom(repoURL string, auth GitAuth, repoPath, baseBranch string, sparsePaths []string) error {
+	mirrorPath := c.mirrorPath(repoURL)
+	lock := c.lock(mirrorPath)
+	lock.Lock()
+	defer lock.Unlock()
+
+	env, cleanup, err := auth.env(repoURL)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if _, err := os.Stat(mirrorPath); err == nil {
+		c.logger.WithField("mirror", mirrorPath).Info("Fetching repository mirror")
+		if err := runGit(mirrorPath, env, "fetch", "--prune", "--tags", "--quiet", "origin"); err != nil {
+			return fmt.Errorf("failed to fetch mirror: %w", err)
+		}
+	} else {
+		c.logger.WithField("mirror", mirrorPath).Info("Creating repository mirror")
+		if err := c.createMirror(repoURL, mirrorPath, env); err != nil {
+			os.RemoveAll(mirrorPath)
+			return err
+		}
+	}
+
+	cloneArgs := []string{"clone", "--quiet", "--local", mirrorPath, repoPath}
+	if baseBranch != "" {
+		cloneArgs = append(cloneArgs, "--branch", baseBranch)
+	}
+	if len(sparsePaths) > 0 {
+		cloneArgs = append(cloneArgs, "--sparse")
+	}
+	checkoutEnv := skipLFSSmudge(nil)
+	if err := runGit("", checkoutEnv, cloneArgs...); err != nil {
+		return fmt.Errorf("failed to clone from mirror: %w", err)
+	}
+	if len(sparsePaths) > 0 {
+		if err := runGit(repoPath, checkoutEnv, append([]string{"sparse-checkout", "set", "--cone"}, sparsePaths...)...); err != nil {
+			return fmt.Errorf("failed to set sparse checkout: %w", err)
+		}
+	}
+	if err := runGit(repoPath, nil, "remote", "set-url", "origin", repoURL); err != nil {
+		return fmt.Errorf("failed to set origin: %w", err)
+	}
+
+	// The modification time of the mirror orders the mirrors for eviction
+	now := time.Now()
+	os.Chtimes(mirrorPath, now, now)
+
+	c.evict(mirrorPath)
+	return nil
+}
+
+// createMirror clones a bare mirror of the branches and tags of a repository. Other refs
+// (such as pull request heads) are left out to keep the mirror small.
+func (c *MirrorCache) createMirror(repoURL, mirrorPath string, env []string) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mirror directory: %w", err)
+	}
+	if err := runGit("", env, "clone", "--bare", "--quiet", repoURL, mirrorPath); err != nil {
+		return fmt.Errorf("failed to create mirror: %w", err)
+	}
+	if err := runGit(mirrorPath, nil, "config", "remote.origin.fetch", "+refs/heads/*:refs/heads/*"); err != nil {
+		return fmt.Errorf("failed to configure mirror: %w", err)
+	}
+	return nil
+}
+
+// evict removes the least recently used mirrors, except the current one and mirrors in use,
+// until the cache fits its size limit
+func (c *MirrorCache) evict(current string) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type mirror struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	mirrors := []mirror{}
+	total := int64(0)
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.dir, dirEntry.Name())
+		size := diskUsage(path)
+		mirrors = append(mirrors, mirror{path: path, size: size, modTime: info.ModTime()})
+		total += size
+	}
+
+	sort.Slice(mirrors, func(i, j int) bool {
+		return mirrors[i].modTime.Before(mirrors[j].modTime)
+	})
+	for _, m := range mirrors {
+		if total <= c.maxBytes {
+			break
+		}
+		if m.path == current {
+			continue
+		}
+
+		lock := c.lock(m.path)
+		if !lock.TryLock() {
+			continue
+		}
+		err := os.RemoveAll(m.path)
+		lock.Unlock()
+		if err != nil {
+			c.logger.WithError(err).Warn("Failed to evict repository mirror")
+			continue
+		}
+		total -= m.size
+		c.logger.WithField("mirror", m.path).Info("Evicted repository mirror")
+	}
+}
+
+// lock returns the mutex serializing the use of a mirror
+func (c *MirrorCache) lock(mirrorPath string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, ok := c.locks[mirrorPath]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.locks[mirrorPath] = lock
+	}
+	return lock
+}
+
+// mirrorPath returns the mirror directory of a repository URL
+func (c *MirrorCache) mirrorPath(repoURL string) string {
+	normalized := strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+	hash := sha256.Sum256([]byte(normalized))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:8])+".git")
+}
+
+// diskUsage returns the total size of the files under a directory
+func diskUsage(dir string) int64 {
+	size := int64(0)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// runGit runs a git command and returns its output in the error when it fails
+func runGit(dir string, env []string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// gitTest runs a git command for a test with a fixed identity
+func gitTest(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// newTestRemote creates a bare repository with one commit on main and a working clone to
+// push further commits from
+func newTestRemote(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	work := filepath.Join(root, "work")
+
+	gitTest(t, root, "init", "--bare", "--quiet", "--initial-branch=main", remote)
+	gitTest(t, root, "clone", "--quiet", remote, work)
+	if err := os.WriteFile(filepath.Join(work, "README.md"), []byte("# App\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, work, "add", ".")
+	gitTest(t, work, "commit", "--quiet", "-m", "Initial commit")
+	gitTest(t, work, "push", "--quiet", "origin", "HEAD:main")
+	return remote, work
+}
+
+func TestMirrorCache_CloneFrom(t *testing.T) {
+	remote, work := newTestRemote(t)
+	cache := NewMirrorCache(t.TempDir(), 0, logrus.New())
+
+	first := filepath.Join(t.TempDir(), "repo")
+	if err := cache.CloneFrom(remote, GitAuth{}, first, "", nil); err != nil {
+		t.Fatalf("Failed to clone from mirror: %v", err)
+	}
+	if url := gitTest(t, first, "remote", "get-url", "origin"); url != remote {
+		t.Errorf("Expected origin to point to the repository, got %s", url)
+	}
+	if _, err := os.Stat(cache.mirrorPath(remote)); err != nil {
+		t.Errorf("Expected a mirror to be created: %v", err)
+	}
+
+	// A new commit reaches the next clone through an incremental fetch
+	if err := os.WriteFile(filepath.Join(work, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, work, "add", ".")
+	gitTest(t, work, "commit", "--quiet", "-m", "Add main")
+	gitTest(t, work, "push", "--quiet", "origin", "HEAD:main")
+
+	second := filepath.Join(t.TempDir(), "repo")
+	if err := cache.CloneFrom(remote+"/", GitAuth{}, second, "", nil); err != nil {
+		t.Fatalf("Failed to clone from mirror: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(second, "main.go")); err != nil {
+		t.Error("Expected the second clone to contain the new commit")
+	}
+	if branch := gitTest(t, second, "rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("Expected the default branch to be checked out, got %s", branch)
+	}
+}
+
+func TestMirrorCache_Eviction(t *testing.T) {
+	first, _ := newTestRemote(t)
+	second, _ := newTestRemote(t)
+	dir := t.TempDir()
+
+	cache := NewMirrorCache(dir, 0, logrus.New())
+	if err := cache.CloneFrom(first, GitAuth{}, filepath.Join(t.TempDir(), "repo"), "", nil); err != nil {
+		t.Fatalf("Failed to clone from mirror: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(cache.mirrorPath(first), past, past)
+
+	// A limit below one mirror evicts every mirror but the one just used
+	small := NewMirrorCache(dir, 1, logrus.New())
+	if err := small.CloneFrom(second, GitAuth{}, filepath.Join(t.TempDir(), "repo"), "", nil); err != nil {
+		t.Fatalf("Failed to clone from mirror: %v", err)
+	}
+	if _, err := os.Stat(small.mirrorPath(first)); !os.IsNotExist(err) {
+		t.Error("Expected the least recently used mirror to be evicted")
+	}
+	if _, err := os.Stat(small.mirrorPath(second)); err != nil {
+		t.Error("Expected the current mirror to be kept")
+	}
+}
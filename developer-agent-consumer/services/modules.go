@@ -0,0 +1,468 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	// Maximum number of modules selected for an issue
+	maxTargetModules = 3
+
+	// Relevance scores of the signals matching a module to an issue
+	moduleNameScore      = 10
+	moduleTokenScore     = 3
+	moduleGenericScore   = 1
+	moduleFrameworkScore = 2
+)
+
+var (
+	// moduleManifests make the directory they are in a module
+	moduleManifests = []string{
+		"go.mod", "package.json", "pyproject.toml", "requirements.txt", "Pipfile",
+		"pom.xml", "build.gradle", "build.gradle.kts", "Cargo.toml",
+	}
+
+	// Module name parts too common to tell modules apart
+	genericModuleTokens = []string{"api", "app", "web", "service", "server", "src", "lib", "core", "common", "pkg"}
+
+	// gradleIncludePattern finds the project paths of settings.gradle include statements
+	gradleIncludePattern = regexp.MustCompile(`(?m)^\s*include\b(.*)$`)
+	quotedPattern        = regexp.MustCompile(`["']([^"']+)["']`)
+)
+
+// detectModules splits a monorepo into modules: directories with a manifest plus the members
+// declared by go.work, npm/yarn/pnpm workspaces, Gradle settings and Cargo workspaces.
+// Single-project repositories get no modules. languageFiles maps file paths to languages.
+func (s *AnalyzerService) detectModules(repoPath string, analysis *models.RepositoryAnalysis, languageFiles map[string]string) {
+	dirs := map[string]bool{}
+	goModules := 0
+	for _, configFile := range analysis.ConfigFiles {
+		configFile = filepath.ToSlash(configFile)
+		if contains(moduleManifests, path.Base(configFile)) {
+			dirs[path.Dir(configFile)] = true
+		}
+		if path.Base(configFile) == "go.mod" {
+			goModules++
+		}
+	}
+
+	workspaceRoot, rootMember := false, false
+	addMembers := func(kind string, members []string) {
+		if len(members) == 0 {
+			return
+		}
+		analysis.Workspaces = append(analysis.Workspaces, kind)
+		workspaceRoot = true
+		for _, member := range members {
+			dirs[member] = true
+			rootMember = rootMember || member == "."
+		}
+	}
+
+	if members := goWorkMembers(repoPath); members != nil {
+		addMembers("go.work", members)
+	} else if goModules > 1 {
+		analysis.Workspaces = append(analysis.Workspaces, "Go modules")
+	}
+	if kind, members := nodeWorkspaceMembers(repoPath); kind != "" {
+		addMembers(kind, members)
+	}
+	addMembers("Gradle multi-project", gradleMembers(repoPath))
+	addMembers("Cargo workspace", cargoWorkspaceMembers(repoPath))
+
+	// The root only declares the workspace unless it is a member itself
+	if workspaceRoot && !rootMember && len(dirs) > 1 {
+		delete(dirs, ".")
+	}
+	if len(dirs) < 2 {
+		analysis.Workspaces = nil
+		return
+	}
+
+	paths := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		paths = append(paths, dir)
+	}
+	sort.Strings(paths)
+
+	modules := make([]models.Module, len(paths))
+	for i, dir := range paths {
+		modules[i] = models.Module{
+			Path:      dir,
+			Name:      moduleDirName(dir),
+			Languages: []string{},
+			Manifests: []string{},
+		}
+	}
+	owner := func(file string) *models.Module {
+		var best *models.Module
+		for i := range modules {
+			dir := modules[i].Path
+			if dir == "." || file == dir || strings.HasPrefix(file, dir+"/") {
+				if best == nil || len(dir) > len(best.Path) || best.Path == "." {
+					best = &modules[i]
+				}
+			}
+		}
+		return best
+	}
+
+	configFiles := map[*models.Module][]string{}
+	for _, configFile := range analysis.ConfigFiles {
+		configFile = filepath.ToSlash(configFile)
+		if module := owner(configFile); module != nil {
+			configFiles[module] = append(configFiles[module], configFile)
+			if path.Dir(configFile) == module.Path && contains(moduleManifests, path.Base(configFile)) {
+				module.Manifests = append(module.Manifests, configFile)
+			}
+		}
+	}
+	for _, manifest := range analysis.Manifests {
+		module := owner(manifest.Path)
+		if module == nil || path.Dir(manifest.Path) != module.Path {
+			continue
+		}
+		// Go module paths and Maven coordinates are longer than the directory name
+		if manifest.Name != "" && module.Name == moduleDirName(module.Path) &&
+			manifest.Ecosystem != models.EcosystemGo && manifest.Ecosystem != models.EcosystemJava {
+			module.Name = manifest.Name
+		}
+		module.Frameworks = appendUnique(module.Frameworks, manifest.Frameworks...)
+		module.TestFrameworks = appendUnique(module.TestFrameworks, manifest.TestFrameworks...)
+	}
+	for _, entryPoint := range analysis.EntryPoints {
+		if module := owner(filepath.ToSlash(entryPoint)); module != nil {
+			module.EntryPoints = append(module.EntryPoints, entryPoint)
+		}
+	}
+	for _, keyDir := range analysis.KeyDirectories {
+		if module := owner(filepath.ToSlash(keyDir)); module != nil {
+			module.KeyDirectories = append(module.KeyDirectories, keyDir)
+		}
+	}
+
+	languageCounts := map[*models.Module]map[string]int{}
+	for file, language := range languageFiles {
+		if module := owner(file); module != nil {
+			if languageCounts[module] == nil {
+				languageCounts[module] = map[string]int{}
+			}
+			languageCounts[module][language]++
+		}
+	}
+
+	moduleTypes := []string{}
+	for i := range modules {
+		module := &modules[i]
+		counts := languageCounts[module]
+		for language := range counts {
+			module.Languages = append(module.Languages, language)
+		}
+		sort.Slice(module.Languages, func(a, b int) bool {
+			la, lb := module.Languages[a], module.Languages[b]
+			if counts[la] != counts[lb] {
+				return counts[la] > counts[lb]
+			}
+			return la < lb
+		})
+
+		module.ProjectType = s.detectProjectType(&models.RepositoryAnalysis{
+			ConfigFiles: configFiles[module],
+			Languages:   module.Languages,
+		})
+		moduleTypes = appendUnique(moduleTypes, module.ProjectType)
+	}
+
+	analysis.Modules = modules
+	analysis.ProjectType = fmt.Sprintf("Monorepo (%s)", strings.Join(moduleTypes, ", "))
+}
+
+// SelectModules scores the modules of a monorepo against the issue and stores the relevant
+// ones in TargetModules, best match first. Issues matching no module leave it empty.
+func (s *AnalyzerService) SelectModules(analysis *models.RepositoryAnalysis, request *models.DevelopmentRequest) {
+	analysis.TargetModules = nil
+	if len(analysis.Modules) == 0 {
+		return
+	}
+
+	text := strings.ToLower(request.Summary + "\n" + request.Description)
+	top := 0
+	for i := range analysis.Modules {
+		module := &analysis.Modules[i]
+		module.Relevance = scoreModule(module, text, request.Labels)
+		if module.Relevance > top {
+			top = module.Relevance
+		}
+	}
+	if top == 0 {
+		return
+	}
+
+	candidates := []models.Module{}
+	for _, module := range analysis.Modules {
+		if module.Relevance > 0 && module.Relevance*2 >= top {
+			candidates = append(candidates, module)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Relevance > candidates[j].Relevance
+	})
+	for i, module := range candidates {
+		if i == maxTargetModules {
+			break
+		}
+		analysis.TargetModules = append(analysis.TargetModules, module.Path)
+	}
+}
+
+// scoreModule matches the module path, name and frameworks against the issue text and labels
+func scoreModule(module *models.Module, text string, labels []string) int {
+	score := 0
+	base := strings.ToLower(path.Base(module.Path))
+	name := strings.ToLower(module.Name)
+
+	for _, label := range labels {
+		if label = strings.ToLower(label); label == base || label == name {
+			score += moduleNameScore
+			break
+		}
+	}
+	// "backoffice-ui" also matches "backoffice UI"
+	spaced := strings.NewReplacer("-", " ", "_", " ").Replace(base)
+	for _, candidate := range []string{strings.ToLower(module.Path), base, name, spaced} {
+		if len(candidate) >= 3 && containsWord(text, candidate) {
+			score += moduleNameScore
+			break
+		}
+	}
+
+	tokens := strings.FieldsFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, token := range tokens {
+		if len(token) < 2 || !containsWord(text, token) {
+			continue
+		}
+		if contains(genericModuleTokens, token) {
+			score += moduleGenericScore
+		} else {
+			score += moduleTokenScore
+		}
+	}
+
+	for _, framework := range module.Frameworks {
+		if containsWord(text, strings.ToLower(framework)) {
+			score += moduleFrameworkScore
+		}
+	}
+
+	return score
+}
+
+// containsWord reports whether text contains word delimited by non-alphanumeric characters
+func containsWord(text, word string) bool {
+	isWordChar := func(b byte) bool {
+		return b < 0x80 && (unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b)))
+	}
+	for offset := 0; offset <= len(text)-len(word); {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		if (start == 0 || !isWordChar(text[start-1])) && (end == len(text) || !isWordChar(text[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+// goWorkMembers returns the modules used by the go.work file, nil without one
+func goWorkMembers(repoPath string) []string {
+	file, err := os.Open(filepath.Join(repoPath, "go.work"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	members := []string{}
+	inUse := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inUse && fields[0] == ")":
+			inUse = false
+		case inUse:
+			members = append(members, cleanMemberPath(fields[0]))
+		case fields[0] == "use" && len(fields) > 1 && fields[1] == "(":
+			inUse = true
+		case fields[0] == "use" && len(fields) > 1:
+			members = append(members, cleanMemberPath(fields[1]))
+		}
+	}
+	return members
+}
+
+// nodeWorkspaceMembers resolves the package globs of pnpm-workspace.yaml or the
+// workspaces field of the root package.json
+func nodeWorkspaceMembers(repoPath string) (string, []string) {
+	if content, err := os.ReadFile(filepath.Join(repoPath, "pnpm-workspace.yaml")); err == nil {
+		globs := []string{}
+		inPackages := false
+		for _, line := range strings.Split(string(content), "\n") {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "packages:"):
+				inPackages = true
+			case inPackages && strings.HasPrefix(trimmed, "-"):
+				globs = append(globs, strings.Trim(strings.TrimSpace(trimmed[1:]), `"'`))
+			case inPackages && trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+				inPackages = false
+			}
+		}
+		return "pnpm workspaces", resolveMemberGlobs(repoPath, globs, "package.json")
+	}
+
+	content, err := os.ReadFile(filepath.Join(repoPath, "package.json"))
+	if err != nil {
+		return "", nil
+	}
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil || len(pkg.Workspaces) == 0 {
+		return "", nil
+	}
+
+	// Either an array of globs or {"packages": [...]}
+	var globs []string
+	if err := json.Unmarshal(pkg.Workspaces, &globs); err != nil {
+		var workspaces struct {
+			Packages []string `json:"packages"`
+		}
+		if err := json.Unmarshal(pkg.Workspaces, &workspaces); err != nil {
+			return "", nil
+		}
+		globs = workspaces.Packages
+	}
+
+	kind := "npm workspaces"
+	if fileExists(filepath.Join(repoPath, "yarn.lock")) {
+		kind = "yarn workspaces"
+	}
+	return kind, resolveMemberGlobs(repoPath, globs, "package.json")
+}
+
+// gradleMembers returns the project directories included by settings.gradle(.kts)
+func gradleMembers(repoPath string) []string {
+	var content []byte
+	var err error
+	for _, name := range []string{"settings.gradle", "settings.gradle.kts"} {
+		if content, err = os.ReadFile(filepath.Join(repoPath, name)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil
+	}
+
+	members := []string{}
+	for _, include := range gradleIncludePattern.FindAllStringSubmatch(string(content), -1) {
+		for _, project := range quotedPattern.FindAllStringSubmatch(include[1], -1) {
+			dir := strings.ReplaceAll(strings.TrimPrefix(project[1], ":"), ":", "/")
+			if dir != "" && fileExists(filepath.Join(repoPath, dir)) {
+				members = append(members, dir)
+			}
+		}
+	}
+	return members
+}
+
+// cargoWorkspaceMembers resolves the members of a [workspace] in the root Cargo.toml
+func cargoWorkspaceMembers(repoPath string) []string {
+	content, err := os.ReadFile(filepath.Join(repoPath, "Cargo.toml"))
+	if err != nil {
+		return nil
+	}
+	workspace, ok := parseTOMLTables(string(content))["workspace"]
+	if !ok {
+		return nil
+	}
+	return resolveMemberGlobs(repoPath, tomlStringArray(workspace["members"]), "Cargo.toml")
+}
+
+// resolveMemberGlobs expands workspace globs to the directories containing the manifest.
+// Negated globs ("!pkg/legacy") exclude directories.
+func resolveMemberGlobs(repoPath string, globs []string, manifest string) []string {
+	excluded := map[string]bool{}
+	for _, glob := range globs {
+		if strings.HasPrefix(glob, "!") {
+			excluded[cleanMemberPath(glob[1:])] = true
+		}
+	}
+
+	members := []string{}
+	for _, glob := range globs {
+		if strings.HasPrefix(glob, "!") {
+			continue
+		}
+		// "packages/**" is treated as "packages/*"
+		glob = strings.ReplaceAll(cleanMemberPath(glob), "**", "*")
+		matches, err := filepath.Glob(filepath.Join(repoPath, filepath.FromSlash(glob), manifest))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(repoPath, filepath.Dir(match))
+			if err != nil {
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+			if !excluded[rel] && !contains(members, rel) {
+				members = append(members, rel)
+			}
+		}
+	}
+	return members
+}
+
+// moduleDirName is the default name of a module directory
+func moduleDirName(dir string) string {
+	if dir == "." {
+		return "root"
+	}
+	return path.Base(dir)
+}
+
+// cleanMemberPath normalizes a workspace member path to a slash-separated relative path
+func cleanMemberPath(member string) string {
+	return path.Clean(strings.TrimPrefix(strings.Trim(member, `"'`), "./"))
+}
+
+// appendUnique appends the items not yet in the slice
+func appendUnique(slice []string, items ...string) []string {
+	for _, item := range items {
+		if !contains(slice, item) {
+			slice = append(slice, item)
+		}
+	}
+	return slice
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	tempDir, err := os.MkdirTemp("", "test-monorepo-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	return tempDir
+}
+
+func TestAnalyzeRepository_GoMonorepo(t *testing.T) {
+	tempDir := writeTestFiles(t, map[string]string{
+		"configuration-api/go.mod":                    "module github.com/example/configuration-api\n\ngo 1.20\n\nrequire github.com/gin-gonic/gin v1.9.1\n",
+		"configuration-api/main.go":                   "package main\n",
+		"configuration-api/handlers/project.go":       "package handlers\n",
+		"developer-agent-consumer/go.mod":             "module github.com/example/developer-agent-consumer\n\ngo 1.20\n",
+		"developer-agent-consumer/main.go":            "package main\n",
+		"developer-agent-consumer/services/git.go":    "package services\n",
+		"backoffice-ui/package.json":                  `{"name": "backoffice-ui", "dependencies": {"react": "^18.2.0"}}`,
+		"backoffice-ui/src/App.tsx":                   "export default function App() {}\n",
+		"backoffice-ui/src/pages/ProjectsPage.tsx":    "export {}\n",
+		"backoffice-ui/src/components/ProjectForm.ts": "export {}\n",
+	})
+	defer os.RemoveAll(tempDir)
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	service := NewAnalyzerService(logger)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+
+	if len(analysis.Modules) != 3 {
+		t.Fatalf("Expected 3 modules, got %+v", analysis.Modules)
+	}
+	if analysis.ProjectType != "Monorepo (Node.js Application, Go Application)" {
+		t.Errorf("Unexpected project type %q", analysis.ProjectType)
+	}
+
+	ui := analysis.Modules[0]
+	if ui.Path != "backoffice-ui" || ui.ProjectType != "Node.js Application" || ui.Languages[0] != "TypeScript" || !contains(ui.Frameworks, "React") {
+		t.Errorf("Unexpected UI module %+v", ui)
+	}
+	api := analysis.Modules[1]
+	if api.Path != "configuration-api" || api.ProjectType != "Go Application" || !contains(api.Frameworks, "Gin") {
+		t.Errorf("Unexpected API module %+v", api)
+	}
+	if len(api.EntryPoints) != 1 || api.EntryPoints[0] != filepath.Join("configuration-api", "main.go") {
+		t.Errorf("Expected the API module to own its main.go, got %v", api.EntryPoints)
+	}
+
+	service.SelectModules(analysis, &models.DevelopmentRequest{
+		Summary:     "Show the repository count on the projects page",
+		Description: "The backoffice UI should list how many repositories each project has. The configuration-api already returns them.",
+	})
+	if strings.Join(analysis.TargetModules, ",") != "configuration-api,backoffice-ui" && strings.Join(analysis.TargetModules, ",") != "backoffice-ui,configuration-api" {
+		t.Errorf("Expected the UI and API modules to be selected, got %v", analysis.TargetModules)
+	}
+
+	service.SelectModules(analysis, &models.DevelopmentRequest{Summary: "Retry git push in the developer agent consumer"})
+	if strings.Join(analysis.TargetModules, ",") != "developer-agent-consumer" {
+		t.Errorf("Expected the consumer module to be selected, got %v", analysis.TargetModules)
+	}
+
+	service.SelectModules(analysis, &models.DevelopmentRequest{Summary: "Improve logging"})
+	if len(analysis.TargetModules) != 0 {
+		t.Errorf("Expected no module for an unrelated issue, got %v", analysis.TargetModules)
+	}
+}
+
+func TestAnalyzeRepository_Workspaces(t *testing.T) {
+	tempDir := writeTestFiles(t, map[string]string{
+		"package.json":                 `{"name": "root", "private": true}`,
+		"pnpm-workspace.yaml":          "packages:\n  - 'packages/*'\n  - '!packages/legacy'\n",
+		"packages/web/package.json":    `{"name": "@acme/web"}`,
+		"packages/web/index.ts":        "export {}\n",
+		"packages/shared/package.json": `{"name": "@acme/shared"}`,
+		"packages/legacy/package.json": `{"name": "@acme/legacy"}`,
+		"settings.gradle":              "rootProject.name = 'acme'\ninclude ':server', ':tools:cli'\n",
+		"server/build.gradle":          "",
+		"tools/cli/build.gradle.kts":   "",
+	})
+	defer os.RemoveAll(tempDir)
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	service := NewAnalyzerService(logger)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+
+	paths := []string{}
+	names := []string{}
+	for _, module := range analysis.Modules {
+		paths = append(paths, module.Path)
+		names = append(names, module.Name)
+	}
+	// The legacy package still has a manifest, so it stays a module
+	expected := "packages/legacy,packages/shared,packages/web,server,tools/cli"
+	if strings.Join(paths, ",") != expected {
+		t.Errorf("Expected modules %s, got %v", expected, paths)
+	}
+	if !contains(names, "@acme/web") {
+		t.Errorf("Expected package names as module names, got %v", names)
+	}
+	if strings.Join(analysis.Workspaces, ",") != "pnpm workspaces,Gradle multi-project" {
+		t.Errorf("Unexpected workspaces %v", analysis.Workspaces)
+	}
+}
+
+func TestAnalyzeRepository_SingleProjectHasNoModules(t *testing.T) {
+	tempDir := createTestGoProject(t)
+	defer os.RemoveAll(tempDir)
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	service := NewAnalyzerService(logger)
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+
+	if len(analysis.Modules) != 0 || len(analysis.Workspaces) != 0 {
+		t.Errorf("Expected no modules, got %+v (workspaces %v)", analysis.Modules, analysis.Workspaces)
+	}
+	if analysis.ProjectType != "Go Application" {
+		t.Errorf("Expected project type 'Go Application', got '%s'", analysis.ProjectType)
+	}
+}
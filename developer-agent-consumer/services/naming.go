@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	// Templates used when the repository does not configure its own
+	DefaultBranchTemplate = "feature/{{.Key}}"
+	DefaultCommitTemplate = "[{{.Key}}] {{.Summary}}{{if .Group}} ({{.Group}}){{end}}\n\nAutomated commit by SDLC AI Agent"
+
+	// slugMaxLength caps the slugified summary in branch names
+	slugMaxLength = 50
+)
+
+// NamingData is the data branch name and commit message templates are rendered with
+type NamingData struct {
+	Key        string // JIRA issue key, e.g. PROJ-123
+	ProjectKey string
+	Type       string // JIRA issue type, e.g. Bug or Story
+	Summary    string
+	Slug       string // Summary in lower case with dashes, at most 50 characters
+	Labels     []string
+	Group      string // Commit group when changes are split into several commits, e.g. tests
+}
+
+// namingTemplateFuncs are the prompt template functions plus slug
+var namingTemplateFuncs = func() template.FuncMap {
+	funcs := template.FuncMap{"slug": Slugify}
+	for name, fn := range promptTemplateFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}()
+
+// NewNamingData collects the template data of a development request
+func NewNamingData(request *models.DevelopmentRequest) NamingData {
+	return NamingData{
+		Key:        request.JiraIssueKey,
+		ProjectKey: request.JiraProjectKey,
+		Type:       request.IssueType,
+		Summary:    request.Summary,
+		Slug:       Slugify(request.Summary),
+		Labels:     request.Labels,
+	}
+}
+
+// BranchName renders the branch name of an issue with the repository's branch template
+// and checks it against the git ref name rules
+func BranchName(settings *models.NamingSettings, data NamingData) (string, error) {
+	content := DefaultBranchTemplate
+	if settings != nil && settings.BranchTemplate != "" {
+		content = settings.BranchTemplate
+	}
+
+	branchName, err := renderNamingTemplate("branch", content, data)
+	if err != nil {
+		return "", err
+	}
+	branchName = strings.TrimSpace(branchName)
+	if err := ValidateBranchName(branchName); err != nil {
+		return "", err
+	}
+	return branchName, nil
+}
+
+// CommitMessage renders the commit message of an issue with the repository's commit template.
+// For a commit group the group is appended to the subject unless the template uses it.
+func CommitMessage(settings *models.NamingSettings, data NamingData) (string, error) {
+	content := DefaultCommitTemplate
+	if settings != nil && settings.CommitTemplate != "" {
+		content = settings.CommitTemplate
+	}
+
+	message, err := renderNamingTemplate("commit", content, data)
+	if err != nil {
+		return "", err
+	}
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "", fmt.Errorf("commit template rendered an empty message")
+	}
+	if data.Group != "" && !strings.Contains(content, ".Group") {
+		subject, body, found := strings.Cut(message, "\n")
+		message = fmt.Sprintf("%s (%s)", subject, data.Group)
+		if found {
+			message += "\n" + body
+		}
+	}
+	return message, nil
+}
+
+func renderNamingTemplate(name, content string, data NamingData) (string, error) {
+	tmpl, err := template.New(name).Funcs(namingTemplateFuncs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return rendered.String(), nil
+}
+
+// Slugify lower-cases a text and joins its ASCII letters and digits with dashes, cut to
+// at most 50 characters at a word boundary when possible
+func Slugify(text string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	result := slug.String()
+	if len(result) > slugMaxLength {
+		result = result[:slugMaxLength]
+		if i := strings.LastIndex(result, "-"); i > 0 {
+			result = result[:i]
+		}
+	}
+	return result
+}
+
+// ValidateBranchName checks a branch name against the rules of git check-ref-format
+func ValidateBranchName(name string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid branch name %q: %s", name, reason)
+	}
+
+	switch {
+	case name == "" || name == "@":
+		return invalid("must not be empty or @")
+	case strings.HasPrefix(name, "-"):
+		return invalid("must not start with a dash")
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.Contains(name, "//"):
+		return invalid("must not start or end with a slash or contain empty components")
+	case strings.HasSuffix(name, "."):
+		return invalid("must not end with a dot")
+	case strings.Contains(name, ".."):
+		return invalid("must not contain ..")
+	case strings.Contains(name, "@{"):
+		return invalid("must not contain @{")
+	}
+
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return invalid(fmt.Sprintf("must not contain %q", r))
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return invalid("components must not start with a dot or end with .lock")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func testNamingData() NamingData {
+	return NewNamingData(&models.DevelopmentRequest{
+		JiraIssueKey:   "ECOM-42",
+		JiraProjectKey: "ECOM",
+		Summary:        "Fix: checkout fails for carts with 100+ items!",
+		IssueType:      "Bug",
+		Labels:         []string{"backend", "Breaking"},
+	})
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Fix: checkout fails for carts with 100+ items!":                   "fix-checkout-fails-for-carts-with-100-items",
+		"  Über   café__menu ":                                             "ber-caf-menu",
+		"Add the wishlist endpoint and a background job that cleans it up": "add-the-wishlist-endpoint-and-a-background-job",
+		"***": "",
+	}
+	for text, expected := range tests {
+		if slug := Slugify(text); slug != expected {
+			t.Errorf("Slugify(%q) = %q, expected %q", text, slug, expected)
+		}
+	}
+}
+
+func TestBranchName(t *testing.T) {
+	data := testNamingData()
+
+	branchName, err := BranchName(nil, data)
+	if err != nil || branchName != "feature/ECOM-42" {
+		t.Errorf("Expected the default branch name, got %q (%v)", branchName, err)
+	}
+
+	settings := &models.NamingSettings{
+		BranchTemplate: `{{if eq .Type "Bug"}}bugfix{{else}}feature{{end}}/{{.Key}}-{{.Slug}}`,
+	}
+	branchName, err = BranchName(settings, data)
+	if err != nil || branchName != "bugfix/ECOM-42-fix-checkout-fails-for-carts-with-100-items" {
+		t.Errorf("Expected a bugfix branch, got %q (%v)", branchName, err)
+	}
+
+	for _, template := range []string{"feature/{{.Summary}}", "{{.Key}}.lock", "feature/{{.Missing}}", "feature/{{.Key"} {
+		if branchName, err := BranchName(&models.NamingSettings{BranchTemplate: template}, data); err == nil {
+			t.Errorf("Expected template %q to fail, got %q", template, branchName)
+		}
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	data := testNamingData()
+
+	message, err := CommitMessage(nil, data)
+	if err != nil || message != "[ECOM-42] Fix: checkout fails for carts with 100+ items!\n\nAutomated commit by SDLC AI Agent" {
+		t.Errorf("Expected the default commit message, got %q (%v)", message, err)
+	}
+
+	settings := &models.NamingSettings{
+		CommitTemplate: `{{if eq .Type "Bug"}}fix{{else}}feat{{end}}{{if hasLabel .Labels "breaking"}}!{{end}}: {{lower .Summary}}
+
+Refs: {{.Key}}`,
+	}
+	message, err = CommitMessage(settings, data)
+	if err != nil || !strings.HasPrefix(message, "fix!: fix: checkout fails") || !strings.HasSuffix(message, "\n\nRefs: ECOM-42") {
+		t.Errorf("Expected a conventional commit message, got %q (%v)", message, err)
+	}
+
+	data.Group = "tests"
+	message, _ = CommitMessage(nil, data)
+	if !strings.HasPrefix(message, "[ECOM-42] Fix: checkout fails for carts with 100+ items! (tests)\n\n") {
+		t.Errorf("Expected the group in the default subject, got %q", message)
+	}
+	message, _ = CommitMessage(settings, data)
+	if !strings.HasPrefix(message, "fix!: fix: checkout fails for carts with 100+ items! (tests)\n\nRefs: ECOM-42") {
+		t.Errorf("Expected the group appended to the subject, got %q", message)
+	}
+	message, _ = CommitMessage(&models.NamingSettings{CommitTemplate: "{{.Group}}: {{.Key}}"}, data)
+	if message != "tests: ECOM-42" {
+		t.Errorf("Expected the template to place the group, got %q", message)
+	}
+	data.Group = ""
+
+	if _, err := CommitMessage(&models.NamingSettings{CommitTemplate: "{{if false}}x{{end}}"}, data); err == nil {
+		t.Error("Expected an empty commit message to fail")
+	}
+}
+
+func TestValidateBranchName(t *testing.T) {
+	for _, name := range []string{"feature/ECOM-42", "bugfix/ECOM-42-fix-login", "release/1.2.x"} {
+		if err := ValidateBranchName(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "@", "-feature", "/feature", "feature/", "feature//x", "feature.", "a..b", "a@{b", "a b", "a~b", "a^b", "a:b", "a?b", "a*b", "a[b", "a\\b", "a/.hidden", "a/b.lock", "a\tb"} {
+		if err := ValidateBranchName(name); err == nil {
+			t.Errorf("Expected %q to be invalid", name)
+		}
+	}
+}
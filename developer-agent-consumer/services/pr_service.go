@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
 )
 
 type PRService struct {
@@ -34,8 +35,22 @@ type RepoInfo struct {
 	BaseURL  string // For GitLab self-hosted instances
 }
 
+// PullRequestOptions holds optional settings for pull/merge request creation
+type PullRequestOptions struct {
+	Draft bool   // Open as draft PR (GitHub) or "Draft:" MR (GitLab)
+	Notes string // Additional markdown appended to the body, e.g. verification results
+
+	// Fork the branch was pushed to; the PR is then opened from the fork against the
+	// base branch of the repository
+	HeadRepoURL string
+}
+
+// CreatePullRequest opens a pull/merge request for the branch and returns its URL. When a
+// previous run of the issue left one open, its title and body are updated instead.
+// With opts.HeadRepoURL set the branch is taken from that fork (a cross-repository PR).
 func (s *PRService) CreatePullRequest(
 	repoURL, branchName, baseBranch, jiraIssueKey, summary, description, accessToken string,
+	opts PullRequestOptions,
 ) (string, error) {
 	// Default base branch to "main" if not specified
 	if baseBranch == "" {
@@ -47,20 +62,31 @@ func (s *PRService) CreatePullRequest(
 	if err != nil {
 		return "", err
 	}
+	headInfo := repoInfo
+	if opts.HeadRepoURL != "" {
+		if headInfo, err = s.parseRepoURL(opts.HeadRepoURL); err != nil {
+			return "", err
+		}
+		if headInfo.Platform != repoInfo.Platform {
+			return "", fmt.Errorf("fork %s is not on the platform of %s", opts.HeadRepoURL, repoURL)
+		}
+	}
 
 	s.logger.WithFields(logrus.Fields{
 		"platform":       repoInfo.Platform,
 		"owner":          repoInfo.Owner,
 		"repo":           repoInfo.Repo,
 		"branch":         branchName,
+		"head_owner":     headInfo.Owner,
 		"base_branch":    baseBranch,
 		"jira_issue_key": jiraIssueKey,
+		"draft":          opts.Draft,
 	}).Info("Creating pull/merge request")
 
 	if repoInfo.Platform == "github" {
-		return s.createGitHubPR(repoInfo, branchName, baseBranch, jiraIssueKey, summary, description, accessToken)
+		return s.createGitHubPR(repoInfo, headInfo, branchName, baseBranch, jiraIssueKey, summary, description, accessToken, opts)
 	} else if repoInfo.Platform == "gitlab" {
-		return s.createGitLabMR(repoInfo, branchName, baseBranch, jiraIssueKey, summary, description, accessToken)
+		return s.createGitLabMR(repoInfo, headInfo, branchName, baseBranch, jiraIssueKey, summary, description, accessToken, opts)
 	}
 
 	return "", fmt.Errorf("unsupported platform: %s", repoInfo.Platform)
@@ -68,7 +94,17 @@ func (s *PRService) CreatePullRequest(
 
 func (s *PRService) parseRepoURL(repoURL string) (*RepoInfo, error) {
 	// Remove .git suffix if present
-	repoURL = strings.TrimSuffix(repoURL, ".git")
+	repoURL = strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+
+	// SSH URLs (git@host:org/repo, ssh://git@host:port/org/repo) are mapped to the HTTPS
+	// URL of the same repository, where the platform API is served
+	if IsSSHURL(repoURL) {
+		host, path, ok := SplitSSHURL(repoURL)
+		if !ok {
+			return nil, fmt.Errorf("invalid repository URL: %s", repoURL)
+		}
+		repoURL = fmt.Sprintf("https://%s/%s", host, path)
+	}
 
 	parsedURL, err := url.Parse(repoURL)
 	if err != nil {
@@ -105,20 +141,56 @@ func (s *PRService) parseRepoURL(repoURL string) (*RepoInfo, error) {
 	return info, nil
 }
 
+// createGitHubPR opens the PR on repoInfo from the branch on headInfo, the same
+// repository or a fork of it
 func (s *PRService) createGitHubPR(
-	repoInfo *RepoInfo,
+	repoInfo, headInfo *RepoInfo,
 	branchName, baseBranch, jiraIssueKey, summary, description, accessToken string,
+	opts PullRequestOptions,
 ) (string, error) {
 	apiURL := fmt.Sprintf("%s/repos/%s/%s/pulls", repoInfo.BaseURL, repoInfo.Owner, repoInfo.Repo)
 
 	title := fmt.Sprintf("[%s] %s", jiraIssueKey, summary)
-	body := s.buildPRBody(jiraIssueKey, description)
+	body := s.buildPRBody(jiraIssueKey, description, opts.Notes)
+
+	// A previous run of the issue may have opened the PR already: update it instead
+	var pulls []struct {
+		Number  int    `json:"number"`
+		NodeID  string `json:"node_id"`
+		HTMLURL string `json:"html_url"`
+		Draft   bool   `json:"draft"`
+	}
+	query := url.Values{"state": {"open"}, "head": {headInfo.Owner + ":" + branchName}}
+	if err := s.getJSON(apiURL+"?"+query.Encode(), "token "+accessToken, &pulls); err != nil {
+		return "", fmt.Errorf("failed to look up existing PR: %w", err)
+	}
+	if len(pulls) > 0 {
+		updateURL := fmt.Sprintf("%s/%d", apiURL, pulls[0].Number)
+		if err := s.sendJSON("PATCH", updateURL, "token "+accessToken, map[string]interface{}{"title": title, "body": body}); err != nil {
+			return "", fmt.Errorf("failed to update PR: %w", err)
+		}
+		// The REST API cannot change the draft state, so the run's result (e.g. verification
+		// now failing or passing) is applied through GraphQL
+		if pulls[0].Draft != opts.Draft {
+			if err := s.setGitHubDraft(repoInfo, pulls[0].NodeID, opts.Draft, accessToken); err != nil {
+				return "", fmt.Errorf("failed to update PR draft state: %w", err)
+			}
+		}
+		s.logger.WithField("pr_url", pulls[0].HTMLURL).Info("Existing GitHub PR updated")
+		return pulls[0].HTMLURL, nil
+	}
+
+	head := branchName
+	if headInfo.Owner != repoInfo.Owner {
+		head = headInfo.Owner + ":" + branchName
+	}
 
 	payload := map[string]interface{}{
 		"title": title,
 		"body":  body,
-		"head":  branchName,
+		"head":  head,
 		"base":  baseBranch,
+		"draft": opts.Draft,
 	}
 
 	jsonData, err := json.Marshal(payload)
@@ -161,45 +233,106 @@ func (s *PRService) createGitHubPR(
 	return prURL, nil
 }
 
-func (s *PRService) createGitLabMR(
-	repoInfo *RepoInfo,
-	branchName, baseBranch, jiraIssueKey, summary, description, accessToken string,
-) (string, error) {
-	// Get project ID first
-	projectPath := fmt.Sprintf("%s/%s", repoInfo.Owner, repoInfo.Repo)
-	encodedPath := url.PathEscape(projectPath)
-	projectURL := fmt.Sprintf("%s/projects/%s", repoInfo.BaseURL, encodedPath)
+// setGitHubDraft converts the PR to a draft or marks it ready for review
+func (s *PRService) setGitHubDraft(repoInfo *RepoInfo, nodeID string, draft bool, accessToken string) error {
+	mutation := "markPullRequestReadyForReview"
+	if draft {
+		mutation = "convertPullRequestToDraft"
+	}
+	payload := map[string]interface{}{
+		"query":     fmt.Sprintf("mutation($id: ID!) { %s(input: {pullRequestId: $id}) { pullRequest { isDraft } } }", mutation),
+		"variables": map[string]string{"id": nodeID},
+	}
 
-	req, err := http.NewRequest("GET", projectURL, nil)
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req, err := http.NewRequest("POST", repoInfo.BaseURL+"/graphql", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+accessToken)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to get project: %w", err)
+		return fmt.Errorf("failed to make HTTP request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("GitHub GraphQL API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var project map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return "", fmt.Errorf("failed to parse project: %w", err)
+	// GraphQL reports failures with 200 OK and an errors array
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%s failed: %s", mutation, result.Errors[0].Message)
 	}
 
-	projectID := int(project["id"].(float64))
+	return nil
+}
+
+// createGitLabMR opens the MR on repoInfo from the branch on headInfo, the same
+// project or a fork of it
+func (s *PRService) createGitLabMR(
+	repoInfo, headInfo *RepoInfo,
+	branchName, baseBranch, jiraIssueKey, summary, description, accessToken string,
+	opts PullRequestOptions,
+) (string, error) {
+	// Get project IDs first
+	projectID, err := s.gitLabProjectID(repoInfo, accessToken)
+	if err != nil {
+		return "", err
+	}
+	sourceProjectID := projectID
+	if headInfo != repoInfo {
+		if sourceProjectID, err = s.gitLabProjectID(headInfo, accessToken); err != nil {
+			return "", err
+		}
+	}
 
 	// Create MR
 	apiURL := fmt.Sprintf("%s/projects/%d/merge_requests", repoInfo.BaseURL, projectID)
 
 	title := fmt.Sprintf("[%s] %s", jiraIssueKey, summary)
-	mrDescription := s.buildPRBody(jiraIssueKey, description)
+	if opts.Draft {
+		title = "Draft: " + title
+	}
+	mrDescription := s.buildPRBody(jiraIssueKey, description, opts.Notes)
+
+	// A previous run of the issue may have opened the MR already: update it instead
+	var mergeRequests []struct {
+		IID             int    `json:"iid"`
+		WebURL          string `json:"web_url"`
+		SourceProjectID int    `json:"source_project_id"`
+	}
+	query := url.Values{"state": {"opened"}, "source_branch": {branchName}}
+	if err := s.getJSON(apiURL+"?"+query.Encode(), "Bearer "+accessToken, &mergeRequests); err != nil {
+		return "", fmt.Errorf("failed to look up existing MR: %w", err)
+	}
+	for _, mergeRequest := range mergeRequests {
+		// Forks may have branches of the same name
+		if mergeRequest.SourceProjectID != 0 && mergeRequest.SourceProjectID != sourceProjectID {
+			continue
+		}
+		updateURL := fmt.Sprintf("%s/%d", apiURL, mergeRequest.IID)
+		if err := s.sendJSON("PUT", updateURL, "Bearer "+accessToken, map[string]interface{}{"title": title, "description": mrDescription}); err != nil {
+			return "", fmt.Errorf("failed to update MR: %w", err)
+		}
+		s.logger.WithField("mr_url", mergeRequest.WebURL).Info("Existing GitLab MR updated")
+		return mergeRequest.WebURL, nil
+	}
 
 	payload := map[string]interface{}{
 		"source_branch": branchName,
@@ -207,13 +340,18 @@ func (s *PRService) createGitLabMR(
 		"title":         title,
 		"description":   mrDescription,
 	}
+	// A cross-project MR is created on the fork and targets the upstream project
+	if sourceProjectID != projectID {
+		apiURL = fmt.Sprintf("%s/projects/%d/merge_requests", repoInfo.BaseURL, sourceProjectID)
+		payload["target_project_id"] = projectID
+	}
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	req, err = http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -221,7 +359,7 @@ func (s *PRService) createGitLabMR(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err = s.httpClient.Do(req)
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to create MR: %w", err)
 	}
@@ -247,7 +385,52 @@ func (s *PRService) createGitLabMR(
 	return mrURL, nil
 }
 
-func (s *PRService) buildPRBody(jiraIssueKey, description string) string {
+// FormatPreviousAttempt describes in the PR body how the run treated the branch left by
+// a previous run of the issue
+func FormatPreviousAttempt(attempt *models.PreviousAttempt) string {
+	if attempt == nil {
+		return ""
+	}
+
+	head := attempt.BranchHead
+	if len(head) > 7 {
+		head = head[:7]
+	}
+	if attempt.Policy == models.RerunPolicyReplace {
+		return fmt.Sprintf("## Previous Attempt\n\nThis run replaced the previous attempt (`%s`) with a fresh one from the base branch.\n", head)
+	}
+	return fmt.Sprintf("## Previous Attempt\n\nThis run added commits on top of the previous attempt (`%s`).\n", head)
+}
+
+// sendJSON sends a JSON payload with the given method and expects 200 OK
+func (s *PRService) sendJSON(method, apiURL, authorization string, payload interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequest(method, apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func (s *PRService) buildPRBody(jiraIssueKey, description, notes string) string {
 	var body strings.Builder
 
 	body.WriteString(fmt.Sprintf("## JIRA Issue: %s\n\n", jiraIssueKey))
@@ -258,6 +441,11 @@ func (s *PRService) buildPRBody(jiraIssueKey, description string) string {
 		body.WriteString("\n\n")
 	}
 
+	if notes != "" {
+		body.WriteString(notes)
+		body.WriteString("\n")
+	}
+
 	body.WriteString("---\n\n")
 	body.WriteString("*This pull request was automatically generated by SDLC AI Agent*\n")
 
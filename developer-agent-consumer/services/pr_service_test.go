@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestCreateGitHubPR_UpdatesExistingPR(t *testing.T) {
+	var updated map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/company/backend/pulls":
+			if r.URL.Query().Get("head") != "company:feature/TEST-1" || r.URL.Query().Get("state") != "open" {
+				t.Errorf("Unexpected pull request query %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"number": 7, "html_url": "https://github.com/company/backend/pull/7"}]`))
+		case r.Method == "PATCH" && r.URL.Path == "/repos/company/backend/pulls/7":
+			json.NewDecoder(r.Body).Decode(&updated)
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer server.Close()
+
+	service := NewPRService(logrus.New())
+	repoInfo := &RepoInfo{Platform: "github", Owner: "company", Repo: "backend", BaseURL: server.URL}
+	prURL, err := service.createGitHubPR(repoInfo, repoInfo, "feature/TEST-1", "main", "TEST-1", "Add login", "Description", "token",
+		PullRequestOptions{Notes: FormatPreviousAttempt(&models.PreviousAttempt{BranchHead: "0123456789abcdef", Policy: models.RerunPolicyReplace})})
+	if err != nil {
+		t.Fatalf("Failed to update PR: %v", err)
+	}
+
+	if prURL != "https://github.com/company/backend/pull/7" {
+		t.Errorf("Expected the existing PR URL, got %s", prURL)
+	}
+	if updated["title"] != "[TEST-1] Add login" || !strings.Contains(updated["body"], "replaced the previous attempt (`0123456`)") {
+		t.Errorf("Expected the title and body to be updated, got %v", updated)
+	}
+}
+
+func TestCreateGitHubPR_UpdatesDraftState(t *testing.T) {
+	var mutation string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/company/backend/pulls":
+			w.Write([]byte(`[{"number": 7, "node_id": "PR_kw7", "html_url": "https://github.com/company/backend/pull/7", "draft": false}]`))
+		case r.Method == "PATCH" && r.URL.Path == "/repos/company/backend/pulls/7":
+			w.Write([]byte(`{}`))
+		case r.Method == "POST" && r.URL.Path == "/graphql":
+			var payload struct {
+				Query     string            `json:"query"`
+				Variables map[string]string `json:"variables"`
+			}
+			json.NewDecoder(r.Body).Decode(&payload)
+			if payload.Variables["id"] != "PR_kw7" {
+				t.Errorf("Expected the PR node ID, got %v", payload.Variables)
+			}
+			mutation = payload.Query
+			w.Write([]byte(`{"data": {"convertPullRequestToDraft": {"pullRequest": {"isDraft": true}}}}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer server.Close()
+
+	service := NewPRService(logrus.New())
+	repoInfo := &RepoInfo{Platform: "github", Owner: "company", Repo: "backend", BaseURL: server.URL}
+	if _, err := service.createGitHubPR(repoInfo, repoInfo, "feature/TEST-1", "main", "TEST-1", "Add login", "Description", "token",
+		PullRequestOptions{Draft: true}); err != nil {
+		t.Fatalf("Failed to update PR: %v", err)
+	}
+
+	if !strings.Contains(mutation, "convertPullRequestToDraft") {
+		t.Errorf("Expected the PR to be converted to a draft, got %q", mutation)
+	}
+}
+
+func TestCreateGitLabMR_CreatesWhenNoneOpen(t *testing.T) {
+	created := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/projects/group/repo":
+			w.Write([]byte(`{"id": 42}`))
+		case r.Method == "GET" && r.URL.Path == "/projects/42/merge_requests":
+			if r.URL.Query().Get("source_branch") != "feature/TEST-1" {
+				t.Errorf("Unexpected merge request query %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[]`))
+		case r.Method == "POST" && r.URL.Path == "/projects/42/merge_requests":
+			created = true
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"web_url": "https://gitlab.internal/group/repo/-/merge_requests/3"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	service := NewPRService(logrus.New())
+	repoInfo := &RepoInfo{Platform: "gitlab", Owner: "group", Repo: "repo", BaseURL: server.URL}
+	mrURL, err := service.createGitLabMR(repoInfo, repoInfo, "feature/TEST-1", "main", "TEST-1", "Add login", "", "token", PullRequestOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create MR: %v", err)
+	}
+	if !created || mrURL != "https://gitlab.internal/group/repo/-/merge_requests/3" {
+		t.Errorf("Expected a new MR, got %s", mrURL)
+	}
+}
+
+func TestCreateGitHubPR_FromFork(t *testing.T) {
+	var created map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/company/backend/pulls":
+			if r.URL.Query().Get("head") != "agents:feature/TEST-1" {
+				t.Errorf("Unexpected pull request query %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[]`))
+		case r.Method == "POST" && r.URL.Path == "/repos/company/backend/pulls":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"html_url": "https://github.com/company/backend/pull/8"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer server.Close()
+
+	service := NewPRService(logrus.New())
+	repoInfo := &RepoInfo{Platform: "github", Owner: "company", Repo: "backend", BaseURL: server.URL}
+	headInfo := &RepoInfo{Platform: "github", Owner: "agents", Repo: "backend", BaseURL: server.URL}
+	prURL, err := service.createGitHubPR(repoInfo, headInfo, "feature/TEST-1", "main", "TEST-1", "Add login", "", "token", PullRequestOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+	if prURL != "https://github.com/company/backend/pull/8" || created["head"] != "agents:feature/TEST-1" || created["base"] != "main" {
+		t.Errorf("Expected a cross-repository PR, got %s with %v", prURL, created)
+	}
+}
+
+func TestCreateGitLabMR_FromFork(t *testing.T) {
+	var created map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/projects/group/repo":
+			w.Write([]byte(`{"id": 42}`))
+		case r.Method == "GET" && r.URL.Path == "/projects/agents/repo":
+			w.Write([]byte(`{"id": 51}`))
+		case r.Method == "GET" && r.URL.Path == "/projects/42/merge_requests":
+			// An MR of another fork with a branch of the same name
+			w.Write([]byte(`[{"iid": 2, "source_project_id": 50}]`))
+		case r.Method == "POST" && r.URL.Path == "/projects/51/merge_requests":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"web_url": "https://gitlab.internal/group/repo/-/merge_requests/3"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	service := NewPRService(logrus.New())
+	repoInfo := &RepoInfo{Platform: "gitlab", Owner: "group", Repo: "repo", BaseURL: server.URL}
+	headInfo := &RepoInfo{Platform: "gitlab", Owner: "agents", Repo: "repo", BaseURL: server.URL}
+	mrURL, err := service.createGitLabMR(repoInfo, headInfo, "feature/TEST-1", "main", "TEST-1", "Add login", "", "token", PullRequestOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create MR: %v", err)
+	}
+	if mrURL != "https://gitlab.internal/group/repo/-/merge_requests/3" || created["target_project_id"] != float64(42) || created["source_branch"] != "feature/TEST-1" {
+		t.Errorf("Expected a cross-project MR, got %s with %v", mrURL, created)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+// GetPullRequestStatus fetches the state of a pull/merge request from its URL.
+// Reviews counts submitted reviews on GitHub and user notes on GitLab.
+func (s *PRService) GetPullRequestStatus(prURL, accessToken string) (*models.PullRequestStatus, error) {
+	repoInfo, err := s.parseRepoURL(prURL)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmedURL := strings.TrimRight(prURL, "/")
+	number := trimmedURL[strings.LastIndex(trimmedURL, "/")+1:]
+	if _, err := strconv.Atoi(number); err != nil {
+		return nil, fmt.Errorf("could not find pull request number in URL: %s", prURL)
+	}
+
+	switch repoInfo.Platform {
+	case "github":
+		return s.getGitHubPRStatus(repoInfo, number, accessToken)
+	case "gitlab":
+		return s.getGitLabMRStatus(repoInfo, number, accessToken)
+	}
+
+	return nil, fmt.Errorf("unsupported platform: %s", repoInfo.Platform)
+}
+
+func (s *PRService) getGitHubPRStatus(repoInfo *RepoInfo, number, accessToken string) (*models.PullRequestStatus, error) {
+	prURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%s", repoInfo.BaseURL, repoInfo.Owner, repoInfo.Repo, number)
+
+	var pr struct {
+		State    string     `json:"state"` // open, closed
+		MergedAt *time.Time `json:"merged_at"`
+		ClosedAt *time.Time `json:"closed_at"`
+	}
+	if err := s.getJSON(prURL, "token "+accessToken, &pr); err != nil {
+		return nil, err
+	}
+
+	var reviews []json.RawMessage
+	if err := s.getJSON(prURL+"/reviews?per_page=100", "token "+accessToken, &reviews); err != nil {
+		return nil, err
+	}
+
+	status := &models.PullRequestStatus{
+		State:     models.PullRequestStateOpen,
+		Reviews:   len(reviews),
+		CheckedAt: time.Now(),
+	}
+	if pr.MergedAt != nil {
+		status.State = models.PullRequestStateMerged
+		status.MergedAt = pr.MergedAt
+	} else if pr.State == "closed" {
+		status.State = models.PullRequestStateClosed
+		status.ClosedAt = pr.ClosedAt
+	}
+
+	return status, nil
+}
+
+func (s *PRService) getGitLabMRStatus(repoInfo *RepoInfo, number, accessToken string) (*models.PullRequestStatus, error) {
+	projectPath := url.PathEscape(fmt.Sprintf("%s/%s", repoInfo.Owner, repoInfo.Repo))
+	mrURL := fmt.Sprintf("%s/projects/%s/merge_requests/%s", repoInfo.BaseURL, projectPath, number)
+
+	var mr struct {
+		State          string     `json:"state"` // opened, closed, locked, merged
+		MergedAt       *time.Time `json:"merged_at"`
+		ClosedAt       *time.Time `json:"closed_at"`
+		UserNotesCount int        `json:"user_notes_count"`
+	}
+	if err := s.getJSON(mrURL, "Bearer "+accessToken, &mr); err != nil {
+		return nil, err
+	}
+
+	status := &models.PullRequestStatus{
+		State:     models.PullRequestStateOpen,
+		Reviews:   mr.UserNotesCount,
+		CheckedAt: time.Now(),
+	}
+	switch mr.State {
+	case "merged":
+		status.State = models.PullRequestStateMerged
+		status.MergedAt = mr.MergedAt
+	case "closed":
+		status.State = models.PullRequestStateClosed
+		status.ClosedAt = mr.ClosedAt
+	}
+
+	return status, nil
+}
+
+func (s *PRService) getJSON(apiURL, authorization string, target interface{}) error {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+// PromptData is the data a prompt template is rendered with. Project and repository
+// are exposed without credentials.
+type PromptData struct {
+	Request    *models.DevelopmentRequest
+	Issue      PromptIssue
+	Project    PromptProject
+	Repository PromptRepository
+	Analysis   *models.RepositoryAnalysis
+}
+
+// PromptIssue is the JIRA issue enriched with project details
+type PromptIssue struct {
+	Key         string
+	ProjectKey  string
+	Summary     string
+	Description string
+	Type        string
+	Labels      []string
+	URL         string
+}
+
+// PromptProject holds the project fields available to templates
+type PromptProject struct {
+	Name            string
+	Description     string
+	Scope           string
+	JiraProjectKey  string
+	JiraProjectName string
+	JiraProjectURL  string
+}
+
+// PromptRepository holds the repository fields available to templates
+type PromptRepository struct {
+	URL         string
+	Description string
+	BaseBranch  string
+}
+
+// promptTemplateFuncs are the helper functions available in prompt templates
+var promptTemplateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	"hasLabel": func(labels []string, label string) bool {
+		for _, l := range labels {
+			if strings.EqualFold(l, label) {
+				return true
+			}
+		}
+		return false
+	},
+	"default": func(fallback, value string) string {
+		if strings.TrimSpace(value) == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// NewPromptData collects the template data for a development request
+func NewPromptData(
+	request *models.DevelopmentRequest,
+	project *models.Project,
+	repository *models.Repository,
+	analysis *models.RepositoryAnalysis,
+) *PromptData {
+	data := &PromptData{
+		Request: request,
+		Issue: PromptIssue{
+			Key:         request.JiraIssueKey,
+			ProjectKey:  request.JiraProjectKey,
+			Summary:     request.Summary,
+			Description: request.Description,
+			Type:        request.IssueType,
+			Labels:      request.Labels,
+		},
+		Project: PromptProject{
+			Name:            project.Name,
+			Description:     project.Description,
+			Scope:           project.Scope,
+			JiraProjectKey:  project.JiraProjectKey,
+			JiraProjectName: project.JiraProjectName,
+			JiraProjectURL:  project.JiraProjectURL,
+		},
+		Analysis: analysis,
+	}
+	if repository != nil {
+		data.Repository = PromptRepository{
+			URL:         repository.URL,
+			Description: repository.Description,
+			BaseBranch:  repository.BaseBranch,
+		}
+	}
+	if project.JiraProjectURL != "" {
+		data.Issue.URL = issueURL(project.JiraProjectURL, request.JiraIssueKey)
+	}
+	return data
+}
+
+// RenderPromptTemplate renders a prompt template with the given data. Missing map
+// keys are reported as errors instead of being rendered as "<no value>".
+func RenderPromptTemplate(content string, data *PromptData) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(promptTemplateFuncs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+
+	return prompt.String(), nil
+}
+
+// issueURL builds the browse URL of an issue from the JIRA project URL
+// (e.g. https://jira.company.com/projects/ECOM -> https://jira.company.com/browse/ECOM-1)
+func issueURL(projectURL, issueKey string) string {
+	base := strings.TrimRight(projectURL, "/")
+	for _, marker := range []string{"/jira/", "/projects/", "/browse/"} {
+		if i := strings.Index(base, marker); i >= 0 {
+			base = base[:i]
+		}
+	}
+	return fmt.Sprintf("%s/browse/%s", base, issueKey)
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func testPromptData() *PromptData {
+	request := &models.DevelopmentRequest{
+		JiraIssueKey:   "ECOM-42",
+		JiraProjectKey: "ECOM",
+		Summary:        "Add wishlist endpoint",
+		Description:    "Users can save products for later.",
+		IssueType:      "Story",
+		Labels:         []string{"backend", "API"},
+	}
+	project := &models.Project{
+		Name:           "E-commerce",
+		Scope:          "Online store backend",
+		JiraProjectURL: "https://jira.company.com/projects/ECOM",
+	}
+	repository := &models.Repository{
+		URL:            "https://github.com/company/ecommerce-api",
+		BaseBranch:     "main",
+		GitAccessToken: "ghp_secret",
+	}
+	analysis := &models.RepositoryAnalysis{ProjectType: "go", Languages: []string{"Go", "SQL"}}
+
+	return NewPromptData(request, project, repository, analysis)
+}
+
+func TestRenderPromptTemplate(t *testing.T) {
+	content := `# {{.Issue.Key}}: {{.Issue.Summary}} ({{.Issue.Type}})
+Project: {{.Project.Name}} - {{.Project.Scope}}
+Languages: {{join .Analysis.Languages ", "}}
+Base branch: {{.Repository.BaseBranch}}
+Link: {{.Issue.URL}}
+{{if hasLabel .Issue.Labels "api"}}Update the OpenAPI spec.{{end}}`
+
+	prompt, err := RenderPromptTemplate(content, testPromptData())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, part := range []string{
+		"# ECOM-42: Add wishlist endpoint (Story)",
+		"Project: E-commerce - Online store backend",
+		"Languages: Go, SQL",
+		"Base branch: main",
+		"Link: https://jira.company.com/browse/ECOM-42",
+		"Update the OpenAPI spec.",
+	} {
+		if !strings.Contains(prompt, part) {
+			t.Errorf("Expected prompt to contain %q, got %q", part, prompt)
+		}
+	}
+}
+
+func TestRenderPromptTemplate_Errors(t *testing.T) {
+	if _, err := RenderPromptTemplate("{{.Issue.Key", testPromptData()); err == nil {
+		t.Error("Expected parse error")
+	}
+
+	// Credentials are not part of the template data
+	if _, err := RenderPromptTemplate("{{.Repository.GitAccessToken}}", testPromptData()); err == nil {
+		t.Error("Expected error for field not exposed to templates")
+	}
+}
+
+// TestPromptData_MatchesConfigurationAPI compares the template data with the copy the
+// Configuration API previews and validates templates with, so that a template passing the
+// preview renders here and the other way round
+func TestPromptData_MatchesConfigurationAPI(t *testing.T) {
+	source := "../../configuration-api/services/prompt_renderer.go"
+	if _, err := os.Stat(source); err != nil {
+		t.Skipf("Configuration API source not available: %v", err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), source, nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", source, err)
+	}
+
+	structs := map[string]*ast.StructType{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if spec, ok := node.(*ast.TypeSpec); ok {
+			if st, ok := spec.Type.(*ast.StructType); ok {
+				structs[spec.Name.Name] = st
+			}
+		}
+		return true
+	})
+
+	var compare func(path string, local reflect.Type, mirror ast.Expr)
+	compare = func(path string, local reflect.Type, mirror ast.Expr) {
+		for {
+			switch expr := mirror.(type) {
+			case *ast.StarExpr:
+				mirror = expr.X
+				continue
+			case *ast.ArrayType:
+				mirror = expr.Elt
+				continue
+			}
+			break
+		}
+		for local.Kind() == reflect.Ptr || local.Kind() == reflect.Slice {
+			local = local.Elem()
+		}
+		if local.Kind() != reflect.Struct {
+			return
+		}
+
+		ident, ok := mirror.(*ast.Ident)
+		if !ok || structs[ident.Name] == nil {
+			t.Errorf("%s: expected a struct in the Configuration API, got %#v", path, mirror)
+			return
+		}
+
+		mirrorFields := map[string]ast.Expr{}
+		for _, field := range structs[ident.Name].Fields.List {
+			for _, name := range field.Names {
+				mirrorFields[name.Name] = field.Type
+			}
+		}
+
+		for i := 0; i < local.NumField(); i++ {
+			field := local.Field(i)
+			mirrorType, ok := mirrorFields[field.Name]
+			if !ok {
+				t.Errorf("%s.%s is missing in the Configuration API (%s)", path, field.Name, ident.Name)
+				continue
+			}
+			compare(path+"."+field.Name, field.Type, mirrorType)
+		}
+		for name := range mirrorFields {
+			if _, ok := local.FieldByName(name); !ok {
+				t.Errorf("%s.%s of the Configuration API (%s) does not exist in the consumer", path, name, ident.Name)
+			}
+		}
+	}
+
+	compare("PromptData", reflect.TypeOf(PromptData{}), ast.NewIdent("PromptData"))
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	defaultReadinessThreshold   = 60
+	defaultMinDescriptionLength = 100
+	maxReferenceScanFileSize    = 1024 * 1024
+)
+
+// Readiness check names and weights
+const (
+	ReadinessCheckDescription        = "description"
+	ReadinessCheckAcceptanceCriteria = "acceptance_criteria"
+	ReadinessCheckReferences         = "references"
+	ReadinessCheckLLMJudgment        = "llm_judgment"
+
+	weightDescription        = 25
+	weightAcceptanceCriteria = 35
+	weightReferences         = 20
+	weightLLMJudgment        = 20
+)
+
+var (
+	acceptanceCriteriaPattern = regexp.MustCompile(`(?im)acceptance criteria|definition of done|^\s*AC\s*[:\d]|\bgiven\b.+\bwhen\b.+\bthen\b|^\s*[-*]\s*\[[ xX]\]`)
+	fileReferencePattern      = regexp.MustCompile(`(?:[\w.-]+/)*[\w-]+\.(?:go|js|jsx|ts|tsx|py|java|kt|rb|rs|cs|php|sql|proto|yml|yaml|json|html|css|scss)\b`)
+	endpointReferencePattern  = regexp.MustCompile(`/(?:api|v\d+)(?:/[\w:{}.-]+)+`)
+)
+
+// ReadinessService scores development requests before any code is generated
+type ReadinessService struct {
+	claudeService *ClaudeService
+	logger        *logrus.Logger
+}
+
+func NewReadinessService(claudeService *ClaudeService, logger *logrus.Logger) *ReadinessService {
+	return &ReadinessService{
+		claudeService: claudeService,
+		logger:        logger,
+	}
+}
+
+// Score runs the readiness checks enabled in the settings and combines them into a 0-100 score.
+// A check that cannot run (e.g. the LLM judgment fails) is left out of the score.
+func (s *ReadinessService) Score(
+	request *models.DevelopmentRequest,
+	repoPath string,
+	settings *models.ReadinessSettings,
+	profile *models.AgentProfile,
+) *models.ReadinessResult {
+	threshold := settings.Threshold
+	if threshold <= 0 {
+		threshold = defaultReadinessThreshold
+	}
+
+	checks := []models.ReadinessCheck{checkDescription(request, settings)}
+	if settings.RequireAcceptanceCriteria {
+		checks = append(checks, checkAcceptanceCriteria(request))
+	}
+	if settings.CheckReferences {
+		checks = append(checks, checkReferences(request, repoPath))
+	}
+	if settings.LLMJudgment {
+		check, err := s.checkLLMJudgment(request, repoPath, profile)
+		if err != nil {
+			s.logger.WithError(err).Warn("LLM readiness judgment failed, skipping check")
+		} else {
+			checks = append(checks, check)
+		}
+	}
+
+	result := &models.ReadinessResult{
+		Score:     readinessScore(checks),
+		Threshold: threshold,
+		Checks:    checks,
+	}
+	result.Passed = result.Score >= threshold
+
+	s.logger.WithFields(logrus.Fields{
+		"jira_issue_key": request.JiraIssueKey,
+		"score":          result.Score,
+		"threshold":      threshold,
+		"passed":         result.Passed,
+	}).Info("Readiness scored")
+
+	return result
+}
+
+// readinessScore combines the checks into a 0-100 score weighted by each check's weight
+func readinessScore(checks []models.ReadinessCheck) int {
+	total, earned := 0, 0.0
+	for _, check := range checks {
+		total += check.Weight
+		earned += float64(check.Weight) * check.Score
+	}
+	if total == 0 {
+		return 100
+	}
+	return int(earned/float64(total)*100 + 0.5)
+}
+
+func checkDescription(request *models.DevelopmentRequest, settings *models.ReadinessSettings) models.ReadinessCheck {
+	minLength := settings.MinDescriptionLength
+	if minLength <= 0 {
+		minLength = defaultMinDescriptionLength
+	}
+
+	length := len(strings.TrimSpace(request.Description))
+	check := models.ReadinessCheck{
+		Name:   ReadinessCheckDescription,
+		Weight: weightDescription,
+	}
+	if length >= minLength {
+		check.Score = 1
+		check.Passed = true
+		return check
+	}
+
+	check.Score = float64(length) / float64(minLength)
+	check.Detail = fmt.Sprintf("The description has %d characters; at least %d are expected", length, minLength)
+	return check
+}
+
+func checkAcceptanceCriteria(request *models.DevelopmentRequest) models.ReadinessCheck {
+	check := models.ReadinessCheck{
+		Name:   ReadinessCheckAcceptanceCriteria,
+		Weight: weightAcceptanceCriteria,
+	}
+	if acceptanceCriteriaPattern.MatchString(request.Description) {
+		check.Score = 1
+		check.Passed = true
+		return check
+	}
+
+	check.Detail = "No acceptance criteria found (add an \"Acceptance Criteria\" section, a checklist or Given/When/Then scenarios)"
+	return check
+}
+
+// checkReferences scores the share of files and endpoints mentioned in the ticket that exist in the repository
+func checkReferences(request *models.DevelopmentRequest, repoPath string) models.ReadinessCheck {
+	check := models.ReadinessCheck{
+		Name:   ReadinessCheckReferences,
+		Weight: weightReferences,
+	}
+
+	text := request.Summary + "\n" + request.Description
+	files := uniqueMatches(fileReferencePattern, text)
+	endpoints := uniqueMatches(endpointReferencePattern, text)
+	if len(files)+len(endpoints) == 0 {
+		check.Detail = "No files or endpoints of the repository are referenced"
+		return check
+	}
+
+	repoFiles := listRepositoryFiles(repoPath)
+	missing := []string{}
+	for _, file := range files {
+		if !referencedFileExists(repoFiles, file) {
+			missing = append(missing, file)
+		}
+	}
+	for _, endpoint := range endpoints {
+		if !referencedEndpointExists(repoPath, repoFiles, endpoint) {
+			missing = append(missing, endpoint)
+		}
+	}
+
+	total := len(files) + len(endpoints)
+	check.Score = float64(total-len(missing)) / float64(total)
+	check.Passed = len(missing) == 0
+	if len(missing) > 0 {
+		check.Detail = fmt.Sprintf("Referenced but not found in the repository: %s", strings.Join(missing, ", "))
+	}
+	return check
+}
+
+func (s *ReadinessService) checkLLMJudgment(request *models.DevelopmentRequest, repoPath string, profile *models.AgentProfile) (models.ReadinessCheck, error) {
+	judgment, err := s.claudeService.JudgeReadiness(request, repoPath, profile)
+	if err != nil {
+		return models.ReadinessCheck{}, err
+	}
+
+	check := models.ReadinessCheck{
+		Name:   ReadinessCheckLLMJudgment,
+		Weight: weightLLMJudgment,
+		Score:  float64(judgment.Score) / 100,
+		Passed: len(judgment.Missing) == 0,
+	}
+	if len(judgment.Missing) > 0 {
+		check.Detail = strings.Join(judgment.Missing, "; ")
+	}
+	return check, nil
+}
+
+// FormatReadinessComment renders a failed readiness result as a JIRA comment listing what is missing
+func FormatReadinessComment(result *models.ReadinessResult) string {
+	var comment strings.Builder
+
+	comment.WriteString("h3. Ticket needs refinement\n\n")
+	comment.WriteString(fmt.Sprintf("The readiness score is %d, below the threshold of %d. No code was generated.\n\n", result.Score, result.Threshold))
+	comment.WriteString("What is missing:\n")
+	for _, check := range result.Checks {
+		if check.Passed || check.Detail == "" {
+			continue
+		}
+		comment.WriteString(fmt.Sprintf("* %s\n", check.Detail))
+	}
+	comment.WriteString("\nPlease update the ticket and move it to In Development again.")
+
+	return comment.String()
+}
+
+func uniqueMatches(pattern *regexp.Regexp, text string) []string {
+	seen := map[string]bool{}
+	matches := []string{}
+	for _, match := range pattern.FindAllString(text, -1) {
+		if !seen[match] {
+			seen[match] = true
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// listRepositoryFiles returns the slash-separated paths of the repository files,
+// skipping hidden and dependency directories
+func listRepositoryFiles(repoPath string) []string {
+	files := []string{}
+	filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != repoPath && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "venv") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, _ := filepath.Rel(repoPath, path)
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+	return files
+}
+
+// referencedFileExists matches a referenced path against the end of the repository paths,
+// so "handlers/user.go" matches "api/handlers/user.go"
+func referencedFileExists(repoFiles []string, reference string) bool {
+	reference = strings.TrimPrefix(reference, "/")
+	for _, file := range repoFiles {
+		if file == reference || strings.HasSuffix(file, "/"+reference) {
+			return true
+		}
+	}
+	return false
+}
+
+// referencedEndpointExists looks for the static part of the endpoint (up to the first
+// path parameter) in the repository sources
+func referencedEndpointExists(repoPath string, repoFiles []string, endpoint string) bool {
+	segments := strings.Split(endpoint, "/")
+	static := []string{}
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "{") {
+			break
+		}
+		static = append(static, segment)
+	}
+	// Routes are often registered relative to an /api or /v1 group
+	needles := []string{strings.Join(static, "/")}
+	if len(static) > 2 {
+		needles = append(needles, "/"+strings.Join(static[2:], "/"))
+	}
+
+	for _, file := range repoFiles {
+		if _, ok := languageExtensions[filepath.Ext(file)]; !ok {
+			continue
+		}
+		path := filepath.Join(repoPath, file)
+		if info, err := os.Stat(path); err != nil || info.Size() > maxReferenceScanFileSize {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, needle := range needles {
+			if strings.Contains(string(content), needle) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestReadinessScore_WellSpecifiedIssue(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-readiness-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	os.MkdirAll(filepath.Join(tempDir, "api", "handlers"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "api", "handlers", "user.go"), []byte(`router.GET("/users/:id", GetUser)`), 0644)
+
+	request := &models.DevelopmentRequest{
+		JiraIssueKey: "PROJ-1",
+		Summary:      "Return the user email",
+		Description: "GET /api/users/:id in handlers/user.go should also return the email address of the user.\n\n" +
+			"Acceptance Criteria:\n- [ ] email is part of the response\n- [ ] existing fields are unchanged",
+	}
+	settings := &models.ReadinessSettings{
+		Enabled:                   true,
+		RequireAcceptanceCriteria: true,
+		CheckReferences:           true,
+	}
+
+	service := NewReadinessService(NewClaudeService("claude", logrus.New()), logrus.New())
+	result := service.Score(request, tempDir, settings, nil)
+
+	if !result.Passed || result.Score != 100 {
+		t.Fatalf("Expected full score, got %d: %+v", result.Score, result.Checks)
+	}
+	if len(result.Checks) != 3 {
+		t.Errorf("Expected 3 checks, got %d", len(result.Checks))
+	}
+}
+
+func TestReadinessScore_UnderspecifiedIssue(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-readiness-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	request := &models.DevelopmentRequest{
+		JiraIssueKey: "PROJ-2",
+		Summary:      "Fix billing",
+		Description:  "See services/billing.go",
+	}
+	settings := &models.ReadinessSettings{
+		Enabled:                   true,
+		Threshold:                 50,
+		RequireAcceptanceCriteria: true,
+		CheckReferences:           true,
+	}
+
+	service := NewReadinessService(NewClaudeService("claude", logrus.New()), logrus.New())
+	result := service.Score(request, tempDir, settings, nil)
+
+	if result.Passed {
+		t.Fatalf("Expected issue to need refinement, got score %d", result.Score)
+	}
+	if result.Threshold != 50 {
+		t.Errorf("Expected threshold 50, got %d", result.Threshold)
+	}
+
+	comment := FormatReadinessComment(result)
+	for _, part := range []string{"No acceptance criteria found", "services/billing.go", "at least 100 are expected"} {
+		if !strings.Contains(comment, part) {
+			t.Errorf("Expected comment to contain %q, got %q", part, comment)
+		}
+	}
+}
+
+func TestParseReadinessJudgment(t *testing.T) {
+	output := "I reviewed the ticket.\n```json\n{\"score\": 40, \"missing\": [\"expected error codes\"]}\n```"
+
+	judgment, err := parseReadinessJudgment(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if judgment.Score != 40 || len(judgment.Missing) != 1 {
+		t.Errorf("Unexpected judgment: %+v", judgment)
+	}
+
+	if _, err := parseReadinessJudgment("no verdict"); err == nil {
+		t.Error("Expected error when no verdict is present")
+	}
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	// DefaultRepoMapTokens is the token budget of the rendered repository map
+	DefaultRepoMapTokens = 2048
+
+	// Characters per token used to turn the token budget into a size
+	repoMapCharsPerToken = 4
+
+	// Limits keeping the map cheap on very large repositories
+	maxRepoMapFiles     = 5000
+	maxSymbolSourceSize = 512 * 1024
+)
+
+var (
+	// Symbol extractors by file extension, besides go/parser for Go
+	jsExportPattern     = regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(function\*?|class|interface|type|enum|const|let|var)\s+([A-Za-z_$][\w$]*)`)
+	pythonSymbolPattern = regexp.MustCompile(`(?m)^(?:async\s+)?(def|class)\s+([A-Za-z]\w*)`)
+
+	scriptExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"}
+
+	// Symbol caps tried, from all symbols down to none, until the map fits the budget
+	repoMapSymbolCaps = []int{-1, 10, 5, 2, 0}
+)
+
+// buildRepositoryMap lists the files of the repository that are not ignored by .gitignore
+// together with their top-level exported symbols
+func (s *AnalyzerService) buildRepositoryMap(repoPath string) *models.RepositoryMap {
+	repoMap := &models.RepositoryMap{Files: []models.MapFile{}}
+	ignore := &gitignore{}
+
+	err := filepath.Walk(repoPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, _ := filepath.Rel(repoPath, filePath)
+		relPath = filepath.ToSlash(relPath)
+		if relPath == "." {
+			ignore.load(repoPath, "")
+			return nil
+		}
+
+		// Hidden files and dependency directories are left out like in the analysis
+		name := info.Name()
+		skipDir := name == "node_modules" || name == "vendor" || name == "venv" || name == "__pycache__"
+		if strings.HasPrefix(name, ".") || (info.IsDir() && skipDir) || ignore.ignored(relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			ignore.load(repoPath, relPath)
+			return nil
+		}
+
+		if len(repoMap.Files) == maxRepoMapFiles {
+			repoMap.Truncated = true
+			return filepath.SkipAll
+		}
+
+		file := models.MapFile{Path: relPath, Language: languageExtensions[filepath.Ext(name)]}
+		if info.Size() <= maxSymbolSourceSize {
+			file.Symbols = extractSymbols(filePath, name)
+		}
+		repoMap.Files = append(repoMap.Files, file)
+
+		return nil
+	})
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to build repository map")
+	}
+
+	sort.Slice(repoMap.Files, func(i, j int) bool {
+		return repoMap.Files[i].Path < repoMap.Files[j].Path
+	})
+
+	content, truncated := RenderRepositoryMap(repoMap, DefaultRepoMapTokens)
+	repoMap.Content = content
+	repoMap.Truncated = repoMap.Truncated || truncated
+
+	return repoMap
+}
+
+// extractSymbols returns the top-level exported symbols of a source file
+func extractSymbols(filePath, name string) []string {
+	ext := filepath.Ext(name)
+	switch {
+	case ext == ".go" && !strings.HasSuffix(name, "_test.go"):
+		return goSymbols(filePath)
+	case contains(scriptExtensions, ext):
+		return regexSymbols(filePath, jsExportPattern)
+	case ext == ".py" && !strings.HasPrefix(name, "test_"):
+		return regexSymbols(filePath, pythonSymbolPattern)
+	}
+	return nil
+}
+
+// goSymbols parses a Go file and returns its exported types, functions and methods
+func goSymbols(filePath string) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	symbols := []string{}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				symbols = append(symbols, "func "+decl.Name.Name)
+				continue
+			}
+			if receiver := receiverTypeName(decl.Recv.List[0].Type); ast.IsExported(receiver) {
+				symbols = append(symbols, receiver+"."+decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok || !typeSpec.Name.IsExported() {
+					continue
+				}
+				switch typeSpec.Type.(type) {
+				case *ast.StructType:
+					symbols = append(symbols, "type "+typeSpec.Name.Name+" struct")
+				case *ast.InterfaceType:
+					symbols = append(symbols, "type "+typeSpec.Name.Name+" interface")
+				default:
+					symbols = append(symbols, "type "+typeSpec.Name.Name)
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverTypeName returns the type name of a method receiver such as *T or T[K]
+func receiverTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// regexSymbols returns "<kind> <name>" for every match of a two-group pattern
+func regexSymbols(filePath string, pattern *regexp.Regexp) []string {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+
+	symbols := []string{}
+	for _, match := range pattern.FindAllSubmatch(content, -1) {
+		symbols = append(symbols, string(match[1])+" "+string(match[2]))
+	}
+	return symbols
+}
+
+// RenderRepositoryMap renders the map as an indented tree within a token budget. Symbols per
+// file are capped, then left out, then files collapse into per-directory counts until the
+// map fits; the boolean reports whether anything was left out.
+func RenderRepositoryMap(repoMap *models.RepositoryMap, maxTokens int) (string, bool) {
+	if repoMap == nil || len(repoMap.Files) == 0 {
+		return "", false
+	}
+	if maxTokens <= 0 {
+		maxTokens = DefaultRepoMapTokens
+	}
+	budget := maxTokens * repoMapCharsPerToken
+
+	for _, symbolCap := range repoMapSymbolCaps {
+		content, capped := renderMapTree(repoMap.Files, symbolCap)
+		if len(content) <= budget {
+			return content, capped
+		}
+	}
+
+	content := renderMapDirectories(repoMap.Files)
+	if len(content) > budget {
+		content = content[:budget]
+		if i := strings.LastIndex(content, "\n"); i > 0 {
+			content = content[:i+1]
+		}
+		content += "...\n"
+	}
+	return content, true
+}
+
+// renderMapTree renders files under their directories with at most symbolCap symbols per
+// file (-1 for all) and reports whether symbols were left out
+func renderMapTree(files []models.MapFile, symbolCap int) (string, bool) {
+	var tree strings.Builder
+	capped := false
+	current := []string{}
+
+	for _, file := range files {
+		dirs := strings.Split(path.Dir(file.Path), "/")
+		if dirs[0] == "." {
+			dirs = nil
+		}
+		current = writeMapDirectories(&tree, current, dirs)
+
+		tree.WriteString(strings.Repeat("  ", len(dirs)))
+		tree.WriteString(path.Base(file.Path))
+		symbols := file.Symbols
+		if symbolCap >= 0 && len(symbols) > symbolCap {
+			symbols = symbols[:symbolCap]
+			capped = true
+		}
+		if len(symbols) > 0 {
+			tree.WriteString(": " + strings.Join(symbols, "; "))
+			if len(symbols) < len(file.Symbols) {
+				tree.WriteString(fmt.Sprintf("; +%d more", len(file.Symbols)-len(symbols)))
+			}
+		}
+		tree.WriteString("\n")
+	}
+
+	return tree.String(), capped
+}
+
+// renderMapDirectories renders directories only, with the number of files directly in each
+func renderMapDirectories(files []models.MapFile) string {
+	counts := map[string]int{}
+	dirs := []string{}
+	for _, file := range files {
+		dir := path.Dir(file.Path)
+		if _, ok := counts[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		counts[dir]++
+	}
+	sort.Strings(dirs)
+
+	var tree strings.Builder
+	current := []string{}
+	for _, dir := range dirs {
+		if dir == "." {
+			tree.WriteString(fmt.Sprintf("(%d files)\n", counts[dir]))
+			continue
+		}
+		segments := strings.Split(dir, "/")
+		current = writeMapDirectories(&tree, current, segments[:len(segments)-1])
+		tree.WriteString(strings.Repeat("  ", len(segments)-1))
+		tree.WriteString(fmt.Sprintf("%s/ (%d files)\n", segments[len(segments)-1], counts[dir]))
+		current = segments
+	}
+	return tree.String()
+}
+
+// writeMapDirectories writes the directory lines between the current and the next
+// directory and returns the next one
+func writeMapDirectories(tree *strings.Builder, current, next []string) []string {
+	common := 0
+	for common < len(current) && common < len(next) && current[common] == next[common] {
+		common++
+	}
+	for depth := common; depth < len(next); depth++ {
+		tree.WriteString(strings.Repeat("  ", depth))
+		tree.WriteString(next[depth] + "/\n")
+	}
+	return next
+}
+
+// WithRepositoryMap appends the repository map to a task prompt, rendered within the
+// token budget (0 for the default)
+func WithRepositoryMap(prompt string, repoMap *models.RepositoryMap, maxTokens int) string {
+	content, truncated := RenderRepositoryMap(repoMap, maxTokens)
+	if content == "" {
+		return prompt
+	}
+
+	note := ""
+	if truncated {
+		note = " Some files or symbols are left out to keep it short."
+	}
+	return fmt.Sprintf("%s\n## Repository Map\nFiles of the repository with their exported symbols.%s\n\n```\n%s```\n", prompt, note, content)
+}
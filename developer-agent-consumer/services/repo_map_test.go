@@ -0,0 +1,114 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestBuildRepositoryMap(t *testing.T) {
+	tempDir := writeTestFiles(t, map[string]string{
+		".gitignore": "/build/\n*.log\n!keep.log\n",
+		"go.mod":     "module example.com/app\n\ngo 1.20\n",
+		"services/project_service.go": `package services
+
+type ProjectService struct{}
+
+type projectCache struct{}
+
+type Store interface{ Get() }
+
+func NewProjectService() *ProjectService { return &ProjectService{} }
+
+func (s *ProjectService) GetAll() {}
+
+func (s *ProjectService) load() {}
+
+func helper() {}
+`,
+		"services/project_service_test.go": "package services\n\nfunc TestGetAll() {}\n",
+		"web/.gitignore":                   "dist\n",
+		"web/src/api.ts":                   "export interface Project {}\nexport async function fetchProjects() {}\nexport default class Client {}\nconst local = 1\n",
+		"web/dist/bundle.js":               "export function ignored() {}\n",
+		"scripts/report.py":                "import os\n\nclass Report:\n    def render(self):\n        pass\n\ndef main():\n    pass\n\ndef _private():\n    pass\n",
+		"build/output.bin":                 "binary",
+		"debug.log":                        "ignored",
+		"keep.log":                         "kept",
+	})
+	defer os.RemoveAll(tempDir)
+
+	service := NewAnalyzerService(logrus.New())
+	repoMap := service.buildRepositoryMap(tempDir)
+
+	files := map[string]models.MapFile{}
+	for _, file := range repoMap.Files {
+		files[file.Path] = file
+	}
+	for _, ignored := range []string{"build/output.bin", "debug.log", "web/dist/bundle.js", ".gitignore"} {
+		if _, ok := files[ignored]; ok {
+			t.Errorf("Expected %s to be left out of the map", ignored)
+		}
+	}
+	if _, ok := files["keep.log"]; !ok {
+		t.Error("Expected the negated pattern to keep keep.log")
+	}
+
+	expected := map[string]string{
+		"services/project_service.go":      "type ProjectService struct|type Store interface|func NewProjectService|ProjectService.GetAll",
+		"services/project_service_test.go": "",
+		"web/src/api.ts":                   "interface Project|function fetchProjects|class Client",
+		"scripts/report.py":                "class Report|def main",
+	}
+	for path, symbols := range expected {
+		if got := strings.Join(files[path].Symbols, "|"); got != symbols {
+			t.Errorf("Expected symbols %q for %s, got %q", symbols, path, got)
+		}
+	}
+
+	for _, line := range []string{"services/\n", "  project_service.go: type ProjectService struct; ", "web/\n  src/\n    api.ts: "} {
+		if !strings.Contains(repoMap.Content, line) {
+			t.Errorf("Expected map content to contain %q, got:\n%s", line, repoMap.Content)
+		}
+	}
+	if repoMap.Truncated {
+		t.Error("Did not expect a small map to be truncated")
+	}
+}
+
+func TestRenderRepositoryMap_Budget(t *testing.T) {
+	repoMap := &models.RepositoryMap{}
+	for _, dir := range []string{"handlers", "services", "repositories"} {
+		for _, name := range []string{"project", "repository", "agent_profile", "experiment"} {
+			repoMap.Files = append(repoMap.Files, models.MapFile{
+				Path:    dir + "/" + name + ".go",
+				Symbols: []string{"type A struct", "func NewA", "A.Get", "A.Create", "A.Update", "A.Delete"},
+			})
+		}
+	}
+
+	full, truncated := RenderRepositoryMap(repoMap, 1000)
+	if truncated || !strings.Contains(full, "A.Delete") {
+		t.Errorf("Expected the full map within a large budget, got:\n%s", full)
+	}
+
+	capped, truncated := RenderRepositoryMap(repoMap, 200)
+	if !truncated || len(capped) > 800 || !strings.Contains(capped, "+4 more") {
+		t.Errorf("Expected symbols capped to fit 200 tokens, got %d bytes:\n%s", len(capped), capped)
+	}
+
+	dirs, truncated := RenderRepositoryMap(repoMap, 20)
+	if !truncated || !strings.Contains(dirs, "handlers/ (4 files)") || strings.Contains(dirs, ".go") {
+		t.Errorf("Expected directory counts within 20 tokens, got:\n%s", dirs)
+	}
+
+	prompt := WithRepositoryMap("# Task", repoMap, 20)
+	if !strings.Contains(prompt, "## Repository Map") || !strings.Contains(prompt, "left out") {
+		t.Errorf("Unexpected prompt with repository map:\n%s", prompt)
+	}
+	if WithRepositoryMap("# Task", &models.RepositoryMap{}, 0) != "# Task" {
+		t.Error("Expected an empty map to leave the prompt unchanged")
+	}
+}
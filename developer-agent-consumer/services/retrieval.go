@@ -0,0 +1,390 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	// DefaultRetrievalTopK is the number of relevant files added to the prompt
+	DefaultRetrievalTopK = 5
+
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// Term weights of the indexed fields: a term in the path counts as often as three in the content
+	pathTermWeight   = 3
+	symbolTermWeight = 2
+
+	// Excerpt size
+	excerptLines       = 8
+	maxExcerptLineSize = 160
+)
+
+var (
+	wordPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*`)
+
+	// Files indexed besides the source files of languageExtensions
+	indexedExtensions = []string{
+		".tsx", ".jsx", ".mjs", ".cjs", ".kt", ".scala", ".swift", ".vue", ".svelte",
+		".md", ".sql", ".proto", ".graphql", ".yaml", ".yml", ".html", ".css", ".scss", ".sh",
+	}
+
+	// Words too common in issues and code to tell files apart
+	retrievalStopwords = map[string]bool{
+		"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+		"can": true, "do": true, "for": true, "from": true, "has": true, "have": true, "if": true, "in": true,
+		"into": true, "is": true, "it": true, "its": true, "not": true, "of": true, "on": true, "or": true,
+		"should": true, "so": true, "than": true, "that": true, "the": true, "their": true, "then": true,
+		"there": true, "these": true, "this": true, "to": true, "we": true, "when": true, "which": true,
+		"will": true, "with": true, "would": true, "all": true, "also": true, "any": true, "each": true,
+		"new": true, "use": true, "used": true, "using": true, "make": true, "need": true, "want": true,
+		"func": true, "return": true, "else": true, "var": true, "const": true, "let": true, "nil": true,
+		"null": true, "true": true, "false": true, "import": true, "package": true, "def": true, "self": true,
+		"err": true, "string": true, "int": true, "type": true, "struct": true, "class": true, "function": true,
+	}
+)
+
+// lexicalDocument is an indexed file
+type lexicalDocument struct {
+	file   models.MapFile
+	terms  map[string]int // Weighted term frequencies
+	length int
+}
+
+// LexicalIndex is a BM25 index over the paths, symbols, identifiers and comments of the
+// repository files
+type LexicalIndex struct {
+	repoPath  string
+	documents []lexicalDocument
+	docFreq   map[string]int
+	avgLength float64
+}
+
+// lexicalIndexJSON is the encoding of a LexicalIndex in the analysis cache
+type lexicalIndexJSON struct {
+	Documents []lexicalDocumentJSON `json:"documents"`
+	DocFreq   map[string]int        `json:"doc_freq"`
+	AvgLength float64               `json:"avg_length"`
+}
+
+type lexicalDocumentJSON struct {
+	File   models.MapFile `json:"file"`
+	Terms  map[string]int `json:"terms"`
+	Length int            `json:"length"`
+}
+
+// MarshalJSON encodes the index without its repository path
+func (idx *LexicalIndex) MarshalJSON() ([]byte, error) {
+	encoded := lexicalIndexJSON{
+		Documents: make([]lexicalDocumentJSON, 0, len(idx.documents)),
+		DocFreq:   idx.docFreq,
+		AvgLength: idx.avgLength,
+	}
+	for _, document := range idx.documents {
+		encoded.Documents = append(encoded.Documents, lexicalDocumentJSON{
+			File:   document.file,
+			Terms:  document.terms,
+			Length: document.length,
+		})
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes an index encoded by MarshalJSON
+func (idx *LexicalIndex) UnmarshalJSON(data []byte) error {
+	decoded := lexicalIndexJSON{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	idx.documents = make([]lexicalDocument, 0, len(decoded.Documents))
+	for _, document := range decoded.Documents {
+		idx.documents = append(idx.documents, lexicalDocument{
+			file:   document.File,
+			terms:  document.Terms,
+			length: document.Length,
+		})
+	}
+	idx.docFreq = decoded.DocFreq
+	if idx.docFreq == nil {
+		idx.docFreq = map[string]int{}
+	}
+	idx.avgLength = decoded.AvgLength
+	return nil
+}
+
+// BuildLexicalIndex indexes the source and documentation files of the repository map
+func BuildLexicalIndex(repoPath string, files []models.MapFile) *LexicalIndex {
+	index := &LexicalIndex{repoPath: repoPath, docFreq: map[string]int{}}
+	totalLength := 0
+
+	for _, file := range files {
+		ext := filepath.Ext(file.Path)
+		if _, ok := languageExtensions[ext]; !ok && !contains(indexedExtensions, ext) {
+			continue
+		}
+
+		fullPath := filepath.Join(repoPath, filepath.FromSlash(file.Path))
+		info, err := os.Stat(fullPath)
+		if err != nil || info.Size() > maxSymbolSourceSize {
+			continue
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			continue
+		}
+
+		document := lexicalDocument{file: file, terms: map[string]int{}}
+		addTerms := func(text string, weight int) {
+			for _, term := range tokenize(text) {
+				document.terms[term] += weight
+				document.length += weight
+			}
+		}
+		addTerms(file.Path, pathTermWeight)
+		addTerms(strings.Join(file.Symbols, " "), symbolTermWeight)
+		addTerms(string(content), 1)
+		if document.length == 0 {
+			continue
+		}
+
+		for term := range document.terms {
+			index.docFreq[term]++
+		}
+		totalLength += document.length
+		index.documents = append(index.documents, document)
+	}
+
+	if len(index.documents) > 0 {
+		index.avgLength = float64(totalLength) / float64(len(index.documents))
+	}
+	return index
+}
+
+// Search ranks the indexed files against the query terms and returns the best k with
+// the matching symbols and an excerpt of each
+func (idx *LexicalIndex) Search(terms []string, k int) []models.RetrievedFile {
+	type scored struct {
+		document *lexicalDocument
+		score    float64
+	}
+
+	n := float64(len(idx.documents))
+	results := []scored{}
+	for i := range idx.documents {
+		document := &idx.documents[i]
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(document.terms[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(document.length)/idx.avgLength)
+			score += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+		if score > 0 {
+			results = append(results, scored{document: document, score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].document.file.Path < results[j].document.file.Path
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+
+	files := make([]models.RetrievedFile, 0, len(results))
+	for _, result := range results {
+		file := models.RetrievedFile{
+			Path:    result.document.file.Path,
+			Score:   math.Round(result.score*1000) / 1000,
+			Symbols: matchingSymbols(result.document.file.Symbols, terms),
+		}
+		file.Excerpt, file.StartLine = idx.excerpt(file.Path, terms)
+		files = append(files, file)
+	}
+	return files
+}
+
+// excerpt returns the window of lines containing the most query terms
+func (idx *LexicalIndex) excerpt(path string, terms []string) (string, int) {
+	content, err := os.ReadFile(filepath.Join(idx.repoPath, filepath.FromSlash(path)))
+	if err != nil {
+		return "", 0
+	}
+
+	lines := strings.Split(string(content), "\n")
+	hits := make([]int, len(lines))
+	for i, line := range lines {
+		for _, term := range tokenize(line) {
+			if contains(terms, term) {
+				hits[i]++
+			}
+		}
+	}
+
+	best, bestHits, windowHits := 0, 0, 0
+	for i := range lines {
+		windowHits += hits[i]
+		if i >= excerptLines {
+			windowHits -= hits[i-excerptLines]
+		}
+		if windowHits > bestHits {
+			best, bestHits = i-excerptLines+1, windowHits
+		}
+	}
+	if bestHits == 0 {
+		return "", 0
+	}
+	if best < 0 {
+		best = 0
+	}
+
+	end := best + excerptLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+	excerpt := make([]string, 0, end-best)
+	for _, line := range lines[best:end] {
+		line = strings.TrimRight(line, " \t\r")
+		if len(line) > maxExcerptLineSize {
+			line = line[:maxExcerptLineSize] + "..."
+		}
+		excerpt = append(excerpt, line)
+	}
+	return strings.Join(excerpt, "\n"), best + 1
+}
+
+// matchingSymbols returns the symbols sharing a term with the query
+func matchingSymbols(symbols, terms []string) []string {
+	matching := []string{}
+	for _, symbol := range symbols {
+		for _, term := range tokenize(symbol) {
+			if contains(terms, term) {
+				matching = append(matching, symbol)
+				break
+			}
+		}
+	}
+	return matching
+}
+
+// RetrieveRelevantFiles ranks the files of the index, built from the repository map of the
+// analysis, against the issue summary, description and labels. The best topK files are
+// stored in the analysis.
+func (s *AnalyzerService) RetrieveRelevantFiles(index *LexicalIndex, analysis *models.RepositoryAnalysis, request *models.DevelopmentRequest, topK int) *models.Retrieval {
+	if topK <= 0 {
+		topK = DefaultRetrievalTopK
+	}
+
+	terms := uniqueTerms(request.Summary + "\n" + request.Description + "\n" + strings.Join(request.Labels, " "))
+	retrieval := &models.Retrieval{
+		Terms:     terms,
+		Files:     []models.RetrievedFile{},
+		CreatedAt: time.Now(),
+	}
+	if index == nil || len(terms) == 0 {
+		return retrieval
+	}
+
+	retrieval.Files = index.Search(terms, topK)
+	analysis.RelevantFiles = retrieval.Files
+
+	s.logger.WithFields(logrus.Fields{
+		"indexed_files": len(index.documents),
+		"terms":         len(terms),
+		"retrieved":     len(retrieval.Files),
+	}).Info("Ranked repository files against the issue")
+
+	return retrieval
+}
+
+// RetrievalHits counts the retrieved files among the changed files
+func RetrievalHits(retrieval *models.Retrieval, changedFiles []string) int {
+	hits := 0
+	for _, file := range retrieval.Files {
+		if contains(changedFiles, file.Path) {
+			hits++
+		}
+	}
+	return hits
+}
+
+// tokenize splits text into lowercase terms: identifiers are split on camelCase and digits
+// boundaries, stopwords are dropped and plurals reduced
+func tokenize(text string) []string {
+	terms := []string{}
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			parts = append(parts, word)
+		}
+		for _, part := range parts {
+			term := stemTerm(strings.ToLower(part))
+			if len(term) < 2 || retrievalStopwords[term] {
+				continue
+			}
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// uniqueTerms tokenizes text and keeps the first occurrence of each term
+func uniqueTerms(text string) []string {
+	terms := []string{}
+	for _, term := range tokenize(text) {
+		if !contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// splitIdentifier splits camelCase and PascalCase identifiers, keeping acronyms together:
+// "HTTPServerConfig" becomes "HTTP", "Server", "Config"
+func splitIdentifier(word string) []string {
+	runes := []rune(word)
+	parts := []string{}
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// stemTerm reduces English plurals so that "projects" matches "project"
+func stemTerm(term string) string {
+	switch {
+	case len(term) > 4 && strings.HasSuffix(term, "ies"):
+		return term[:len(term)-3] + "y"
+	case len(term) > 4 && (strings.HasSuffix(term, "ches") || strings.HasSuffix(term, "shes") || strings.HasSuffix(term, "sses") || strings.HasSuffix(term, "xes")):
+		return term[:len(term)-2]
+	case len(term) > 3 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss") && !strings.HasSuffix(term, "us"):
+		return term[:len(term)-1]
+	}
+	return term
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestRetrieveRelevantFiles(t *testing.T) {
+	tempDir := writeTestFiles(t, map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.20\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+		"handlers/webhook_handler.go": `package handlers
+
+// WebhookHandler receives JIRA webhooks
+type WebhookHandler struct{}
+
+// HandleWebhook parses the issue payload
+func (h *WebhookHandler) HandleWebhook() {}
+`,
+		"services/retry_service.go": `package services
+
+import "time"
+
+// RetryPolicy controls how often a failed git push is retried
+type RetryPolicy struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+func (p RetryPolicy) Next(attempt int) time.Duration {
+	return p.Backoff * time.Duration(attempt)
+}
+
+// PushWithRetry pushes the branch, retrying with exponential backoff
+func PushWithRetry(policy RetryPolicy) error {
+	return nil
+}
+`,
+		"services/git_service.go": "package services\n\n// GitService clones repositories and pushes branches\ntype GitService struct{}\n\nfunc (s *GitService) PushBranch() error { return nil }\n",
+		"README.md":               "# App\n\nProcesses webhooks.\n",
+	})
+	defer os.RemoveAll(tempDir)
+
+	service := NewAnalyzerService(logrus.New())
+	analysis, err := service.AnalyzeRepository(tempDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyze repository: %v", err)
+	}
+
+	request := &models.DevelopmentRequest{
+		Summary:     "Retry failed git pushes",
+		Description: "Pushing the branch sometimes fails. Retry with a backoff before failing the development.",
+		Labels:      []string{"reliability"},
+	}
+	retrieval := service.RetrieveRelevantFiles(BuildLexicalIndex(tempDir, analysis.RepoMap.Files), analysis, request, 2)
+
+	if !contains(retrieval.Terms, "retry") || !contains(retrieval.Terms, "push") || contains(retrieval.Terms, "the") {
+		t.Errorf("Unexpected query terms %v", retrieval.Terms)
+	}
+	if len(retrieval.Files) != 2 {
+		t.Fatalf("Expected 2 retrieved files, got %+v", retrieval.Files)
+	}
+
+	top := retrieval.Files[0]
+	if top.Path != "services/retry_service.go" {
+		t.Errorf("Expected the retry service to rank first, got %+v", retrieval.Files)
+	}
+	if !contains(top.Symbols, "func PushWithRetry") || !contains(top.Symbols, "type RetryPolicy struct") {
+		t.Errorf("Expected matching symbols, got %v", top.Symbols)
+	}
+	if top.StartLine == 0 || !strings.Contains(top.Excerpt, "func PushWithRetry") {
+		t.Errorf("Expected an excerpt around the retrying push, got line %d:\n%s", top.StartLine, top.Excerpt)
+	}
+	if retrieval.Files[1].Path != "services/git_service.go" {
+		t.Errorf("Expected the git service to rank second, got %+v", retrieval.Files)
+	}
+	if len(analysis.RelevantFiles) != 2 {
+		t.Error("Expected the ranking to be stored on the analysis")
+	}
+
+	if hits := RetrievalHits(retrieval, []string{"services/retry_service.go", "services/retry_service_test.go"}); hits != 1 {
+		t.Errorf("Expected 1 hit, got %d", hits)
+	}
+
+	prompt := NewClaudeService("claude", logrus.New()).BuildPrompt(request, &models.Project{}, analysis)
+	if !strings.Contains(prompt, "## Relevant Files\n") || !strings.Contains(prompt, "- `services/retry_service.go`: ") {
+		t.Errorf("Expected relevant files in the prompt, got:\n%s", prompt)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := map[string]string{
+		"HTTPServerConfig":              "http server config httpserverconfig",
+		"get_user_repositories":         "get user repository",
+		"// Retry the pushes":           "retry push",
+		"parseJSONBody returns the err": "parse json body parsejsonbody",
+	}
+
+	for text, expected := range tests {
+		if got := strings.Join(tokenize(text), " "); got != expected {
+			t.Errorf("tokenize(%q) = %q, expected %q", text, got, expected)
+		}
+	}
+}
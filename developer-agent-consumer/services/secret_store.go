@@ -0,0 +1,40 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSecretsDir is where Docker and Kubernetes mount secrets
+const DefaultSecretsDir = "/run/secrets"
+
+// SecretStore reads secrets mounted as files, one file per secret. The Configuration API
+// only stores secret names, so keys never leave the consumer's environment.
+type SecretStore struct {
+	dir string
+}
+
+func NewSecretStore(dir string) *SecretStore {
+	if dir == "" {
+		dir = DefaultSecretsDir
+	}
+	return &SecretStore{dir: dir}
+}
+
+// Get returns the content of a secret
+func (s *SecretStore) Get(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+
+	content, err := os.ReadFile(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("secret %q not found in %s", name, s.dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %q: %w", name, err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+const (
+	defaultVerificationTimeout = 5 * time.Minute
+	maxVerificationOutput      = 16 * 1024
+)
+
+type VerifierService struct {
+	logger *logrus.Logger
+}
+
+func NewVerifierService(logger *logrus.Logger) *VerifierService {
+	return &VerifierService{
+		logger: logger,
+	}
+}
+
+// ResolvePolicy returns the verification policy for a repository. Verification runs the
+// repository's setup commands and test suite, so repositories opt in by setting a policy;
+// it is disabled by default.
+func ResolvePolicy(settings *models.VerificationSettings) string {
+	if settings == nil || settings.Policy == "" {
+		return models.VerificationPolicyDisabled
+	}
+	return settings.Policy
+}
+
+// ResolveCommands merges the commands detected by the analyzer with per-repository
+// overrides. An override for a stage replaces every detected command of that stage.
+func ResolveCommands(analysis *models.RepositoryAnalysis, settings *models.VerificationSettings) []models.VerificationCommand {
+	overrides := map[string][]string{}
+	if settings != nil {
+		overrides[models.VerificationStageSetup] = settings.SetupCommands
+		overrides[models.VerificationStageBuild] = settings.BuildCommands
+		overrides[models.VerificationStageLint] = settings.LintCommands
+		overrides[models.VerificationStageTest] = settings.TestCommands
+	}
+
+	stages := []string{
+		models.VerificationStageSetup,
+		models.VerificationStageBuild,
+		models.VerificationStageLint,
+		models.VerificationStageTest,
+	}
+
+	commands := []models.VerificationCommand{}
+	for _, stage := range stages {
+		if len(overrides[stage]) > 0 {
+			for _, command := range overrides[stage] {
+				commands = append(commands, models.VerificationCommand{Stage: stage, Command: command, Dir: "."})
+			}
+			continue
+		}
+		for _, command := range analysis.VerificationCommands {
+			if command.Stage == stage {
+				commands = append(commands, command)
+			}
+		}
+	}
+
+	return commands
+}
+
+// Verify runs the verification commands in the workspace and collects their results.
+// Once a setup or build command fails, the remaining commands for the same directory are skipped.
+func (s *VerifierService) Verify(repoPath string, commands []models.VerificationCommand, settings *models.VerificationSettings) *models.VerificationResult {
+	timeout := defaultVerificationTimeout
+	if settings != nil && settings.TimeoutSeconds > 0 {
+		timeout = time.Duration(settings.TimeoutSeconds) * time.Second
+	}
+
+	result := &models.VerificationResult{
+		Passed:    true,
+		Policy:    ResolvePolicy(settings),
+		Steps:     []models.VerificationStep{},
+		StartedAt: time.Now(),
+	}
+
+	brokenDirs := map[string]bool{}
+	for _, command := range commands {
+		dir := command.Dir
+		if dir == "" {
+			dir = "."
+		}
+
+		if brokenDirs[dir] {
+			result.Steps = append(result.Steps, models.VerificationStep{
+				Stage:   command.Stage,
+				Command: command.Command,
+				Dir:     dir,
+				Skipped: true,
+			})
+			continue
+		}
+
+		step := s.runStep(repoPath, dir, command, timeout)
+		result.Steps = append(result.Steps, step)
+
+		if !step.Passed {
+			result.Passed = false
+			if command.Stage == models.VerificationStageSetup || command.Stage == models.VerificationStageBuild {
+				brokenDirs[dir] = true
+			}
+		}
+	}
+
+	result.CompletedAt = time.Now()
+
+	s.logger.WithFields(logrus.Fields{
+		"passed":   result.Passed,
+		"steps":    len(result.Steps),
+		"duration": result.CompletedAt.Sub(result.StartedAt).String(),
+	}).Info("Verification complete")
+
+	return result
+}
+
+func (s *VerifierService) runStep(repoPath, dir string, command models.VerificationCommand, timeout time.Duration) models.VerificationStep {
+	s.logger.WithFields(logrus.Fields{
+		"stage":   command.Stage,
+		"command": command.Command,
+		"dir":     dir,
+	}).Info("Running verification command")
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "bash", "-c", command.Command)
+	cmd.Dir = filepath.Join(repoPath, dir)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	// Run in its own process group so a timeout also kills child processes
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+
+	step := models.VerificationStep{
+		Stage:      command.Stage,
+		Command:    command.Command,
+		Dir:        dir,
+		Passed:     err == nil,
+		DurationMs: time.Since(start).Milliseconds(),
+		Output:     tailOutput(output.String(), maxVerificationOutput),
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			step.ExitCode = exitErr.ExitCode()
+		} else {
+			step.ExitCode = -1
+			step.Output = tailOutput(step.Output+"\n"+err.Error(), maxVerificationOutput)
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			step.TimedOut = true
+		}
+	}
+
+	return step
+}
+
+// FormatVerificationSummary renders verification results as a markdown section for PR bodies
+func FormatVerificationSummary(result *models.VerificationResult) string {
+	if result == nil {
+		return ""
+	}
+
+	var summary strings.Builder
+
+	status := "Passed"
+	if !result.Passed {
+		status = "Failed"
+	}
+	summary.WriteString(fmt.Sprintf("## Verification: %s\n\n", status))
+
+	if len(result.Steps) == 0 {
+		summary.WriteString("No build, lint or test commands were detected.\n")
+		return summary.String()
+	}
+
+	summary.WriteString("| Stage | Command | Directory | Result | Duration |\n")
+	summary.WriteString("|-------|---------|-----------|--------|----------|\n")
+	for _, step := range result.Steps {
+		summary.WriteString(fmt.Sprintf("| %s | `%s` | `%s` | %s | %s |\n",
+			step.Stage,
+			step.Command,
+			step.Dir,
+			stepStatus(step),
+			(time.Duration(step.DurationMs) * time.Millisecond).String(),
+		))
+	}
+
+	for _, step := range result.Steps {
+		if step.Passed || step.Skipped || step.Output == "" {
+			continue
+		}
+		summary.WriteString(fmt.Sprintf("\n<details><summary>Output of <code>%s</code></summary>\n\n```\n%s\n```\n</details>\n",
+			step.Command, tailOutput(step.Output, 4000)))
+	}
+
+	return summary.String()
+}
+
+// FailedStepsSummary lists the failed verification commands on a single line
+func FailedStepsSummary(result *models.VerificationResult) string {
+	failed := []string{}
+	for _, step := range result.Steps {
+		if !step.Passed && !step.Skipped {
+			failed = append(failed, fmt.Sprintf("%s `%s` (%s)", step.Stage, step.Command, stepStatus(step)))
+		}
+	}
+	return strings.Join(failed, ", ")
+}
+
+func stepStatus(step models.VerificationStep) string {
+	switch {
+	case step.Skipped:
+		return "skipped"
+	case step.TimedOut:
+		return "timed out"
+	case step.Passed:
+		return "passed"
+	default:
+		return fmt.Sprintf("failed (exit %d)", step.ExitCode)
+	}
+}
+
+// tailOutput keeps the last max bytes of command output
+func tailOutput(output string, max int) string {
+	if len(output) <= max {
+		return output
+	}
+	return "...(truncated)...\n" + output[len(output)-max:]
+}
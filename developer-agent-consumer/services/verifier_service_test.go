@@ -0,0 +1,94 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/storos/sdlc-agent/developer-agent-consumer/models"
+)
+
+func TestVerify_SkipsDirectoryAfterBuildFailure(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-verify-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	service := NewVerifierService(logrus.New())
+	commands := []models.VerificationCommand{
+		{Stage: models.VerificationStageBuild, Command: "echo building && exit 2", Dir: "."},
+		{Stage: models.VerificationStageTest, Command: "echo testing", Dir: "."},
+	}
+
+	result := service.Verify(tempDir, commands, &models.VerificationSettings{Policy: models.VerificationPolicyAnnotate})
+
+	if result.Passed {
+		t.Fatal("Expected verification to fail")
+	}
+	if result.Policy != models.VerificationPolicyAnnotate {
+		t.Errorf("Expected policy annotate, got %s", result.Policy)
+	}
+	if len(result.Steps) != 2 {
+		t.Fatalf("Expected 2 steps, got %d", len(result.Steps))
+	}
+	if result.Steps[0].ExitCode != 2 || !strings.Contains(result.Steps[0].Output, "building") {
+		t.Errorf("Unexpected build step: %+v", result.Steps[0])
+	}
+	if !result.Steps[1].Skipped {
+		t.Error("Expected test step to be skipped after build failure")
+	}
+}
+
+func TestResolvePolicy_DisabledByDefault(t *testing.T) {
+	if policy := ResolvePolicy(nil); policy != models.VerificationPolicyDisabled {
+		t.Errorf("Expected verification to be disabled without settings, got %s", policy)
+	}
+	if policy := ResolvePolicy(&models.VerificationSettings{TestCommands: []string{"make test"}}); policy != models.VerificationPolicyDisabled {
+		t.Errorf("Expected verification to be disabled without a policy, got %s", policy)
+	}
+	if policy := ResolvePolicy(&models.VerificationSettings{Policy: models.VerificationPolicyDraft}); policy != models.VerificationPolicyDraft {
+		t.Errorf("Expected the configured policy, got %s", policy)
+	}
+}
+
+func TestVerify_Timeout(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test-verify-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	service := NewVerifierService(logrus.New())
+	commands := []models.VerificationCommand{
+		{Stage: models.VerificationStageTest, Command: "sleep 30", Dir: "."},
+	}
+
+	result := service.Verify(tempDir, commands, &models.VerificationSettings{TimeoutSeconds: 1})
+
+	if result.Passed || !result.Steps[0].TimedOut {
+		t.Errorf("Expected timed out step, got %+v", result.Steps[0])
+	}
+}
+
+func TestResolveCommands_OverridesStage(t *testing.T) {
+	analysis := &models.RepositoryAnalysis{
+		VerificationCommands: []models.VerificationCommand{
+			{Stage: models.VerificationStageBuild, Command: "go build ./...", Dir: "."},
+			{Stage: models.VerificationStageTest, Command: "go test ./...", Dir: "."},
+		},
+	}
+	settings := &models.VerificationSettings{
+		TestCommands: []string{"make test"},
+	}
+
+	commands := ResolveCommands(analysis, settings)
+
+	if len(commands) != 2 {
+		t.Fatalf("Expected 2 commands, got %v", commands)
+	}
+	if commands[0].Command != "go build ./..." || commands[1].Command != "make test" {
+		t.Errorf("Unexpected commands: %v", commands)
+	}
+}
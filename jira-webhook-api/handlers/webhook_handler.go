@@ -55,6 +55,23 @@ func (h *WebhookHandler) HandleWebhook(c *gin.Context) {
 			return
 		}
 
+		// Comments without an agent command are ignored as well
+		if err == services.ErrNotACommand {
+			h.logger.Debug("Webhook ignored - comment is not a command")
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Webhook received but ignored (comment is not a command)",
+			})
+			return
+		}
+
+		// So are commands from users who may not run them
+		if err == services.ErrCommandNotAllowed {
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Webhook received but ignored (comment author may not run commands)",
+			})
+			return
+		}
+
 		// Log and return error for other cases
 		h.logger.WithError(err).Error("Failed to process webhook")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -8,11 +9,12 @@ import (
 
 // JiraWebhookPayload represents the incoming JIRA webhook
 type JiraWebhookPayload struct {
-	WebhookEvent   string      `json:"webhookEvent"`
-	IssueEventType string      `json:"issue_event_type_name"`
-	Issue          JiraIssue   `json:"issue"`
-	Changelog      *Changelog  `json:"changelog,omitempty"`
-	User           *JiraUser   `json:"user,omitempty"`
+	WebhookEvent   string       `json:"webhookEvent"`
+	IssueEventType string       `json:"issue_event_type_name"`
+	Issue          JiraIssue    `json:"issue"`
+	Changelog      *Changelog   `json:"changelog,omitempty"`
+	User           *JiraUser    `json:"user,omitempty"`
+	Comment        *JiraComment `json:"comment,omitempty"` // Set for comment_created events
 }
 
 // JiraIssue represents JIRA issue details
@@ -25,11 +27,14 @@ type JiraIssue struct {
 
 // JiraIssueFields contains issue field data
 type JiraIssueFields struct {
-	Summary     string       `json:"summary"`
-	Description string       `json:"description"`
-	Status      JiraStatus   `json:"status"`
-	Project     JiraProject  `json:"project"`
+	Summary     string        `json:"summary"`
+	Description string        `json:"description"`
+	Status      JiraStatus    `json:"status"`
+	Project     JiraProject   `json:"project"`
 	IssueType   JiraIssueType `json:"issuetype"`
+	Labels      []string      `json:"labels,omitempty"`
+	Assignee    *JiraUser     `json:"assignee,omitempty"`
+	Reporter    *JiraUser     `json:"reporter,omitempty"`
 }
 
 // JiraStatus represents issue status
@@ -52,11 +57,121 @@ type JiraIssueType struct {
 
 // JiraUser represents user information
 type JiraUser struct {
+	AccountID    string `json:"accountId,omitempty"` // JIRA Cloud
 	Name         string `json:"name"`
 	EmailAddress string `json:"emailAddress"`
 	DisplayName  string `json:"displayName"`
 }
 
+// sameUser reports whether two users are the same JIRA user, compared by account ID
+// (JIRA Cloud) or user name (JIRA Server)
+func (u *JiraUser) sameUser(other *JiraUser) bool {
+	if u == nil || other == nil {
+		return false
+	}
+	if u.AccountID != "" || other.AccountID != "" {
+		return u.AccountID == other.AccountID
+	}
+	return u.Name != "" && u.Name == other.Name
+}
+
+// listedIn reports whether the account ID, user name or email address of the user is in the list
+func (u *JiraUser) listedIn(users []string) bool {
+	for _, user := range users {
+		if user == "" {
+			continue
+		}
+		if user == u.AccountID || user == u.Name || strings.EqualFold(user, u.EmailAddress) {
+			return true
+		}
+	}
+	return false
+}
+
+// JiraComment represents a comment on an issue
+type JiraComment struct {
+	ID     string    `json:"id"`
+	Body   string    `json:"body"`
+	Author *JiraUser `json:"author,omitempty"`
+}
+
+// Actions forwarded to the developer agent consumer for command comments
+const (
+	ActionApprovePlan    = "approve_plan"
+	ActionRejectPlan     = "reject_plan"
+	ActionAnswerQuestion = "answer_question"
+)
+
+// commentCommands maps comment commands to consumer actions
+var commentCommands = map[string]string{
+	"/approve": ActionApprovePlan,
+	"/reject":  ActionRejectPlan,
+	"/answer":  ActionAnswerQuestion,
+}
+
+// Command parses an agent command (e.g. "/reject use Redis instead") from the comment body.
+// Returns an empty action when the comment does not start with a known command.
+func (c *JiraComment) Command() (action, argument string) {
+	body := strings.TrimSpace(c.Body)
+	command := body
+	if i := strings.IndexAny(body, " \t\n"); i >= 0 {
+		command = body[:i]
+		argument = strings.TrimSpace(body[i:])
+	}
+
+	action, ok := commentCommands[strings.ToLower(command)]
+	if !ok {
+		return "", ""
+	}
+	return action, argument
+}
+
+// CommentAuthorAllowed reports whether the author of the comment may run agent commands on
+// the issue: its reporter, its assignee, or a user in the allowlist (account IDs, user names
+// or email addresses)
+func (p *JiraWebhookPayload) CommentAuthorAllowed(allowlist []string) bool {
+	if p.Comment == nil || p.Comment.Author == nil {
+		return false
+	}
+	author := p.Comment.Author
+	return author.sameUser(p.Issue.Fields.Reporter) ||
+		author.sameUser(p.Issue.Fields.Assignee) ||
+		author.listedIn(allowlist)
+}
+
+// AuthorName returns the display name of the comment author
+func (c *JiraComment) AuthorName() string {
+	if c.Author == nil {
+		return ""
+	}
+	if c.Author.DisplayName != "" {
+		return c.Author.DisplayName
+	}
+	return c.Author.Name
+}
+
+// Assignee identifies the user an issue is assigned to. The consumer maps it to the
+// co-author of its commits.
+type Assignee struct {
+	AccountID   string `bson:"account_id,omitempty" json:"account_id,omitempty"`
+	Name        string `bson:"name,omitempty" json:"name,omitempty"`
+	Email       string `bson:"email,omitempty" json:"email,omitempty"`
+	DisplayName string `bson:"display_name,omitempty" json:"display_name,omitempty"`
+}
+
+// IssueAssignee returns the assignee of the issue, or nil when it is unassigned
+func (f *JiraIssueFields) IssueAssignee() *Assignee {
+	if f.Assignee == nil {
+		return nil
+	}
+	return &Assignee{
+		AccountID:   f.Assignee.AccountID,
+		Name:        f.Assignee.Name,
+		Email:       f.Assignee.EmailAddress,
+		DisplayName: f.Assignee.DisplayName,
+	}
+}
+
 // Changelog represents status change information
 type Changelog struct {
 	Items []ChangelogItem `json:"items"`
@@ -74,26 +189,38 @@ type ChangelogItem struct {
 
 // WebhookEvent represents stored webhook event in MongoDB
 type WebhookEvent struct {
-	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	JiraIssueID      string             `bson:"jira_issue_id" json:"jira_issue_id"`
-	JiraIssueKey     string             `bson:"jira_issue_key" json:"jira_issue_key"`
-	JiraProjectKey   string             `bson:"jira_project_key" json:"jira_project_key"`
-	Summary          string             `bson:"summary" json:"summary"`
-	Description      string             `bson:"description" json:"description"`
-	Status           string             `bson:"status" json:"status"`
-	PreviousStatus   string             `bson:"previous_status" json:"previous_status"`
-	EventType        string             `bson:"event_type" json:"event_type"`
-	ReceivedAt       time.Time          `bson:"received_at" json:"received_at"`
-	ProcessedAt      *time.Time         `bson:"processed_at,omitempty" json:"processed_at,omitempty"`
-	RawPayload       interface{}        `bson:"raw_payload" json:"raw_payload"`
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JiraIssueID    string             `bson:"jira_issue_id" json:"jira_issue_id"`
+	JiraIssueKey   string             `bson:"jira_issue_key" json:"jira_issue_key"`
+	JiraProjectKey string             `bson:"jira_project_key" json:"jira_project_key"`
+	Summary        string             `bson:"summary" json:"summary"`
+	Description    string             `bson:"description" json:"description"`
+	Status         string             `bson:"status" json:"status"`
+	PreviousStatus string             `bson:"previous_status" json:"previous_status"`
+	EventType      string             `bson:"event_type" json:"event_type"`
+	IssueType      string             `bson:"issue_type,omitempty" json:"issue_type,omitempty"`
+	Labels         []string           `bson:"labels,omitempty" json:"labels,omitempty"`
+	Action         string             `bson:"action,omitempty" json:"action,omitempty"`
+	Comment        string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	CommentAuthor  string             `bson:"comment_author,omitempty" json:"comment_author,omitempty"`
+	Assignee       *Assignee          `bson:"assignee,omitempty" json:"assignee,omitempty"`
+	ReceivedAt     time.Time          `bson:"received_at" json:"received_at"`
+	ProcessedAt    *time.Time         `bson:"processed_at,omitempty" json:"processed_at,omitempty"`
+	RawPayload     interface{}        `bson:"raw_payload" json:"raw_payload"`
 }
 
 // DevelopmentRequest represents message sent to RabbitMQ
 type DevelopmentRequest struct {
-	JiraIssueID    string `json:"jira_issue_id"`
-	JiraIssueKey   string `json:"jira_issue_key"`
-	JiraProjectKey string `json:"jira_project_key"`
-	Summary        string `json:"summary"`
-	Description    string `json:"description"`
-	Repository     string `json:"repository,omitempty"`
+	JiraIssueID    string    `json:"jira_issue_id"`
+	JiraIssueKey   string    `json:"jira_issue_key"`
+	JiraProjectKey string    `json:"jira_project_key"`
+	Summary        string    `json:"summary"`
+	Description    string    `json:"description"`
+	IssueType      string    `json:"issue_type,omitempty"`
+	Labels         []string  `json:"labels,omitempty"`
+	Repository     string    `json:"repository,omitempty"`
+	Action         string    `json:"action,omitempty"`         // Set for command comments
+	Comment        string    `json:"comment,omitempty"`        // Comment text following the command
+	CommentAuthor  string    `json:"comment_author,omitempty"` // Display name of the comment author
+	Assignee       *Assignee `json:"assignee,omitempty"`
 }
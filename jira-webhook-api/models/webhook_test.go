@@ -192,3 +192,86 @@ func TestJiraWebhookPayload_NoChangelog(t *testing.T) {
 		t.Errorf("Expected issue key 'PROJ-124', got '%s'", payload.Issue.Key)
 	}
 }
+
+func TestJiraComment_Command(t *testing.T) {
+	tests := []struct {
+		body     string
+		action   string
+		argument string
+	}{
+		{"/approve", ActionApprovePlan, ""},
+		{"  /APPROVE looks good", ActionApprovePlan, "looks good"},
+		{"/reject Use Redis instead\nof a map", ActionRejectPlan, "Use Redis instead\nof a map"},
+		{"/answer EUR only", ActionAnswerQuestion, "EUR only"},
+		{"Please /approve", "", ""},
+		{"/unknown", "", ""},
+	}
+
+	for _, tt := range tests {
+		comment := &JiraComment{Body: tt.body}
+		action, argument := comment.Command()
+		if action != tt.action || argument != tt.argument {
+			t.Errorf("Command(%q) = (%q, %q), expected (%q, %q)", tt.body, action, argument, tt.action, tt.argument)
+		}
+	}
+}
+
+func TestJiraIssueFields_IssueAssignee(t *testing.T) {
+	jsonData := `{
+		"summary": "Assigned Issue",
+		"assignee": {
+			"accountId": "5b10a2844c20165700ede21g",
+			"emailAddress": "jane@example.com",
+			"displayName": "Jane Doe"
+		}
+	}`
+
+	var fields JiraIssueFields
+	if err := json.Unmarshal([]byte(jsonData), &fields); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+
+	assignee := fields.IssueAssignee()
+	if assignee == nil {
+		t.Fatal("Expected an assignee")
+	}
+	if assignee.AccountID != "5b10a2844c20165700ede21g" || assignee.Email != "jane@example.com" || assignee.DisplayName != "Jane Doe" {
+		t.Errorf("Unexpected assignee %+v", assignee)
+	}
+
+	if (&JiraIssueFields{}).IssueAssignee() != nil {
+		t.Error("Expected no assignee for unassigned issues")
+	}
+}
+
+func TestJiraWebhookPayload_CommentAuthorAllowed(t *testing.T) {
+	payload := func(author *JiraUser) *JiraWebhookPayload {
+		return &JiraWebhookPayload{
+			Issue: JiraIssue{Fields: JiraIssueFields{
+				Reporter: &JiraUser{AccountID: "reporter-id", Name: "reporter"},
+				Assignee: &JiraUser{AccountID: "assignee-id", Name: "assignee"},
+			}},
+			Comment: &JiraComment{Body: "/approve", Author: author},
+		}
+	}
+	allowlist := []string{"lead@example.com", "release-bot"}
+
+	tests := []struct {
+		name    string
+		author  *JiraUser
+		allowed bool
+	}{
+		{"reporter", &JiraUser{AccountID: "reporter-id"}, true},
+		{"assignee", &JiraUser{AccountID: "assignee-id"}, true},
+		{"allowlisted email", &JiraUser{AccountID: "lead-id", EmailAddress: "Lead@example.com"}, true},
+		{"allowlisted user name", &JiraUser{Name: "release-bot"}, true},
+		{"other user", &JiraUser{AccountID: "other-id", Name: "reporter"}, false},
+		{"no author", nil, false},
+	}
+
+	for _, tt := range tests {
+		if allowed := payload(tt.author).CommentAuthorAllowed(allowlist); allowed != tt.allowed {
+			t.Errorf("%s: expected allowed=%v, got %v", tt.name, tt.allowed, allowed)
+		}
+	}
+}
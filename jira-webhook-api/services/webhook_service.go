@@ -15,6 +15,8 @@ import (
 var (
 	ErrInvalidPayload       = errors.New("invalid webhook payload")
 	ErrNotInDevelopment     = errors.New("status change is not 'In Development'")
+	ErrNotACommand          = errors.New("comment is not an agent command")
+	ErrCommandNotAllowed    = errors.New("comment author may not run agent commands")
 	ErrRabbitMQNotConnected = errors.New("RabbitMQ connection not available")
 )
 
@@ -24,13 +26,16 @@ type WebhookService struct {
 	rabbitConn     *amqp.Connection
 	rabbitChannel  *amqp.Channel
 	exchangeName   string
+	commandUsers   []string // Users allowed to run comment commands besides the reporter and assignee
 	logger         *logrus.Logger
 }
 
-// NewWebhookService creates a new webhook service
+// NewWebhookService creates a new webhook service. Comment commands are accepted from the
+// issue's reporter and assignee and from the commandUsers (account IDs, user names or emails).
 func NewWebhookService(
 	repo *repositories.WebhookRepository,
 	rabbitConn *amqp.Connection,
+	commandUsers []string,
 	logger *logrus.Logger,
 ) (*WebhookService, error) {
 	channel, err := rabbitConn.Channel()
@@ -43,6 +48,7 @@ func NewWebhookService(
 		rabbitConn:    rabbitConn,
 		rabbitChannel: channel,
 		exchangeName:  "webhook.development.request",
+		commandUsers:  commandUsers,
 		logger:        logger,
 	}
 
@@ -76,6 +82,11 @@ func (s *WebhookService) ProcessWebhook(ctx context.Context, payload *models.Jir
 		return err
 	}
 
+	// Comments can carry commands for a development (e.g. plan approval)
+	if payload.WebhookEvent == "comment_created" {
+		return s.processComment(ctx, payload)
+	}
+
 	// Check if status changed to "In Development"
 	isInDevelopment, previousStatus := s.isStatusChangedToInDevelopment(payload)
 	if !isInDevelopment {
@@ -88,7 +99,7 @@ func (s *WebhookService) ProcessWebhook(ctx context.Context, payload *models.Jir
 		"to_status":   payload.Issue.Fields.Status.Name,
 	}).Info("Detected 'In Development' status change")
 
-	// Store webhook event
+	// Store webhook event and publish it
 	event := &models.WebhookEvent{
 		JiraIssueID:    payload.Issue.ID,
 		JiraIssueKey:   payload.Issue.Key,
@@ -98,9 +109,68 @@ func (s *WebhookService) ProcessWebhook(ctx context.Context, payload *models.Jir
 		Status:         payload.Issue.Fields.Status.Name,
 		PreviousStatus: previousStatus,
 		EventType:      payload.WebhookEvent,
+		IssueType:      payload.Issue.Fields.IssueType.Name,
+		Labels:         payload.Issue.Fields.Labels,
+		Assignee:       payload.Issue.Fields.IssueAssignee(),
+		RawPayload:     payload,
+	}
+
+	if err := s.storeAndPublish(ctx, event); err != nil {
+		return err
+	}
+
+	s.logger.WithField("issue_key", payload.Issue.Key).Info("Webhook processed successfully")
+	return nil
+}
+
+// processComment forwards command comments (/approve, /reject <feedback>, /answer <text>) to the consumer
+func (s *WebhookService) processComment(ctx context.Context, payload *models.JiraWebhookPayload) error {
+	if payload.Comment == nil {
+		return fmt.Errorf("%w: missing comment", ErrInvalidPayload)
+	}
+
+	action, argument := payload.Comment.Command()
+	if action == "" {
+		s.logger.WithField("issue_key", payload.Issue.Key).Debug("Comment is not a command, ignoring")
+		return ErrNotACommand
+	}
+
+	if !payload.CommentAuthorAllowed(s.commandUsers) {
+		s.logger.WithFields(logrus.Fields{
+			"issue_key": payload.Issue.Key,
+			"action":    action,
+			"author":    payload.Comment.AuthorName(),
+		}).Warn("Comment author may not run agent commands, ignoring")
+		return ErrCommandNotAllowed
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"issue_key": payload.Issue.Key,
+		"action":    action,
+	}).Info("Detected command comment")
+
+	event := &models.WebhookEvent{
+		JiraIssueID:    payload.Issue.ID,
+		JiraIssueKey:   payload.Issue.Key,
+		JiraProjectKey: payload.Issue.Fields.Project.Key,
+		Summary:        payload.Issue.Fields.Summary,
+		Description:    payload.Issue.Fields.Description,
+		Status:         payload.Issue.Fields.Status.Name,
+		EventType:      payload.WebhookEvent,
+		IssueType:      payload.Issue.Fields.IssueType.Name,
+		Labels:         payload.Issue.Fields.Labels,
+		Action:         action,
+		Comment:        argument,
+		CommentAuthor:  payload.Comment.AuthorName(),
+		Assignee:       payload.Issue.Fields.IssueAssignee(),
 		RawPayload:     payload,
 	}
 
+	return s.storeAndPublish(ctx, event)
+}
+
+// storeAndPublish stores the webhook event, publishes it to RabbitMQ and marks it as processed
+func (s *WebhookService) storeAndPublish(ctx context.Context, event *models.WebhookEvent) error {
 	if err := s.repo.Create(ctx, event); err != nil {
 		s.logger.WithError(err).Error("Failed to store webhook event")
 		return fmt.Errorf("failed to store webhook event: %w", err)
@@ -120,7 +190,6 @@ func (s *WebhookService) ProcessWebhook(ctx context.Context, payload *models.Jir
 		// Don't return error - message was already published
 	}
 
-	s.logger.WithField("issue_key", payload.Issue.Key).Info("Webhook processed successfully")
 	return nil
 }
 
@@ -178,6 +247,12 @@ func (s *WebhookService) publishToRabbitMQ(ctx context.Context, event *models.We
 		JiraProjectKey: event.JiraProjectKey,
 		Summary:        event.Summary,
 		Description:    event.Description,
+		IssueType:      event.IssueType,
+		Labels:         event.Labels,
+		Action:         event.Action,
+		Comment:        event.Comment,
+		CommentAuthor:  event.CommentAuthor,
+		Assignee:       event.Assignee,
 	}
 
 	// Marshal to JSON